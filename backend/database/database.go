@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"backend/internal/models"
 
@@ -26,6 +27,8 @@ func Init(cfg DatabaseConfig) (*gorm.DB, error) {
 			TablePrefix:   "",
 			SingularTable: false,
 		},
+		// 唯一约束冲突转换为 gorm.ErrDuplicatedKey，任务入队据此判断是否已在队列中
+		TranslateError: true,
 	}
 
 	if cfg.LogMode == "debug" {
@@ -56,6 +59,15 @@ func Migrate(db *gorm.DB) error {
 		&models.Target{},
 		&models.RepoTarget{},
 		&models.Push{},
+		&models.Digest{},
+		&models.Holiday{},
+		&models.HeldMessage{},
+		&models.Job{},
+		&models.DeliveryAttempt{},
+		&models.ReviewIssue{},
+		&models.ReviewComment{},
+		&models.ReviewCache{},
+		&models.ReviewFile{},
 		&models.Template{},
 		&models.Prompt{},
 		&models.PromptHistory{},
@@ -78,6 +90,39 @@ func Migrate(db *gorm.DB) error {
 		}
 	}
 
+	// 数据迁移：提交+推送目标的唯一索引改为按仓库策略生成的去重键
+	if db.Migrator().HasIndex(&models.Push{}, "idx_commit_target") {
+		if err := db.Migrator().DropIndex(&models.Push{}, "idx_commit_target"); err != nil {
+			return fmt.Errorf("failed to drop idx_commit_target: %w", err)
+		}
+	}
+	// 已有记录按原规则（同一提交同一目标）去重
+	db.Model(&models.Push{}).Where("dedup_key = '' OR dedup_key IS NULL").Update("dedup_key", gorm.Expr("commit_id"))
+	db.Model(&models.Repo{}).Where("dedup_policy = '' OR dedup_policy IS NULL").Update("dedup_policy", models.DedupPolicyCommit)
+	// 数据迁移：已有仓库的默认分支进入高优先级通道（清空后保存为空字符串，不会被再次填充）
+	db.Model(&models.Repo{}).Where("priority_branches IS NULL").Update("priority_branches", models.DefaultPriorityBranches)
+
+	// 数据迁移：同一队列中未结束任务的去重键改由部分唯一索引保证
+	if !db.Migrator().HasIndex(&models.Job{}, "idx_job_active_dedup") {
+		active := []string{models.JobStatusQueued, models.JobStatusRunning, models.JobStatusFailed}
+		// 建索引前取消重复的未结束任务，每个去重键保留最早入队的一个
+		first := db.Model(&models.Job{}).Select("MIN(id)").
+			Where("dedup_key <> '' AND status IN ?", active).
+			Group("queue, dedup_key")
+		db.Model(&models.Job{}).
+			Where("dedup_key <> '' AND status IN ? AND id NOT IN (?)", active, first).
+			Updates(map[string]interface{}{
+				"status":           models.JobStatusCanceled,
+				"last_error":       "重复任务",
+				"lease_expires_at": nil,
+				"finished_at":      time.Now(),
+			})
+		if err := db.Exec("CREATE UNIQUE INDEX idx_job_active_dedup ON jobs (queue, dedup_key) " +
+			"WHERE dedup_key <> '' AND status IN ('queued', 'running', 'failed')").Error; err != nil {
+			return fmt.Errorf("failed to create idx_job_active_dedup: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -132,6 +177,26 @@ func InitData(db *gorm.DB) error {
 		fmt.Println("Initial default templates created")
 	}
 
+	// 3. 初始化默认摘要模板（摘要场景为后续新增，已有模板的库也需要补充）
+	var digestCount int64
+	db.Model(&models.Template{}).Where("scene = ?", models.TemplateSceneDigestNotify).Count(&digestCount)
+	if digestCount == 0 {
+		digestTemplate := &models.Template{
+			Name:      "默认推送摘要",
+			Type:      "dingtalk",
+			Scene:     models.TemplateSceneDigestNotify,
+			Title:     "推送摘要",
+			Content:   "### 📬 推送摘要\n\n**推送目标：** {{.TargetName}}\n**时间范围：** {{.PeriodStart}} ~ {{.PeriodEnd}}\n**提交数：** {{.PushCount}}（{{.RepoCount}} 个仓库）\n**审查结论：** 通过 {{.PassCount}}，有问题 {{.ProblemCount}}；问题共 {{.IssueCount}} 个（错误 {{.ErrorCount}}，警告 {{.WarningCount}}）\n\n---\n{{.PushList}}",
+			IsDefault: true,
+			Status:    models.StatusActive,
+			Version:   1,
+		}
+		if err := db.Create(digestTemplate).Error; err != nil {
+			return fmt.Errorf("failed to init digest template: %w", err)
+		}
+		fmt.Println("Initial digest template created")
+	}
+
 	return nil
 }
 
@@ -15,6 +15,9 @@ type Config struct {
 	Logging  LoggingConfig  `mapstructure:"logging"`
 	AI       AIConfig       `mapstructure:"ai"`
 	Webhook  WebhookConfig  `mapstructure:"webhook"`
+	Delivery DeliveryConfig `mapstructure:"delivery"`
+	Queue    QueueConfig    `mapstructure:"queue"`
+	Git      GitConfig      `mapstructure:"git"`
 }
 
 type AppConfig struct {
@@ -22,6 +25,8 @@ type AppConfig struct {
 	Port int    `mapstructure:"port"`
 	Name string `mapstructure:"name"`
 	Env  string `mapstructure:"env"`
+	// ShutdownTimeout 停机时等待请求和队列任务完成的时长（秒），超时未完成的任务重新排队
+	ShutdownTimeout int `mapstructure:"shutdown_timeout"`
 }
 
 type DatabaseConfig struct {
@@ -53,12 +58,62 @@ type LoggingConfig struct {
 type AIConfig struct {
 	DefaultModelID int `mapstructure:"default_model_id"`
 	Timeout        int `mapstructure:"timeout"`
+	// ReviewCacheDays 审查结果缓存的有效期（天），相同的文件差异在有效期内复用审查结果，负数表示不缓存
+	ReviewCacheDays int `mapstructure:"review_cache_days"`
 }
 
 type WebhookConfig struct {
 	SigningKey string `mapstructure:"signing_key"`
 }
 
+type DeliveryConfig struct {
+	// RateLimits 按推送类型配置的默认限流，推送目标可单独覆盖
+	RateLimits map[string]RateLimitConfig `mapstructure:"rate_limits"`
+	// Retry 临时错误（超时、5xx、限流）的自动重试，推送目标可单独配置最多次数
+	Retry RetryConfig `mapstructure:"retry"`
+	// CircuitBreaker 推送目标连续失败后自动暂停并告警
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+}
+
+type CircuitBreakerConfig struct {
+	Threshold     int  `mapstructure:"threshold"`       // 连续失败多少次后暂停推送目标，负数表示关闭
+	AlertTargetID uint `mapstructure:"alert_target_id"` // 默认告警目标，推送目标可单独配置
+}
+
+type RetryConfig struct {
+	MaxAttempts int `mapstructure:"max_attempts"` // 最多发送次数（含首次）
+	BaseDelay   int `mapstructure:"base_delay"`   // 首次重试延迟（秒），之后指数增长
+	MaxDelay    int `mapstructure:"max_delay"`    // 重试延迟上限（秒）
+}
+
+type QueueConfig struct {
+	// Workers 按队列名配置的执行者数量（push_notify、code_review、push_retry），未配置的使用默认值
+	Workers map[string]int `mapstructure:"workers"`
+	// HighWorkers 按队列名配置的高优先级通道专用执行者数量，默认 1，0 表示不保留
+	HighWorkers map[string]int `mapstructure:"high_workers"`
+	// RetentionDays 已结束（成功、取消、重试次数用尽）的任务保留天数，超过后自动删除，负数表示不清理
+	RetentionDays int `mapstructure:"retention_days"`
+}
+
+type GitConfig struct {
+	// ContextLines 代码审查使用的统一差异中变更前后保留的上下文行数
+	ContextLines int `mapstructure:"context_lines"`
+	// MirrorDir 仓库镜像缓存目录，代码审查增量拉取到磁盘上的裸镜像，不再每次完整克隆
+	// 目录只能由一个服务进程使用，多个实例需配置不同的目录
+	MirrorDir string `mapstructure:"mirror_dir"`
+	// MirrorMaxSize 镜像缓存总大小上限（MB），超出时淘汰最久未使用的镜像，负数表示不限制
+	MirrorMaxSize int `mapstructure:"mirror_max_size"`
+	// MirrorGCInterval 镜像维护（合并包文件、清理对象、按容量淘汰）的间隔（分钟）
+	MirrorGCInterval int `mapstructure:"mirror_gc_interval"`
+	// KnownHosts 全局 known_hosts 文件路径，校验 SSH 仓库的平台主机公钥，为空时使用 ~/.ssh/known_hosts
+	KnownHosts string `mapstructure:"known_hosts"`
+}
+
+type RateLimitConfig struct {
+	PerMinute int `mapstructure:"per_minute"`
+	Burst     int `mapstructure:"burst"`
+}
+
 func Load() (*Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -81,6 +136,9 @@ func Load() (*Config, error) {
 	if cfg.App.Port == 0 {
 		cfg.App.Port = 8080
 	}
+	if cfg.App.ShutdownTimeout == 0 {
+		cfg.App.ShutdownTimeout = 30
+	}
 	if cfg.JWT.AccessTokenExpire == 0 {
 		cfg.JWT.AccessTokenExpire = 86400
 	}
@@ -90,6 +148,44 @@ func Load() (*Config, error) {
 	if cfg.AI.Timeout == 0 {
 		cfg.AI.Timeout = 60
 	}
+	if cfg.AI.ReviewCacheDays == 0 {
+		cfg.AI.ReviewCacheDays = 30
+	}
+	if cfg.Queue.RetentionDays == 0 {
+		cfg.Queue.RetentionDays = 7
+	}
+	if cfg.Delivery.RateLimits == nil {
+		// 钉钉、企业微信机器人每分钟最多20条，Slack Webhook 约每秒1条
+		cfg.Delivery.RateLimits = map[string]RateLimitConfig{
+			"dingtalk": {PerMinute: 20, Burst: 5},
+			"wecom":    {PerMinute: 20, Burst: 5},
+			"slack":    {PerMinute: 60, Burst: 1},
+		}
+	}
+	if cfg.Delivery.Retry.MaxAttempts == 0 {
+		cfg.Delivery.Retry.MaxAttempts = 3
+	}
+	if cfg.Delivery.Retry.BaseDelay == 0 {
+		cfg.Delivery.Retry.BaseDelay = 10
+	}
+	if cfg.Delivery.Retry.MaxDelay == 0 {
+		cfg.Delivery.Retry.MaxDelay = 600
+	}
+	if cfg.Delivery.CircuitBreaker.Threshold == 0 {
+		cfg.Delivery.CircuitBreaker.Threshold = 5
+	}
+	if cfg.Git.ContextLines == 0 {
+		cfg.Git.ContextLines = 3
+	}
+	if cfg.Git.MirrorDir == "" {
+		cfg.Git.MirrorDir = "mirrors"
+	}
+	if cfg.Git.MirrorMaxSize == 0 {
+		cfg.Git.MirrorMaxSize = 10240
+	}
+	if cfg.Git.MirrorGCInterval == 0 {
+		cfg.Git.MirrorGCInterval = 60
+	}
 
 	return &cfg, nil
 }
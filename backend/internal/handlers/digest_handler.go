@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"strconv"
+
+	"backend/internal/services"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DigestHandler struct {
+	digestService *services.DigestService
+}
+
+func NewDigestHandler(digestService *services.DigestService) *DigestHandler {
+	return &DigestHandler{digestService: digestService}
+}
+
+// List 获取摘要列表
+func (h *DigestHandler) List(c *gin.Context) {
+	page := utils.GetPage(c)
+	size := utils.GetSize(c)
+	targetID, _ := strconv.ParseUint(c.Query("target_id"), 10, 32)
+	status := c.Query("status")
+
+	digests, total, err := h.digestService.GetList(page, size, uint(targetID), status)
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	utils.SuccessWithPage(c, digests, int(total), page, size)
+}
+
+// Detail 获取摘要详情（含合并的推送记录）
+func (h *DigestHandler) Detail(c *gin.Context) {
+	id := utils.GetID(c)
+	digest, err := h.digestService.GetByID(id)
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	utils.Success(c, digest)
+}
+
+// Flush 立即发送推送目标当前累积的摘要
+func (h *DigestHandler) Flush(c *gin.Context) {
+	var req struct {
+		TargetID uint `json:"target_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidateError(c, []string{err.Error()})
+		return
+	}
+
+	digest, err := h.digestService.Flush(req.TargetID)
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(c, "摘要已发送", digest)
+}
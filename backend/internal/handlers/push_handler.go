@@ -38,7 +38,7 @@ func (h *PushHandler) List(c *gin.Context) {
 // Detail 获取推送详情
 func (h *PushHandler) Detail(c *gin.Context) {
 	id := utils.GetID(c)
-	push, err := h.pushService.GetByID(id)
+	push, err := h.pushService.GetDetail(id)
 	if err != nil {
 		utils.Fail(c, 400, err.Error())
 		return
@@ -62,15 +62,13 @@ func (h *PushHandler) Delete(c *gin.Context) {
 // Retry 重试推送
 func (h *PushHandler) Retry(c *gin.Context) {
 	id := utils.GetID(c)
-	newID, err := h.pushService.Retry(id)
-	if err != nil {
+	if err := h.pushService.Retry(id); err != nil {
 		utils.Fail(c, 400, err.Error())
 		return
 	}
 
-	utils.Success(c, map[string]interface{}{
-		"new_push_id":      newID,
-		"original_push_id": id,
+	utils.SuccessWithMsg(c, "重试已提交", map[string]interface{}{
+		"push_id": id,
 	})
 }
 
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"backend/internal/services"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueueHandler struct {
+	queueService *services.QueueService
+	logService   *services.LogService
+}
+
+func NewQueueHandler(queueService *services.QueueService, logService *services.LogService) *QueueHandler {
+	return &QueueHandler{
+		queueService: queueService,
+		logService:   logService,
+	}
+}
+
+// List 获取任务队列状态
+func (h *QueueHandler) List(c *gin.Context) {
+	list, err := h.queueService.List()
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	utils.Success(c, list)
+}
+
+// Jobs 获取队列中的任务
+func (h *QueueHandler) Jobs(c *gin.Context) {
+	page := utils.GetPage(c)
+	size := utils.GetSize(c)
+
+	jobs, total, err := h.queueService.Jobs(c.Param("name"), c.Query("status"), page, size)
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	utils.SuccessWithPage(c, jobs, int(total), page, size)
+}
+
+// Pause 暂停队列
+func (h *QueueHandler) Pause(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.queueService.Pause(name); err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	h.logService.LogOperation(utils.GetUserID(c), "queue", "暂停队列", "queue", 0, gin.H{"queue": name})
+	utils.SuccessWithMsg(c, "队列已暂停", nil)
+}
+
+// Resume 恢复队列
+func (h *QueueHandler) Resume(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.queueService.Resume(name); err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	h.logService.LogOperation(utils.GetUserID(c), "queue", "恢复队列", "queue", 0, gin.H{"queue": name})
+	utils.SuccessWithMsg(c, "队列已恢复", nil)
+}
+
+// SetWorkers 调整队列执行者数量，可分别调整普通执行者和高优先级通道专用执行者
+func (h *QueueHandler) SetWorkers(c *gin.Context) {
+	name := c.Param("name")
+	var req struct {
+		Workers     *int `json:"workers"`
+		HighWorkers *int `json:"high_workers"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidateError(c, []string{err.Error()})
+		return
+	}
+	if req.Workers == nil && req.HighWorkers == nil {
+		utils.ValidateError(c, []string{"workers 和 high_workers 至少需要一个"})
+		return
+	}
+
+	if req.Workers != nil {
+		if err := h.queueService.SetWorkers(name, *req.Workers); err != nil {
+			utils.Fail(c, 400, err.Error())
+			return
+		}
+	}
+	if req.HighWorkers != nil {
+		if err := h.queueService.SetHighWorkers(name, *req.HighWorkers); err != nil {
+			utils.Fail(c, 400, err.Error())
+			return
+		}
+	}
+
+	h.logService.LogOperation(utils.GetUserID(c), "queue", "调整执行者数量", "queue", 0, gin.H{"queue": name, "workers": req.Workers, "high_workers": req.HighWorkers})
+	utils.SuccessWithMsg(c, "更新成功", nil)
+}
+
+// CancelJob 取消任务
+func (h *QueueHandler) CancelJob(c *gin.Context) {
+	id := utils.GetID(c)
+	if err := h.queueService.Cancel(id); err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	h.logService.LogOperation(utils.GetUserID(c), "queue", "取消任务", "job", id, nil)
+	utils.SuccessWithMsg(c, "任务已取消", nil)
+}
+
+// SetJobPriority 调整任务优先级
+func (h *QueueHandler) SetJobPriority(c *gin.Context) {
+	id := utils.GetID(c)
+	var req struct {
+		Priority *int `json:"priority" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidateError(c, []string{err.Error()})
+		return
+	}
+
+	if err := h.queueService.SetPriority(id, *req.Priority); err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	h.logService.LogOperation(utils.GetUserID(c), "queue", "调整任务优先级", "job", id, req)
+	utils.SuccessWithMsg(c, "更新成功", nil)
+}
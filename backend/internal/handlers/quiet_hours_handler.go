@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"backend/internal/services"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuietHoursHandler struct {
+	quietHoursService *services.QuietHoursService
+}
+
+func NewQuietHoursHandler(quietHoursService *services.QuietHoursService) *QuietHoursHandler {
+	return &QuietHoursHandler{quietHoursService: quietHoursService}
+}
+
+// Calendars 获取节假日日历列表
+func (h *QuietHoursHandler) Calendars(c *gin.Context) {
+	calendars, err := h.quietHoursService.GetCalendars()
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	utils.Success(c, calendars)
+}
+
+// Holidays 获取节假日列表
+func (h *QuietHoursHandler) Holidays(c *gin.Context) {
+	holidays, err := h.quietHoursService.GetHolidays(c.Query("calendar"))
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	utils.Success(c, holidays)
+}
+
+// ImportHolidays 从 ICS 文件导入节假日
+// 支持 multipart 上传（字段 file）或直接以 text/calendar 作为请求体
+func (h *QuietHoursHandler) ImportHolidays(c *gin.Context) {
+	calendarName := c.Query("calendar")
+	if calendarName == "" {
+		calendarName = c.PostForm("calendar")
+	}
+
+	var count int
+	var err error
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, ferr := c.FormFile("file")
+		if ferr != nil {
+			utils.ValidateError(c, []string{ferr.Error()})
+			return
+		}
+		f, ferr := file.Open()
+		if ferr != nil {
+			utils.Fail(c, 400, ferr.Error())
+			return
+		}
+		defer f.Close()
+		count, err = h.quietHoursService.ImportICS(calendarName, f)
+	} else {
+		count, err = h.quietHoursService.ImportICS(calendarName, c.Request.Body)
+	}
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(c, "导入成功", map[string]interface{}{
+		"calendar": calendarName,
+		"count":    count,
+	})
+}
+
+// DeleteHoliday 删除节假日
+func (h *QuietHoursHandler) DeleteHoliday(c *gin.Context) {
+	id := utils.GetID(c)
+	if err := h.quietHoursService.DeleteHoliday(id); err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	utils.SuccessWithMsg(c, "删除成功", nil)
+}
+
+// HeldMessages 获取免打扰期间暂存的消息
+func (h *QuietHoursHandler) HeldMessages(c *gin.Context) {
+	page := utils.GetPage(c)
+	size := utils.GetSize(c)
+	targetID, _ := strconv.ParseUint(c.Query("target_id"), 10, 32)
+	status := c.Query("status")
+
+	msgs, total, err := h.quietHoursService.GetHeldList(page, size, uint(targetID), status)
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	utils.SuccessWithPage(c, msgs, int(total), page, size)
+}
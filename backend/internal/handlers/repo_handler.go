@@ -109,6 +109,56 @@ func (h *RepoHandler) TestWebhook(c *gin.Context) {
 	utils.Success(c, result)
 }
 
+// GenerateSSHKey 生成 SSH 部署密钥
+func (h *RepoHandler) GenerateSSHKey(c *gin.Context) {
+	id := utils.GetID(c)
+	publicKey, err := h.repoService.GenerateSSHKey(id)
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	h.logService.LogOperation(utils.GetUserID(c), "repo", "生成部署密钥", "repo", id, nil)
+	utils.Success(c, gin.H{"ssh_public_key": publicKey})
+}
+
+// DeleteSSHKey 删除 SSH 部署密钥
+func (h *RepoHandler) DeleteSSHKey(c *gin.Context) {
+	id := utils.GetID(c)
+	if err := h.repoService.DeleteSSHKey(id); err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	h.logService.LogOperation(utils.GetUserID(c), "repo", "删除部署密钥", "repo", id, nil)
+	utils.SuccessWithMsg(c, "删除成功", nil)
+}
+
+// ClearReviewCache 清空仓库的审查结果缓存
+func (h *RepoHandler) ClearReviewCache(c *gin.Context) {
+	id := utils.GetID(c)
+	count, err := h.repoService.ClearReviewCache(id)
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	h.logService.LogOperation(utils.GetUserID(c), "repo", "清空审查缓存", "repo", id, gin.H{"count": count})
+	utils.SuccessWithMsg(c, "清空成功", gin.H{"count": count})
+}
+
+// ScanHostKeys 获取平台的 SSH 主机公钥
+func (h *RepoHandler) ScanHostKeys(c *gin.Context) {
+	id := utils.GetID(c)
+	keys, err := h.repoService.ScanHostKeys(id)
+	if err != nil {
+		utils.Fail(c, 400, err.Error())
+		return
+	}
+
+	utils.Success(c, keys)
+}
+
 // GetTargets 获取仓库关联的推送目标
 func (h *RepoHandler) GetTargets(c *gin.Context) {
 	id := utils.GetID(c)
@@ -110,6 +110,11 @@ func (h *TargetHandler) Test(c *gin.Context) {
 	utils.SuccessWithMsg(c, "测试消息已发送", result)
 }
 
+// Types 获取支持的推送渠道及其配置项
+func (h *TargetHandler) Types(c *gin.Context) {
+	utils.Success(c, h.targetService.Types())
+}
+
 // AddRepo 关联仓库
 func (h *TargetHandler) AddRepo(c *gin.Context) {
 	id := utils.GetID(c)
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// DeliveryAttempt 一次发送请求的记录，用于排查渠道发送失败
+type DeliveryAttempt struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	TargetID     uint      `gorm:"not null;index" json:"target_id"`
+	Kind         string    `gorm:"size:20;not null" json:"kind"` // push, review, digest, message, alert
+	PushID       *uint     `gorm:"index" json:"push_id,omitempty"`
+	DigestID     *uint     `gorm:"index" json:"digest_id,omitempty"`
+	Part         int       `gorm:"default:1" json:"part"` // 分段序号，从1开始
+	Method       string    `gorm:"size:10" json:"method"`
+	URL          string    `gorm:"size:1000" json:"url"` // 已脱敏
+	RequestBody  string    `gorm:"type:text" json:"request_body"`
+	StatusCode   int       `json:"status_code"`                    // HTTP 状态码，未收到响应时为 0
+	ResponseBody string    `gorm:"type:text" json:"response_body"` // 截断后的响应内容
+	ErrCode      string    `gorm:"size:50" json:"errcode,omitempty"`
+	ErrorMsg     string    `gorm:"type:text" json:"error_msg,omitempty"`
+	LatencyMs    int64     `json:"latency_ms"`
+	Success      bool      `json:"success"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+}
+
+// 发送记录类型
+const (
+	AttemptKindPush    = "push"
+	AttemptKindReview  = "review"
+	AttemptKindDigest  = "digest"
+	AttemptKindMessage = "message"
+	AttemptKindAlert   = "alert"
+)
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// Digest 摘要消息，按推送目标周期性合并多条推送后发送
+type Digest struct {
+	ID          uint        `gorm:"primarykey" json:"id"`
+	TargetID    uint        `gorm:"not null;index" json:"target_id"`
+	Target      Target      `gorm:"foreignKey:TargetID" json:"target,omitempty"`
+	Status      string      `gorm:"size:20;default:'pending'" json:"status"` // pending, success, failed
+	Content     string      `gorm:"type:text" json:"content"`
+	PushCount   int         `gorm:"default:0" json:"push_count"`
+	ErrorMsg    string      `gorm:"type:text" json:"error_msg,omitempty"`
+	Parts       PartResults `gorm:"type:text" json:"parts,omitempty"`
+	PeriodStart time.Time   `json:"period_start"`
+	PeriodEnd   time.Time   `json:"period_end"`
+	SentAt      *time.Time  `json:"sent_at,omitempty"`
+	CreatedAt   time.Time   `gorm:"index" json:"created_at"`
+
+	Pushes   []Push            `gorm:"foreignKey:DigestID" json:"pushes,omitempty"`
+	Attempts []DeliveryAttempt `gorm:"foreignKey:DigestID" json:"attempts,omitempty"` // 发送记录
+}
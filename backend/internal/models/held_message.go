@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// HeldMessage 免打扰期间暂存的消息，免打扰结束后补发
+type HeldMessage struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	TargetID   uint       `gorm:"not null;index" json:"target_id"`
+	Target     Target     `gorm:"foreignKey:TargetID" json:"target,omitempty"`
+	PushID     *uint      `gorm:"index" json:"push_id,omitempty"`
+	Kind       string     `gorm:"size:20;not null" json:"kind"` // push, review
+	Title      string     `gorm:"size:200" json:"title"`
+	Content    string     `gorm:"type:text;not null" json:"content"`
+	Status     string     `gorm:"size:20;default:'held';index" json:"status"` // held, released, failed
+	ErrorMsg   string     `gorm:"type:text" json:"error_msg,omitempty"`
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// 暂存消息类型
+const (
+	HeldKindPush   = "push"
+	HeldKindReview = "review"
+)
+
+// 暂存消息状态
+const (
+	HeldStatusHeld     = "held"
+	HeldStatusReleased = "released"
+	HeldStatusFailed   = "failed"
+)
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// Holiday 节假日，按日历名称分组，可从 ICS 文件导入
+type Holiday struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Calendar  string    `gorm:"size:50;not null;uniqueIndex:idx_calendar_date" json:"calendar"`
+	Date      string    `gorm:"size:10;not null;uniqueIndex:idx_calendar_date" json:"date"` // YYYY-MM-DD
+	Name      string    `gorm:"size:200" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// Job 持久化的后台任务，进程重启后可恢复
+type Job struct {
+	ID             uint       `gorm:"primarykey" json:"id"`
+	Queue          string     `gorm:"size:50;not null;index:idx_queue_status" json:"queue"`
+	Status         string     `gorm:"size:20;not null;default:'queued';index:idx_queue_status" json:"status"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"` // JSON
+	DedupKey       string     `gorm:"size:200;index" json:"dedup_key"`   // 同一队列中未结束的任务不重复入队，由部分唯一索引 idx_job_active_dedup 保证
+	Priority       int        `gorm:"default:0" json:"priority"`         // 越大越先执行
+	Attempts       int        `gorm:"default:0" json:"attempts"`         // 已执行次数
+	MaxAttempts    int        `gorm:"default:3" json:"max_attempts"`     // 超过后进入 dead
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	RunAt          time.Time  `gorm:"index" json:"run_at"` // 最早可执行时间，失败重试时后延
+	LeaseOwner     string     `gorm:"size:100" json:"lease_owner,omitempty"`
+	LeaseExpiresAt *time.Time `gorm:"index" json:"lease_expires_at,omitempty"` // 租约到期未续约视为执行者已失联
+	HeartbeatAt    *time.Time `json:"heartbeat_at,omitempty"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// 任务状态
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed" // 执行失败，等待重试
+	JobStatusDead      = "dead"   // 重试次数用尽
+	JobStatusCanceled  = "canceled"
+)
+
+// 任务优先级，不低于 JobPriorityHigh 的任务进入高优先级通道，由专用执行者处理
+const (
+	JobPriorityNormal = 0
+	JobPriorityHigh   = 10
+)
+
+// 任务队列
+const (
+	JobQueuePushNotify = "push_notify"
+	JobQueueCodeReview = "code_review"
+	JobQueuePushRetry  = "push_retry"
+)
@@ -1,31 +1,59 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type Push struct {
-	ID             uint       `gorm:"primarykey" json:"id"`
-	RepoID         uint       `gorm:"not null;index" json:"repo_id"`
-	Repo           Repo       `gorm:"foreignKey:RepoID" json:"repo,omitempty"`
-	TargetID       uint       `gorm:"not null;index;uniqueIndex:idx_commit_target" json:"target_id"`
-	Target         Target     `gorm:"foreignKey:TargetID" json:"target,omitempty"`
-	TemplateID     *uint      `json:"template_id"`
-	Template       Template   `gorm:"foreignKey:TemplateID" json:"template,omitempty"`
-	CommitID       string     `gorm:"size:50;not null;uniqueIndex:idx_commit_target" json:"commit_id"`
-	CommitMsg      string     `gorm:"size:500;not null" json:"commit_msg"`
-	Status         string     `gorm:"size:20;default:'pending'" json:"status"`
-	Content        string     `gorm:"type:text;not null" json:"content"`
-	ErrorMsg       string     `gorm:"type:text" json:"error_msg,omitempty"`
-	CodeviewResult *string    `gorm:"type:text" json:"codeview_result,omitempty"`
-	CodeviewStatus string     `gorm:"size:20;default:'pending'" json:"codeview_status"`
-	RetryCount     int        `gorm:"default:0" json:"retry_count"`
-	PushedAt       *time.Time `json:"pushed_at,omitempty"`
-	CreatedAt      time.Time  `json:"created_at"`
+	ID             uint        `gorm:"primarykey" json:"id"`
+	RepoID         uint        `gorm:"not null;index" json:"repo_id"`
+	Repo           Repo        `gorm:"foreignKey:RepoID" json:"repo,omitempty"`
+	TargetID       uint        `gorm:"not null;index;index:idx_push_dedup,priority:1" json:"target_id"`
+	Target         Target      `gorm:"foreignKey:TargetID" json:"target,omitempty"`
+	TemplateID     *uint       `json:"template_id"`
+	Template       Template    `gorm:"foreignKey:TemplateID" json:"template,omitempty"`
+	CommitID       string      `gorm:"size:50;not null;index" json:"commit_id"`
+	CommitMsg      string      `gorm:"size:500;not null" json:"commit_msg"`
+	Status         string      `gorm:"size:20;default:'pending'" json:"status"`
+	Content        string      `gorm:"type:text;not null" json:"content"`
+	ErrorMsg       string      `gorm:"type:text" json:"error_msg,omitempty"`
+	CodeviewResult *string     `gorm:"type:text" json:"codeview_result,omitempty"`
+	CodeviewStatus string      `gorm:"size:20;default:'pending'" json:"codeview_status"`
+	Parts          PartResults `gorm:"type:text" json:"parts,omitempty"` // 分段发送结果
+	QueueWaitMs    int64       `gorm:"default:0" json:"queue_wait_ms"`   // 限流排队等待时长
+	RetryCount     int         `gorm:"default:0" json:"retry_count"`
+	NextRetryAt    *time.Time  `json:"next_retry_at,omitempty"` // 自动重试的计划时间
+	PushedAt       *time.Time  `json:"pushed_at,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
 
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// 摘要投递
+	DeliveredVia string `gorm:"size:20;default:'direct'" json:"delivered_via"` // direct, digest
+	DigestID     *uint  `gorm:"index" json:"digest_id,omitempty"`
+
+	// 去重
+	Branch     string `gorm:"size:255" json:"branch"`
+	DeliveryID string `gorm:"size:100" json:"delivery_id,omitempty"`                     // Webhook 投递ID
+	DedupKey   string `gorm:"size:400;index:idx_push_dedup,priority:2" json:"dedup_key"` // 按仓库去重策略生成，为空表示不去重
+	JobID      *uint  `gorm:"index" json:"job_id,omitempty"`                             // 创建记录的推送任务，任务重试时接续同一条记录
+
+	// 审查问题统计，与 review_issues 同步更新
+	IssueCount   int `gorm:"default:0" json:"issue_count"`
+	ErrorCount   int `gorm:"default:0" json:"error_count"`
+	WarningCount int `gorm:"default:0" json:"warning_count"`
+
+	// 发送记录，仅详情接口返回
+	Attempts []DeliveryAttempt `gorm:"foreignKey:PushID" json:"attempts,omitempty"`
+	// 审查问题，仅详情接口返回（按仓库和提交查询）
+	ReviewIssues []ReviewIssue `gorm:"-" json:"review_issues,omitempty"`
+	// 文件审查记录（使用的提示词和模型），仅详情接口返回
+	ReviewFiles []ReviewFile `gorm:"-" json:"review_files,omitempty"`
 }
 
 // 推送状态
@@ -33,6 +61,16 @@ const (
 	PushStatusPending = "pending"
 	PushStatusSuccess = "success"
 	PushStatusFailed  = "failed"
+	PushStatusDigest  = "digest"   // 等待合并到摘要中发送
+	PushStatusHeld    = "held"     // 免打扰期间暂存，结束后补发
+	PushStatusRetry   = "retrying" // 临时错误，等待自动重试
+	PushStatusDead    = "dead"     // 重试用尽或推送目标已暂停，需人工处理
+)
+
+// 投递途径
+const (
+	DeliveredViaDirect = "direct"
+	DeliveredViaDigest = "digest"
 )
 
 // Codeview 状态
@@ -42,3 +80,56 @@ const (
 	CodeviewStatusFailed  = "failed"
 	CodeviewStatusSkipped = "skipped"
 )
+
+// PartResult 分段消息的发送结果
+type PartResult struct {
+	Index    int        `json:"index"` // 从1开始
+	Total    int        `json:"total"`
+	Bytes    int        `json:"bytes"`
+	WaitMs   int64      `json:"wait_ms,omitempty"` // 限流排队等待时长
+	Status   string     `json:"status"`            // success, failed, skipped
+	ErrorMsg string     `json:"error_msg,omitempty"`
+	SentAt   *time.Time `json:"sent_at,omitempty"`
+}
+
+// 分段状态
+const (
+	PartStatusSuccess = "success"
+	PartStatusFailed  = "failed"
+	PartStatusSkipped = "skipped"
+)
+
+// PartResults 分段发送结果列表，以JSON存储
+type PartResults []PartResult
+
+func (p *PartResults) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch t := value.(type) {
+	case string:
+		if t == "" {
+			return nil
+		}
+		return json.Unmarshal([]byte(t), p)
+	case []byte:
+		if len(t) == 0 {
+			return nil
+		}
+		return json.Unmarshal(t, p)
+	default:
+		return fmt.Errorf("unsupported type %T", t)
+	}
+}
+
+func (p PartResults) Value() (driver.Value, error) {
+	if len(p) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+	_ "time/tzdata" // 内嵌时区数据，容器中缺少 zoneinfo 时也能解析时区
+)
+
+// QuietHours 推送目标的免打扰配置
+// 免打扰期间非紧急消息会被暂存，结束后按顺序补发。
+type QuietHours struct {
+	Enabled  bool   `json:"enabled"`
+	Timezone string `json:"timezone"` // 如 Asia/Shanghai，为空使用服务器时区
+	Start    string `json:"start"`    // 每日免打扰开始时间 HH:MM
+	End      string `json:"end"`      // 每日免打扰结束时间 HH:MM，早于开始时间表示跨天
+	Weekdays []int  `json:"weekdays"` // 全天免打扰的星期，0 为周日
+	Calendar string `json:"calendar"` // 节假日日历名称，日历中的日期全天免打扰
+
+	Urgent UrgentRules `json:"urgent"`
+}
+
+// UrgentRules 紧急消息规则，满足任一条件时忽略免打扰立即发送
+type UrgentRules struct {
+	ForcePushBranches []string `json:"force_push_branches"` // 强制推送到这些分支时，* 表示任意分支
+	Keywords          []string `json:"keywords"`            // 提交信息包含任一关键字时，如 hotfix
+	ReviewErrors      bool     `json:"review_errors"`       // 审查发现错误时
+}
+
+// Location 免打扰使用的时区
+func (q *QuietHours) Location() *time.Location {
+	if q == nil || q.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// IsQuiet 判断指定时间是否处于免打扰时段，isHoliday 用于查询节假日日历
+func (q *QuietHours) IsQuiet(now time.Time, isHoliday func(date string) bool) bool {
+	if q == nil || !q.Enabled {
+		return false
+	}
+
+	t := now.In(q.Location())
+	for _, d := range q.Weekdays {
+		if int(t.Weekday()) == d {
+			return true
+		}
+	}
+	if q.Calendar != "" && isHoliday != nil && isHoliday(t.Format("2006-01-02")) {
+		return true
+	}
+
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(q.End)
+	if err != nil || start == end {
+		return false
+	}
+
+	cur := t.Hour()*60 + t.Minute()
+	if start < end {
+		return cur >= start && cur < end
+	}
+	return cur >= start || cur < end
+}
+
+// Validate 校验免打扰配置
+func (q *QuietHours) Validate() error {
+	if q == nil {
+		return nil
+	}
+	if q.Timezone != "" {
+		if _, err := time.LoadLocation(q.Timezone); err != nil {
+			return fmt.Errorf("无效的时区: %s", q.Timezone)
+		}
+	}
+	if (q.Start == "") != (q.End == "") {
+		return fmt.Errorf("免打扰开始和结束时间需同时设置")
+	}
+	if q.Start != "" {
+		if _, err := parseClock(q.Start); err != nil {
+			return fmt.Errorf("免打扰开始时间格式应为 HH:MM")
+		}
+		if _, err := parseClock(q.End); err != nil {
+			return fmt.Errorf("免打扰结束时间格式应为 HH:MM")
+		}
+	}
+	for _, d := range q.Weekdays {
+		if d < 0 || d > 6 {
+			return fmt.Errorf("无效的星期: %d", d)
+		}
+	}
+	return nil
+}
+
+// parseClock 解析 HH:MM，返回当天的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// 实现Sql序列化和反序列话接口
+func (q *QuietHours) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch t := value.(type) {
+	case string:
+		if t == "" {
+			return nil
+		}
+		return json.Unmarshal([]byte(t), q)
+	case []byte:
+		if len(t) == 0 {
+			return nil
+		}
+		return json.Unmarshal(t, q)
+	default:
+		return fmt.Errorf("unsupported type %T", t)
+	}
+}
+
+func (q *QuietHours) Value() (driver.Value, error) {
+	if q == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(q)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -28,6 +28,42 @@ type Repo struct {
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// 推送去重
+	DedupPolicy string `gorm:"size:20;default:'commit'" json:"dedup_policy"` // commit, commit_branch, delivery, none
+	DedupWindow int    `gorm:"default:0" json:"dedup_window"`                // 去重时间窗口（分钟），0 表示不限
+
+	// 任务优先级：匹配的推送的通知和审查任务进入高优先级通道
+	PriorityBranches string `gorm:"size:500" json:"priority_branches"`  // 受保护分支，逗号分隔，支持通配符，如 main,release/*
+	PriorityTags     string `gorm:"size:500" json:"priority_tags"`      // 发布标签，逗号分隔，支持通配符，如 v*
+	HighPriority     bool   `gorm:"default:false" json:"high_priority"` // 仓库的所有推送都为高优先级
+
+	// 审查评论：将审查问题发布到 Git 平台，使用仓库的访问令牌
+	PublishComments bool   `gorm:"default:false" json:"publish_comments"`
+	CommentMode     string `gorm:"size:20;default:'auto'" json:"comment_mode"`        // auto: 提交属于打开的 PR/MR 时发布到 PR/MR，否则发布到提交；commit: 只发布到提交
+	CommentSeverity string `gorm:"size:20;default:'warning'" json:"comment_severity"` // 发布行评论的最低严重程度，其余问题只在汇总评论中列出
+
+	// 提交状态：将审查结论发布为提交状态，可在平台上设为合并的必需检查
+	PublishStatus   bool   `gorm:"default:false" json:"publish_status"`
+	StatusSeverity  string `gorm:"size:20;default:'error'" json:"status_severity"` // 计入失败阈值的最低严重程度
+	StatusMaxIssues int    `gorm:"default:0" json:"status_max_issues"`             // 计入的问题超过该数量时状态为失败
+
+	// SSH 部署密钥：仓库地址为 git@ 或 ssh:// 时，代码审查使用部署密钥拉取代码，并按 known_hosts 校验平台主机公钥
+	SSHPrivateKey string `gorm:"type:text" json:"-"`
+	SSHPublicKey  string `gorm:"type:text" json:"ssh_public_key"`  // 需添加到平台的部署密钥（只读）
+	SSHKnownHosts string `gorm:"type:text" json:"ssh_known_hosts"` // 平台主机公钥，known_hosts 格式，可多行
+
+	// 差异来源：代码审查优先使用该方式获取提交差异，失败时依次改用其他可用方式
+	DiffSource string `gorm:"size:20;default:'git'" json:"diff_source"` // git, github, gitlab
+	APIURL     string `gorm:"size:500" json:"api_url"`                  // 平台 API 地址，为空时按仓库地址推断，如 https://ghe.example.com/api/v3
+
+	// 审查上下文：除差异外为模型附带变更后的完整文件，预算不足时改为变更附近的片段
+	ContextBudget  int  `gorm:"default:0" json:"context_budget"`      // 上下文预算（token），0 表示不附带
+	ContextRelated bool `gorm:"default:false" json:"context_related"` // 同时附带相关文件（相对导入的文件、同目录的文件）
+
+	// 审查提示词：依次使用仓库指定的提示词、适用于文件语言的提示词、匹配审查场景的提示词、默认提示词
+	PromptID    *uint  `json:"prompt_id"`
+	ReviewScene string `gorm:"size:50" json:"review_scene"` // 审查场景，与提示词的场景匹配，如 安全检查
+
 	// 关联
 	Targets     []Target     `gorm:"many2many:repo_targets;" json:"targets,omitempty"`
 	Pushes      []Push       `json:"-"`
@@ -47,6 +83,30 @@ const (
 	RepoStatusInactive = "inactive"
 )
 
+// 审查评论发布位置
+const (
+	CommentModeAuto   = "auto"
+	CommentModeCommit = "commit"
+)
+
+// 差异来源
+const (
+	DiffSourceGit    = "git"    // 克隆仓库（使用镜像缓存）后本地计算差异
+	DiffSourceGitHub = "github" // GitHub / GitHub Enterprise API
+	DiffSourceGitLab = "gitlab" // GitLab API
+)
+
+// DefaultPriorityBranches 新建仓库默认的高优先级分支
+const DefaultPriorityBranches = "main,master"
+
+// 推送去重策略：同一推送目标在时间窗口内已有相同去重键的推送时不再发送
+const (
+	DedupPolicyCommit       = "commit"        // 按提交，同一提交只通知一次
+	DedupPolicyCommitBranch = "commit_branch" // 按提交+分支，同一提交推送到不同分支分别通知
+	DedupPolicyDelivery     = "delivery"      // 按 Webhook 投递，只忽略平台的重复投递
+	DedupPolicyNone         = "none"          // 不去重
+)
+
 type UpdateRepo struct {
 	ID               uint                `json:"id"`
 	Name             string              `json:"name"`
@@ -58,6 +118,24 @@ type UpdateRepo struct {
 	CommitTemplateID *uint               `json:"commit_template_id"`
 	ReviewTemplates  []RepoTemplateConfig `json:"review_templates"`
 	AccessToken      string              `json:"access_token"`
+	DedupPolicy      string              `json:"dedup_policy"`
+	DedupWindow      *int                `json:"dedup_window"`
+	PriorityBranches *string             `json:"priority_branches"`
+	PriorityTags     *string             `json:"priority_tags"`
+	HighPriority     *bool               `json:"high_priority"`
+	PublishComments  *bool               `json:"publish_comments"`
+	CommentMode      string              `json:"comment_mode"`
+	CommentSeverity  string              `json:"comment_severity"`
+	PublishStatus    *bool               `json:"publish_status"`
+	StatusSeverity   string              `json:"status_severity"`
+	StatusMaxIssues  *int                `json:"status_max_issues"`
+	SSHKnownHosts    *string             `json:"ssh_known_hosts"`
+	DiffSource       string              `json:"diff_source"`
+	APIURL           *string             `json:"api_url"`
+	ContextBudget    *int                `json:"context_budget"`
+	ContextRelated   *bool               `json:"context_related"`
+	PromptID         *uint               `json:"prompt_id"`
+	ReviewScene      *string             `json:"review_scene"`
 }
 
 type CreateRepo struct {
@@ -70,6 +148,24 @@ type CreateRepo struct {
 	CommitTemplateID *uint               `json:"commit_template_id"`
 	ReviewTemplates  []RepoTemplateConfig `json:"review_templates"`
 	AccessToken      string              `json:"access_token"`
+	DedupPolicy      string              `json:"dedup_policy"`
+	DedupWindow      *int                `json:"dedup_window"`
+	PriorityBranches *string             `json:"priority_branches"`
+	PriorityTags     *string             `json:"priority_tags"`
+	HighPriority     *bool               `json:"high_priority"`
+	PublishComments  *bool               `json:"publish_comments"`
+	CommentMode      string              `json:"comment_mode"`
+	CommentSeverity  string              `json:"comment_severity"`
+	PublishStatus    *bool               `json:"publish_status"`
+	StatusSeverity   string              `json:"status_severity"`
+	StatusMaxIssues  *int                `json:"status_max_issues"`
+	SSHKnownHosts    *string             `json:"ssh_known_hosts"`
+	DiffSource       string              `json:"diff_source"`
+	APIURL           *string             `json:"api_url"`
+	ContextBudget    *int                `json:"context_budget"`
+	ContextRelated   *bool               `json:"context_related"`
+	PromptID         *uint               `json:"prompt_id"`
+	ReviewScene      *string             `json:"review_scene"`
 }
 
 type RepoTemplateConfig struct {
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// ReviewCache 文件审查结果缓存
+// 按仓库和指纹（文件名、差异、提示词及版本、模型）复用审查结果，cherry-pick、rebase 或推送到其他分支的相同变更不再调用模型；
+// 提示词或模型变化后指纹随之变化，旧结果不再命中。
+type ReviewCache struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	RepoID        uint      `gorm:"not null;uniqueIndex:idx_review_cache_key,priority:1" json:"repo_id"`
+	Fingerprint   string    `gorm:"size:64;not null;uniqueIndex:idx_review_cache_key,priority:2" json:"fingerprint"`
+	CommitID      string    `gorm:"size:50" json:"commit_id"` // 产生该结果的提交
+	FileName      string    `gorm:"size:500" json:"file_name"`
+	PromptID      uint      `json:"prompt_id"` // 0 表示内置的默认提示词
+	PromptVersion int       `json:"prompt_version"`
+	ModelID       uint      `json:"model_id"`
+	Result        string    `gorm:"type:text;not null" json:"-"` // 审查结果 JSON
+	HitCount      int       `gorm:"default:0" json:"hit_count"`
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+}
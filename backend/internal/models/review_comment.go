@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// ReviewComment 发布到 Git 平台的审查评论，用于重新审查时原地更新汇总评论、不重复发布行评论
+type ReviewComment struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	RepoID      uint      `gorm:"not null;index:idx_review_comment_commit,priority:1" json:"repo_id"`
+	CommitID    string    `gorm:"size:50;not null;index:idx_review_comment_commit,priority:2" json:"commit_id"`
+	Target      string    `gorm:"size:20;not null" json:"target"` // commit, pull_request
+	PRNumber    int       `gorm:"default:0" json:"pr_number"`     // 发布到 PR/MR 时的编号
+	Kind        string    `gorm:"size:20;not null" json:"kind"`   // summary, line
+	Fingerprint string    `gorm:"size:64" json:"fingerprint"`     // 行评论的文件、行号和问题内容摘要
+	ExternalID  string    `gorm:"size:100" json:"external_id"`    // 平台上的评论 ID
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// 评论发布位置
+const (
+	CommentTargetCommit      = "commit"
+	CommentTargetPullRequest = "pull_request"
+)
+
+// 评论类型
+const (
+	CommentKindSummary = "summary"
+	CommentKindLine    = "line"
+)
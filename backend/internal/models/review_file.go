@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// ReviewFile 提交中每个文件的审查记录，记录产生审查结果的提示词版本和模型
+// 与审查问题相同，按仓库和提交保存，重新审查时覆盖上次的记录。
+type ReviewFile struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	RepoID        uint      `gorm:"not null;index:idx_review_file_commit,priority:1" json:"repo_id"`
+	CommitID      string    `gorm:"size:50;not null;index:idx_review_file_commit,priority:2" json:"commit_id"`
+	FileName      string    `gorm:"size:500" json:"file_name"`
+	Language      string    `gorm:"size:50" json:"language"`
+	PromptID      uint      `gorm:"default:0" json:"prompt_id"` // 0 表示内置默认提示词
+	PromptName    string    `gorm:"size:100" json:"prompt_name"`
+	PromptVersion int       `gorm:"default:0" json:"prompt_version"`
+	PromptMatch   string    `gorm:"size:20" json:"prompt_match"` // repo, language, scene, default, builtin
+	ModelID       uint      `gorm:"default:0" json:"model_id"`
+	ModelName     string    `gorm:"size:100" json:"model_name"`
+	Result        string    `gorm:"size:20" json:"result"`
+	CachedFrom    string    `gorm:"size:50" json:"cached_from,omitempty"` // 复用审查结果的提交
+	Error         string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// 提示词匹配方式
+const (
+	PromptMatchRepo     = "repo"     // 仓库指定的提示词
+	PromptMatchLanguage = "language" // 适用语言包含文件语言的提示词
+	PromptMatchScene    = "scene"    // 场景与仓库审查场景相同的提示词
+	PromptMatchDefault  = "default"  // 未设置语言和场景的提示词
+	PromptMatchBuiltin  = "builtin"  // 没有可用的提示词时使用内置提示词
+)
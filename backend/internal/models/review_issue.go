@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// ReviewIssue AI代码审查发现的问题
+// 审查按仓库和提交进行，同一提交的多条推送记录共享审查问题；PushID 为触发审查的推送记录。
+type ReviewIssue struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	PushID     uint      `gorm:"not null;index" json:"push_id"`
+	RepoID     uint      `gorm:"not null;index:idx_review_issue_commit,priority:1" json:"repo_id"`
+	CommitID   string    `gorm:"size:50;not null;index:idx_review_issue_commit,priority:2" json:"commit_id"`
+	File       string    `gorm:"size:500" json:"file"`
+	Line       int       `gorm:"default:0" json:"line"`                   // 0 表示不针对具体行
+	Severity   string    `gorm:"size:20;not null;index" json:"severity"`  // error, warning, info
+	Category   string    `gorm:"size:30;default:'other'" json:"category"` // bug, security, performance, logic, style, maintainability, other
+	Message    string    `gorm:"type:text;not null" json:"message"`
+	Suggestion string    `gorm:"type:text" json:"suggestion,omitempty"`
+	Source     string    `gorm:"size:10;default:'json'" json:"source"` // json: 模型按格式返回；text: 从自由文本中解析
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// 问题严重程度
+const (
+	IssueSeverityError   = "error"
+	IssueSeverityWarning = "warning"
+	IssueSeverityInfo    = "info"
+)
+
+// 问题分类
+const (
+	IssueCategoryBug             = "bug"
+	IssueCategorySecurity        = "security"
+	IssueCategoryPerformance     = "performance"
+	IssueCategoryLogic           = "logic"
+	IssueCategoryStyle           = "style"
+	IssueCategoryMaintainability = "maintainability"
+	IssueCategoryOther           = "other"
+)
+
+// 问题来源
+const (
+	IssueSourceJSON = "json"
+	IssueSourceText = "text"
+)
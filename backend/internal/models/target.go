@@ -15,6 +15,64 @@ type Config struct {
 	Method      string            `json:"method"`
 	Secret      string            `json:"secret"`
 	WebhookURL  string            `json:"webhook_url"`
+
+	// Extra 其他渠道自定义的配置项
+	Extra map[string]interface{} `json:"-"`
+}
+
+type configAlias Config
+
+// MarshalJSON 序列化时合并自定义配置项
+func (c Config) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(configAlias(c))
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Extra) == 0 {
+		return data, nil
+	}
+	merged := make(map[string]interface{}, len(c.Extra)+5)
+	for k, v := range c.Extra {
+		merged[k] = v
+	}
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merged)
+}
+
+// UnmarshalJSON 反序列化时保留未知的配置项
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var alias configAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, key := range []string{"access_token", "headers", "method", "secret", "webhook_url"} {
+		delete(raw, key)
+	}
+	*c = Config(alias)
+	if len(raw) > 0 {
+		c.Extra = raw
+	}
+	return nil
+}
+
+// ToMap 转换为通用键值对，供推送渠道读取
+func (c *Config) ToMap() map[string]interface{} {
+	result := make(map[string]interface{})
+	if c == nil {
+		return result
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return result
+	}
+	json.Unmarshal(data, &result)
+	return result
 }
 
 // 实现Sql序列化和反序列话接口
@@ -46,14 +104,33 @@ func (c *Config) Value() (driver.Value, error) {
 type Target struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
 	Name      string         `gorm:"size:100;not null" json:"name"`
-	Type      string         `gorm:"size:20;not null" json:"type"`          // dingtalk, email
+	Type      string         `gorm:"size:20;not null" json:"type"`          // 推送渠道，见 pkg/notifier
 	Config    *Config        `gorm:"type:text;not null" json:"config"`      // JSON配置
 	Scope     string         `gorm:"size:20;default:'global'" json:"scope"` // global, repo
 	Status    string         `gorm:"size:20;default:'active'" json:"status"`
+	RateLimit int            `gorm:"default:0" json:"rate_limit"` // 每分钟最多发送条数，0 表示使用该类型的默认限流
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// 投递方式
+	DeliveryMode   string `gorm:"size:20;default:'immediate'" json:"delivery_mode"` // immediate, digest
+	DigestInterval int    `gorm:"default:0" json:"digest_interval"`                 // 摘要间隔（分钟），60 即每小时
+	DigestAt       string `gorm:"size:5" json:"digest_at"`                          // 每日摘要发送时间 HH:MM，设置后忽略间隔
+	MaxAttempts    int    `gorm:"default:0" json:"max_attempts"`                    // 临时错误时最多发送次数（含首次），0 表示使用全局配置
+
+	// 免打扰
+	QuietHours *QuietHours `gorm:"type:text" json:"quiet_hours"`
+
+	// 失败熔断
+	FailureThreshold    int        `gorm:"default:0" json:"failure_threshold"`    // 连续失败多少次后暂停，0 表示使用全局配置
+	AlertTargetID       *uint      `json:"alert_target_id"`                       // 暂停时的告警目标，为空使用全局配置
+	ConsecutiveFailures int        `gorm:"default:0" json:"consecutive_failures"` // 连续失败次数，发送成功后清零
+	FailureCount        int        `gorm:"default:0" json:"failure_count"`        // 累计失败次数
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	LastError           string     `gorm:"type:text" json:"last_error,omitempty"`
+	PausedAt            *time.Time `json:"paused_at,omitempty"`
+
 	// 关联
 	Repos       []Repo       `gorm:"many2many:repo_targets;" json:"repos,omitempty"`
 	Pushes      []Push       `json:"-"`
@@ -64,6 +141,19 @@ type Target struct {
 const (
 	TargetTypeDingTalk = "dingtalk"
 	TargetTypeWebhook  = "webhook"
+	TargetTypeSlack    = "slack"
+	TargetTypeWeCom    = "wecom"
+)
+
+// 投递方式
+const (
+	DeliveryModeImmediate = "immediate"
+	DeliveryModeDigest    = "digest"
+)
+
+// 推送目标状态，active/inactive 见 StatusActive/StatusInactive
+const (
+	TargetStatusPaused = "paused" // 连续失败被自动暂停，测试成功后恢复
 )
 
 // 范围
@@ -10,7 +10,7 @@ type Template struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
 	Name      string         `gorm:"uniqueIndex:idx_name_type_scene;size:100;not null" json:"name"`
 	Type      string         `gorm:"uniqueIndex:idx_name_type_scene;size:20;not null" json:"type"`  // dingtalk, email
-	Scene     string         `gorm:"uniqueIndex:idx_name_type_scene;size:50;not null" json:"scene"` // commit_notify, review_notify
+	Scene     string         `gorm:"uniqueIndex:idx_name_type_scene;size:50;not null" json:"scene"` // commit_notify, review_notify, digest_notify
 	Title     string         `gorm:"size:200;not null" json:"title"`
 	Content   string         `gorm:"type:text;not null" json:"content"`
 	IsDefault bool           `gorm:"default:false" json:"is_default"`
@@ -33,4 +33,5 @@ const (
 const (
 	TemplateSceneCommitNotify = "commit_notify"
 	TemplateSceneReviewNotify = "review_notify"
+	TemplateSceneDigestNotify = "digest_notify"
 )
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type DeliveryAttemptRepo struct {
+	db *gorm.DB
+}
+
+func NewDeliveryAttemptRepo(db *gorm.DB) *DeliveryAttemptRepo {
+	return &DeliveryAttemptRepo{db: db}
+}
+
+// Create 保存发送记录
+func (r *DeliveryAttemptRepo) Create(attempt *models.DeliveryAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// GetByPush 获取推送记录的发送记录，按发送顺序排列
+func (r *DeliveryAttemptRepo) GetByPush(pushID uint) ([]models.DeliveryAttempt, error) {
+	var attempts []models.DeliveryAttempt
+	err := r.db.Where("push_id = ?", pushID).Order("id ASC").Find(&attempts).Error
+	return attempts, err
+}
+
+// GetByDigest 获取摘要的发送记录
+func (r *DeliveryAttemptRepo) GetByDigest(digestID uint) ([]models.DeliveryAttempt, error) {
+	var attempts []models.DeliveryAttempt
+	err := r.db.Where("digest_id = ?", digestID).Order("id ASC").Find(&attempts).Error
+	return attempts, err
+}
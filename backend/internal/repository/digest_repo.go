@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type DigestRepo struct {
+	db *gorm.DB
+}
+
+func NewDigestRepo(db *gorm.DB) *DigestRepo {
+	return &DigestRepo{db: db}
+}
+
+// Create 创建摘要记录
+func (r *DigestRepo) Create(digest *models.Digest) error {
+	return r.db.Create(digest).Error
+}
+
+// Update 更新摘要记录
+func (r *DigestRepo) Update(digest *models.Digest) error {
+	return r.db.Omit("Target", "Pushes", "Attempts").Save(digest).Error
+}
+
+// GetByID 根据ID获取摘要及其包含的推送记录
+func (r *DigestRepo) GetByID(id uint) (*models.Digest, error) {
+	var digest models.Digest
+	err := r.db.Preload("Target").Preload("Pushes").Preload("Pushes.Repo").
+		Preload("Attempts", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&digest, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &digest, nil
+}
+
+// GetList 获取摘要列表
+func (r *DigestRepo) GetList(page, size int, targetID uint, status string) ([]models.Digest, int64) {
+	var digests []models.Digest
+	var total int64
+
+	query := r.db.Model(&models.Digest{})
+	if targetID > 0 {
+		query = query.Where("target_id = ?", targetID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Count(&total)
+	query.Preload("Target").Offset((page - 1) * size).Limit(size).Order("created_at DESC").Find(&digests)
+
+	return digests, total
+}
+
+// GetLatestByTarget 获取推送目标最近一次摘要
+func (r *DigestRepo) GetLatestByTarget(targetID uint) (*models.Digest, error) {
+	var digest models.Digest
+	err := r.db.Where("target_id = ?", targetID).Order("created_at DESC").First(&digest).Error
+	if err != nil {
+		return nil, err
+	}
+	return &digest, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type HeldMessageRepo struct {
+	db *gorm.DB
+}
+
+func NewHeldMessageRepo(db *gorm.DB) *HeldMessageRepo {
+	return &HeldMessageRepo{db: db}
+}
+
+// Create 暂存消息
+func (r *HeldMessageRepo) Create(msg *models.HeldMessage) error {
+	return r.db.Create(msg).Error
+}
+
+// Update 更新暂存消息
+func (r *HeldMessageRepo) Update(msg *models.HeldMessage) error {
+	return r.db.Omit("Target").Save(msg).Error
+}
+
+// GetHeld 获取所有待补发的消息，按暂存顺序排列
+func (r *HeldMessageRepo) GetHeld() ([]models.HeldMessage, error) {
+	var msgs []models.HeldMessage
+	err := r.db.Preload("Target").
+		Where("status = ?", models.HeldStatusHeld).
+		Order("created_at ASC, id ASC").
+		Find(&msgs).Error
+	return msgs, err
+}
+
+// GetList 获取暂存消息列表
+func (r *HeldMessageRepo) GetList(page, size int, targetID uint, status string) ([]models.HeldMessage, int64) {
+	var msgs []models.HeldMessage
+	var total int64
+
+	query := r.db.Model(&models.HeldMessage{})
+	if targetID > 0 {
+		query = query.Where("target_id = ?", targetID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Count(&total)
+	query.Preload("Target").Offset((page - 1) * size).Limit(size).Order("created_at DESC").Find(&msgs)
+
+	return msgs, total
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type HolidayRepo struct {
+	db *gorm.DB
+}
+
+func NewHolidayRepo(db *gorm.DB) *HolidayRepo {
+	return &HolidayRepo{db: db}
+}
+
+// ReplaceCalendar 用导入的节假日替换整个日历
+func (r *HolidayRepo) ReplaceCalendar(calendar string, holidays []models.Holiday) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar = ?", calendar).Delete(&models.Holiday{}).Error; err != nil {
+			return err
+		}
+		if len(holidays) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(holidays, 100).Error
+	})
+}
+
+// GetList 获取节假日列表
+func (r *HolidayRepo) GetList(calendar string) ([]models.Holiday, error) {
+	var holidays []models.Holiday
+	query := r.db.Model(&models.Holiday{})
+	if calendar != "" {
+		query = query.Where("calendar = ?", calendar)
+	}
+	err := query.Order("calendar ASC, date ASC").Find(&holidays).Error
+	return holidays, err
+}
+
+// GetCalendars 获取所有日历名称
+func (r *HolidayRepo) GetCalendars() ([]string, error) {
+	var calendars []string
+	err := r.db.Model(&models.Holiday{}).Distinct().Order("calendar ASC").Pluck("calendar", &calendars).Error
+	return calendars, err
+}
+
+// IsHoliday 判断日期是否在日历中
+func (r *HolidayRepo) IsHoliday(calendar, date string) bool {
+	var count int64
+	r.db.Model(&models.Holiday{}).Where("calendar = ? AND date = ?", calendar, date).Count(&count)
+	return count > 0
+}
+
+// Delete 删除节假日
+func (r *HolidayRepo) Delete(id uint) error {
+	return r.db.Delete(&models.Holiday{}, id).Error
+}
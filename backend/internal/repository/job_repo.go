@@ -0,0 +1,302 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type JobRepo struct {
+	db *gorm.DB
+}
+
+func NewJobRepo(db *gorm.DB) *JobRepo {
+	return &JobRepo{db: db}
+}
+
+// activeStatuses 未结束的任务状态
+var activeStatuses = []string{models.JobStatusQueued, models.JobStatusRunning, models.JobStatusFailed}
+
+// Create 创建任务
+func (r *JobRepo) Create(job *models.Job) error {
+	return r.db.Create(job).Error
+}
+
+// CreateUnique 创建任务，同一队列中已有相同去重键的未结束任务时不创建，返回 false
+// 去重由唯一索引 idx_job_active_dedup 保证，先查询只是为了常见情况下不触发约束冲突；
+// 并发入队时查询和插入之间被其他实例抢先，插入违反唯一约束，同样视为已在队列中。
+func (r *JobRepo) CreateUnique(job *models.Job) (bool, error) {
+	if job.DedupKey != "" {
+		var count int64
+		if err := r.db.Model(&models.Job{}).
+			Where("queue = ? AND dedup_key = ? AND status IN ?", job.Queue, job.DedupKey, activeStatuses).
+			Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return false, nil
+		}
+	}
+	if err := r.db.Create(job).Error; err != nil {
+		if job.DedupKey != "" && errors.Is(err, gorm.ErrDuplicatedKey) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Promote 将同键的待执行任务提前到 runAt、提升到 priority（已更早、更高的不变）
+func (r *JobRepo) Promote(queue, dedupKey string, runAt time.Time, priority int) error {
+	waiting := []string{models.JobStatusQueued, models.JobStatusFailed}
+	if err := r.db.Model(&models.Job{}).
+		Where("queue = ? AND dedup_key = ? AND status IN ? AND run_at > ?", queue, dedupKey, waiting, runAt).
+		Update("run_at", runAt).Error; err != nil {
+		return err
+	}
+	return r.db.Model(&models.Job{}).
+		Where("queue = ? AND dedup_key = ? AND status IN ? AND priority < ?", queue, dedupKey, waiting, priority).
+		Update("priority", priority).Error
+}
+
+// GetByID 根据ID获取任务
+func (r *JobRepo) GetByID(id uint) (*models.Job, error) {
+	var job models.Job
+	err := r.db.First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Claim 领取一个优先级不低于 minPriority 的可执行任务并加租约，没有可执行任务时返回 nil
+// 优先级高的先领取；通过带状态条件的更新保证同一任务只会被一个执行者领取。
+func (r *JobRepo) Claim(queue, owner string, lease time.Duration, minPriority int) (*models.Job, error) {
+	for {
+		var jobs []models.Job
+		now := time.Now()
+		err := r.db.Where("queue = ? AND status IN ? AND run_at <= ? AND priority >= ?", queue,
+			[]string{models.JobStatusQueued, models.JobStatusFailed}, now, minPriority).
+			Order("priority DESC, run_at ASC, id ASC").
+			Limit(1).
+			Find(&jobs).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(jobs) == 0 {
+			return nil, nil
+		}
+		job := jobs[0]
+
+		expires := now.Add(lease)
+		result := r.db.Model(&models.Job{}).
+			Where("id = ? AND status = ?", job.ID, job.Status).
+			Updates(map[string]interface{}{
+				"status":           models.JobStatusRunning,
+				"lease_owner":      owner,
+				"lease_expires_at": expires,
+				"heartbeat_at":     now,
+				"started_at":       now,
+				"attempts":         gorm.Expr("attempts + 1"),
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			// 已被其他执行者领取，继续找下一个
+			continue
+		}
+
+		job.Status = models.JobStatusRunning
+		job.LeaseOwner = owner
+		job.LeaseExpiresAt = &expires
+		job.HeartbeatAt = &now
+		job.StartedAt = &now
+		job.Attempts++
+		return &job, nil
+	}
+}
+
+// List 获取队列中的任务列表，statuses 为空时不过滤状态
+func (r *JobRepo) List(queue string, statuses []string, page, size int) ([]models.Job, int64) {
+	var jobs []models.Job
+	var total int64
+
+	query := r.db.Model(&models.Job{}).Where("queue = ?", queue)
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	query.Count(&total)
+	query.Order("priority DESC, run_at ASC, id ASC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&jobs)
+
+	return jobs, total
+}
+
+// CountByStatus 按状态统计队列中的任务数量
+func (r *JobRepo) CountByStatus(queue string) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&models.Job{}).
+		Select("status, COUNT(*) AS count").
+		Where("queue = ?", queue).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// CountWaitingHigh 统计高优先级通道中等待执行的任务数量
+func (r *JobRepo) CountWaitingHigh(queue string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Job{}).
+		Where("queue = ? AND status IN ? AND priority >= ?", queue,
+			[]string{models.JobStatusQueued, models.JobStatusFailed}, models.JobPriorityHigh).
+		Count(&count).Error
+	return count, err
+}
+
+// OldestWaiting 队列中等待执行最久的任务的入队时间，没有时返回 nil
+func (r *JobRepo) OldestWaiting(queue string) (*time.Time, error) {
+	var jobs []models.Job
+	err := r.db.Where("queue = ? AND status IN ?", queue, []string{models.JobStatusQueued, models.JobStatusFailed}).
+		Order("created_at ASC").
+		Limit(1).
+		Find(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0].CreatedAt, nil
+}
+
+// Cancel 取消未结束的任务，任务已结束时返回 false
+// 执行中的任务被取消后，执行者的 Complete/Fail 不再生效。
+func (r *JobRepo) Cancel(id uint) (bool, error) {
+	result := r.db.Model(&models.Job{}).
+		Where("id = ? AND status IN ?", id, activeStatuses).
+		Updates(map[string]interface{}{
+			"status":           models.JobStatusCanceled,
+			"last_error":       "已取消",
+			"lease_expires_at": nil,
+			"finished_at":      time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// SetPriority 调整等待中任务的优先级，任务不在等待中时返回 false
+func (r *JobRepo) SetPriority(id uint, priority int) (bool, error) {
+	result := r.db.Model(&models.Job{}).
+		Where("id = ? AND status IN ?", id, []string{models.JobStatusQueued, models.JobStatusFailed}).
+		Update("priority", priority)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Heartbeat 续约，租约已不属于 owner 时返回 false
+func (r *JobRepo) Heartbeat(id uint, owner string, lease time.Duration) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", id, models.JobStatusRunning, owner).
+		Updates(map[string]interface{}{
+			"lease_expires_at": now.Add(lease),
+			"heartbeat_at":     now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Complete 标记任务成功，任务已被回收（不再由 owner 执行）时不更新
+func (r *JobRepo) Complete(id uint, owner string) error {
+	return r.db.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", id, models.JobStatusRunning, owner).
+		Updates(map[string]interface{}{
+			"status":           models.JobStatusSucceeded,
+			"last_error":       "",
+			"lease_expires_at": nil,
+			"finished_at":      time.Now(),
+		}).Error
+}
+
+// Fail 标记任务失败；retryAt 为空时进入 dead，否则到时间后重试
+func (r *JobRepo) Fail(id uint, owner, errMsg string, retryAt *time.Time) error {
+	updates := map[string]interface{}{
+		"last_error":       errMsg,
+		"lease_expires_at": nil,
+	}
+	if retryAt == nil {
+		updates["status"] = models.JobStatusDead
+		updates["finished_at"] = time.Now()
+	} else {
+		updates["status"] = models.JobStatusFailed
+		updates["run_at"] = *retryAt
+	}
+	return r.db.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND lease_owner = ?", id, models.JobStatusRunning, owner).
+		Updates(updates).Error
+}
+
+// RecoverExpired 回收租约已过期的运行中任务（执行者崩溃或重启），返回回收数量
+// 仍有重试次数的任务重新排队，否则进入 dead。
+func (r *JobRepo) RecoverExpired(queue string, now time.Time) (int64, error) {
+	base := r.db.Model(&models.Job{}).
+		Where("queue = ? AND status = ? AND lease_expires_at < ?", queue, models.JobStatusRunning, now)
+
+	dead := base.Session(&gorm.Session{}).
+		Where("attempts >= max_attempts").
+		Updates(map[string]interface{}{
+			"status":           models.JobStatusDead,
+			"last_error":       "执行者租约过期",
+			"lease_expires_at": nil,
+			"finished_at":      now,
+		})
+	if dead.Error != nil {
+		return 0, dead.Error
+	}
+
+	requeued := base.Session(&gorm.Session{}).
+		Where("attempts < max_attempts").
+		Updates(map[string]interface{}{
+			"status":           models.JobStatusQueued,
+			"last_error":       "执行者租约过期",
+			"lease_expires_at": nil,
+			"run_at":           now,
+		})
+	if requeued.Error != nil {
+		return dead.RowsAffected, requeued.Error
+	}
+
+	return dead.RowsAffected + requeued.RowsAffected, nil
+}
+
+// PurgeFinished 删除队列中在 before 之前结束（成功、取消或重试次数用尽）的任务，返回删除数量
+func (r *JobRepo) PurgeFinished(queue string, before time.Time) (int64, error) {
+	result := r.db.
+		Where("queue = ? AND status IN ? AND finished_at < ?", queue,
+			[]string{models.JobStatusSucceeded, models.JobStatusCanceled, models.JobStatusDead}, before).
+		Delete(&models.Job{})
+	return result.RowsAffected, result.Error
+}
+
+// ReleaseOwner 将 owner 持有的运行中任务放回队列（正常停止时使用，不计入执行次数）
+func (r *JobRepo) ReleaseOwner(queue, owner string) (int64, error) {
+	result := r.db.Model(&models.Job{}).
+		Where("queue = ? AND status = ? AND lease_owner = ?", queue, models.JobStatusRunning, owner).
+		Updates(map[string]interface{}{
+			"status":           models.JobStatusQueued,
+			"lease_expires_at": nil,
+			"attempts":         gorm.Expr("attempts - 1"),
+			"run_at":           time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
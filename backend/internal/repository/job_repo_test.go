@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"backend/database"
+	"backend/internal/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func newTestJob(key string) *models.Job {
+	return &models.Job{
+		Queue:       models.JobQueueCodeReview,
+		Status:      models.JobStatusQueued,
+		Payload:     "{}",
+		DedupKey:    key,
+		MaxAttempts: 3,
+		RunAt:       time.Now(),
+	}
+}
+
+func TestJobRepoCreateUnique(t *testing.T) {
+	repo := NewJobRepo(newTestDB(t))
+
+	created, err := repo.CreateUnique(newTestJob("repo:1:abc"))
+	if err != nil || !created {
+		t.Fatalf("first enqueue: created=%v err=%v", created, err)
+	}
+	created, err = repo.CreateUnique(newTestJob("repo:1:abc"))
+	if err != nil || created {
+		t.Fatalf("duplicate enqueue: created=%v err=%v", created, err)
+	}
+
+	// 绕过查询直接插入，模拟并发入队：由唯一索引拦截
+	if err := repo.Create(newTestJob("repo:1:abc")); !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("raw duplicate insert: err=%v, want ErrDuplicatedKey", err)
+	}
+
+	// 无去重键的任务不受限制
+	for i := 0; i < 2; i++ {
+		if created, err := repo.CreateUnique(newTestJob("")); err != nil || !created {
+			t.Fatalf("enqueue without key: created=%v err=%v", created, err)
+		}
+	}
+}
+
+func TestJobRepoCreateUniqueAfterFinished(t *testing.T) {
+	repo := NewJobRepo(newTestDB(t))
+
+	job := newTestJob("repo:1:abc")
+	if _, err := repo.CreateUnique(job); err != nil {
+		t.Fatal(err)
+	}
+	claimed, err := repo.Claim(job.Queue, "owner", time.Minute, models.JobPriorityNormal)
+	if err != nil || claimed == nil {
+		t.Fatalf("claim: job=%v err=%v", claimed, err)
+	}
+	if err := repo.Complete(claimed.ID, "owner"); err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := repo.CreateUnique(newTestJob("repo:1:abc"))
+	if err != nil || !created {
+		t.Fatalf("enqueue after finished: created=%v err=%v", created, err)
+	}
+}
+
+func TestJobRepoPurgeFinished(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewJobRepo(db)
+
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	jobs := []struct {
+		status     string
+		finishedAt *time.Time
+	}{
+		{models.JobStatusSucceeded, &old},
+		{models.JobStatusCanceled, &old},
+		{models.JobStatusDead, &old},
+		{models.JobStatusSucceeded, &now},
+		{models.JobStatusQueued, nil},
+	}
+	for _, j := range jobs {
+		job := newTestJob("")
+		job.Status = j.status
+		job.FinishedAt = j.finishedAt
+		if err := repo.Create(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := repo.PurgeFinished(models.JobQueueCodeReview, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("purged %d jobs, want 3", n)
+	}
+	var left int64
+	db.Model(&models.Job{}).Count(&left)
+	if left != 2 {
+		t.Fatalf("%d jobs left, want 2", left)
+	}
+}
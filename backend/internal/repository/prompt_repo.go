@@ -71,6 +71,13 @@ func (r *PromptRepo) Delete(id uint) error {
 	return r.db.Delete(&models.Prompt{}, id).Error
 }
 
+// GetByType 获取类型的全部提示词，按创建顺序排序
+func (r *PromptRepo) GetByType(promptType string) ([]models.Prompt, error) {
+	var prompts []models.Prompt
+	err := r.db.Where("type = ?", promptType).Order("id ASC").Find(&prompts).Error
+	return prompts, err
+}
+
 // GetByTypeAndScene 根据类型和场景获取提示词
 func (r *PromptRepo) GetByTypeAndScene(promptType, scene string) ([]models.Prompt, error) {
 	var prompts []models.Prompt
@@ -31,6 +31,18 @@ func (r *PushRepo) GetByID(id uint) (*models.Push, error) {
 	return &push, nil
 }
 
+// GetDetail 获取推送记录详情，包含每次发送的请求记录
+func (r *PushRepo) GetDetail(id uint) (*models.Push, error) {
+	var push models.Push
+	err := r.db.Preload("Repo").Preload("Target").Preload("Template").
+		Preload("Attempts", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&push, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &push, nil
+}
+
 // GetList 获取推送记录列表
 func (r *PushRepo) GetList(page, size int, repoID, targetID uint, status, keyword, startTime, endTime string) ([]models.Push, int64) {
 	var pushes []models.Push
@@ -95,6 +107,13 @@ func (r *PushRepo) UpdateStatus(id uint, status, errorMsg string) error {
 	return r.db.Model(&models.Push{}).Where("id = ?", id).Updates(updates).Error
 }
 
+// SaveDelivery 保存发送结果相关字段（状态、分段结果、重试信息）
+func (r *PushRepo) SaveDelivery(push *models.Push) error {
+	return r.db.Model(push).
+		Select("status", "error_msg", "parts", "queue_wait_ms", "pushed_at", "retry_count", "next_retry_at").
+		Updates(push).Error
+}
+
 // IncrementRetryCount 增加重试次数
 func (r *PushRepo) IncrementRetryCount(id uint) error {
 	return r.db.Model(&models.Push{}).Where("id = ?", id).Update("retry_count", gorm.Expr("retry_count + 1")).Error
@@ -104,12 +123,14 @@ func (r *PushRepo) IncrementRetryCount(id uint) error {
 func (r *PushRepo) GetStats(startDate, endDate string) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 	now := time.Now()
+	// 死信也计入失败
+	failedStatuses := []string{models.PushStatusFailed, models.PushStatusDead}
 
 	// 统计函数辅助
 	getCount := func(start time.Time) (total, success, failed int64) {
 		r.db.Model(&models.Push{}).Where("created_at >= ?", start).Count(&total)
 		r.db.Model(&models.Push{}).Where("created_at >= ? AND status = ?", start, models.PushStatusSuccess).Count(&success)
-		r.db.Model(&models.Push{}).Where("created_at >= ? AND status = ?", start, models.PushStatusFailed).Count(&failed)
+		r.db.Model(&models.Push{}).Where("created_at >= ? AND status IN ?", start, failedStatuses).Count(&failed)
 		return
 	}
 
@@ -152,7 +173,7 @@ func (r *PushRepo) GetStats(startDate, endDate string) (map[string]interface{},
 		var dTotal, dSuccess, dFailed int64
 		r.db.Model(&models.Push{}).Where("created_at >= ? AND created_at < ?", day, nextDay).Count(&dTotal)
 		r.db.Model(&models.Push{}).Where("created_at >= ? AND created_at < ? AND status = ?", day, nextDay, models.PushStatusSuccess).Count(&dSuccess)
-		r.db.Model(&models.Push{}).Where("created_at >= ? AND created_at < ? AND status = ?", day, nextDay, models.PushStatusFailed).Count(&dFailed)
+		r.db.Model(&models.Push{}).Where("created_at >= ? AND created_at < ? AND status IN ?", day, nextDay, failedStatuses).Count(&dFailed)
 		trend = append(trend, map[string]interface{}{
 			"date":    day.Format("2006-01-02"),
 			"total":   dTotal,
@@ -165,19 +186,13 @@ func (r *PushRepo) GetStats(startDate, endDate string) (map[string]interface{},
 	return stats, nil
 }
 
-// GetPendingPushes 获取待推送的记录
-func (r *PushRepo) GetPendingPushes(limit int) ([]models.Push, error) {
-	var pushes []models.Push
-	err := r.db.Where("status = ? AND retry_count < 3", models.PushStatusPending).
-		Order("created_at ASC").
-		Limit(limit).
-		Find(&pushes).Error
-	return pushes, err
-}
-
-// Delete 删除推送记录（物理删除，避免唯一索引冲突）
+// Delete 删除推送记录（物理删除），同时删除发送记录和不再被引用的审查问题
 func (r *PushRepo) Delete(id uint) error {
-	return r.db.Unscoped().Delete(&models.Push{}, id).Error
+	r.db.Where("push_id = ?", id).Delete(&models.DeliveryAttempt{})
+	if err := r.db.Unscoped().Delete(&models.Push{}, id).Error; err != nil {
+		return err
+	}
+	return r.deleteOrphanIssues()
 }
 
 // Transaction 执行事务
@@ -188,26 +203,85 @@ func (r *PushRepo) Transaction(fn func(txRepo *PushRepo) error) error {
 	})
 }
 
-// BatchDelete 批量删除（物理删除）
+// BatchDelete 批量删除（物理删除），同时删除发送记录和不再被引用的审查问题
 func (r *PushRepo) BatchDelete(ids []uint) error {
-	return r.db.Unscoped().Where("id IN ?", ids).Delete(&models.Push{}).Error
+	r.db.Where("push_id IN ?", ids).Delete(&models.DeliveryAttempt{})
+	if err := r.db.Unscoped().Where("id IN ?", ids).Delete(&models.Push{}).Error; err != nil {
+		return err
+	}
+	return r.deleteOrphanIssues()
+}
+
+// deleteOrphanIssues 删除已没有推送记录的提交的审查问题
+func (r *PushRepo) deleteOrphanIssues() error {
+	return r.db.Where("NOT EXISTS (SELECT 1 FROM pushes WHERE pushes.repo_id = review_issues.repo_id AND pushes.commit_id = review_issues.commit_id)").
+		Delete(&models.ReviewIssue{}).Error
+}
+
+// GetByDedupKey 获取同一推送目标下去重键相同的最近一条推送记录，since 不为空时只查找该时间之后创建的记录
+func (r *PushRepo) GetByDedupKey(targetID uint, dedupKey string, since *time.Time) (*models.Push, error) {
+	var push models.Push
+	query := r.db.Where("target_id = ? AND dedup_key = ?", targetID, dedupKey)
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+	err := query.Order("id DESC").First(&push).Error
+	if err != nil {
+		return nil, err
+	}
+	return &push, nil
 }
 
-// ExistsByCommitAndTarget 检查是否存在相同提交和目标的推送记录
-func (r *PushRepo) ExistsByCommitAndTarget(commitID string, targetID uint) bool {
-	var count int64
-	r.db.Model(&models.Push{}).
-		Where("commit_id = ? AND target_id = ?", commitID, targetID).
-		Count(&count)
-	return count > 0
+// GetByJobID 获取同一推送目标下由指定推送任务创建的推送记录
+func (r *PushRepo) GetByJobID(targetID, jobID uint) (*models.Push, error) {
+	var push models.Push
+	err := r.db.Where("target_id = ? AND job_id = ?", targetID, jobID).
+		Order("id DESC").
+		First(&push).Error
+	if err != nil {
+		return nil, err
+	}
+	return &push, nil
 }
 
-// GetByCommitAndTarget 根据commit_id和target_id获取推送记录
-func (r *PushRepo) GetByCommitAndTarget(commitID string, targetID uint) (*models.Push, error) {
+// GetPendingByDeliveryID 获取同一推送目标下同一次投递中仍未发送的推送记录
+func (r *PushRepo) GetPendingByDeliveryID(targetID uint, deliveryID string) (*models.Push, error) {
 	var push models.Push
-	err := r.db.Where("commit_id = ? AND target_id = ?", commitID, targetID).First(&push).Error
+	err := r.db.Where("target_id = ? AND delivery_id = ? AND status = ?", targetID, deliveryID, models.PushStatusPending).
+		Order("id DESC").
+		First(&push).Error
 	if err != nil {
 		return nil, err
 	}
 	return &push, nil
 }
+
+// GetDigestPending 获取等待合并到摘要中的推送记录
+// 配置了模型的仓库在 reviewSince 之后创建、审查尚未完成的推送暂不返回，等审查结论出来后再合并。
+func (r *PushRepo) GetDigestPending(targetID uint, reviewSince time.Time) ([]models.Push, error) {
+	var pushes []models.Push
+	err := r.db.Preload("Repo").
+		Where("target_id = ? AND status = ? AND digest_id IS NULL", targetID, models.PushStatusDigest).
+		Where("codeview_status <> ? OR created_at < ? OR repo_id IN (?)",
+			models.CodeviewStatusPending, reviewSince,
+			r.db.Model(&models.Repo{}).Select("id").Where("model_id IS NULL")).
+		Order("created_at ASC").
+		Find(&pushes).Error
+	return pushes, err
+}
+
+// MarkDigested 将推送记录标记为已通过摘要投递
+func (r *PushRepo) MarkDigested(ids []uint, digestID uint, status, errorMsg string) error {
+	updates := map[string]interface{}{
+		"digest_id":     digestID,
+		"delivered_via": models.DeliveredViaDigest,
+		"status":        status,
+	}
+	if errorMsg != "" {
+		updates["error_msg"] = errorMsg
+	}
+	if status == models.PushStatusSuccess {
+		updates["pushed_at"] = time.Now()
+	}
+	return r.db.Model(&models.Push{}).Where("id IN ?", ids).Updates(updates).Error
+}
@@ -95,6 +95,24 @@ func (r *RepoRepo) Update(repo *models.Repo) error {
 		"model_id":           repo.ModelID,
 		"commit_template_id": repo.CommitTemplateID,
 		"webhook_url":        repo.WebhookURL,
+		"dedup_policy":       repo.DedupPolicy,
+		"dedup_window":       repo.DedupWindow,
+		"priority_branches":  repo.PriorityBranches,
+		"priority_tags":      repo.PriorityTags,
+		"high_priority":      repo.HighPriority,
+		"publish_comments":   repo.PublishComments,
+		"comment_mode":       repo.CommentMode,
+		"comment_severity":   repo.CommentSeverity,
+		"publish_status":     repo.PublishStatus,
+		"status_severity":    repo.StatusSeverity,
+		"status_max_issues":  repo.StatusMaxIssues,
+		"ssh_known_hosts":    repo.SSHKnownHosts,
+		"diff_source":        repo.DiffSource,
+		"api_url":            repo.APIURL,
+		"context_budget":     repo.ContextBudget,
+		"context_related":    repo.ContextRelated,
+		"prompt_id":          repo.PromptID,
+		"review_scene":       repo.ReviewScene,
 	}
 	if repo.AccessToken != "" {
 		updates["access_token"] = repo.AccessToken
@@ -102,6 +120,14 @@ func (r *RepoRepo) Update(repo *models.Repo) error {
 	return r.db.Model(&models.Repo{}).Where("id = ?", repo.ID).Updates(updates).Error
 }
 
+// UpdateSSHKey 更新 SSH 部署密钥，均为空表示删除
+func (r *RepoRepo) UpdateSSHKey(id uint, privateKey, publicKey string) error {
+	return r.db.Model(&models.Repo{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"ssh_private_key": privateKey,
+		"ssh_public_key":  publicKey,
+	}).Error
+}
+
 // Delete 删除仓库
 func (r *RepoRepo) Delete(id uint) error {
 	// 先删除关联
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"time"
+
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReviewCacheRepo struct {
+	db *gorm.DB
+}
+
+func NewReviewCacheRepo(db *gorm.DB) *ReviewCacheRepo {
+	return &ReviewCacheRepo{db: db}
+}
+
+// Get 获取 since 之后缓存的审查结果
+func (r *ReviewCacheRepo) Get(repoID uint, fingerprint string, since time.Time) (*models.ReviewCache, error) {
+	var cache models.ReviewCache
+	err := r.db.Where("repo_id = ? AND fingerprint = ? AND created_at >= ?", repoID, fingerprint, since).First(&cache).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cache, nil
+}
+
+// Save 保存审查结果，指纹已存在时覆盖（如缓存过期后重新审查）
+func (r *ReviewCacheRepo) Save(cache *models.ReviewCache) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repo_id"}, {Name: "fingerprint"}},
+		DoUpdates: clause.AssignmentColumns([]string{"commit_id", "file_name", "prompt_id", "prompt_version", "model_id", "result", "hit_count", "created_at"}),
+	}).Create(cache).Error
+}
+
+// IncrementHit 增加命中次数
+func (r *ReviewCacheRepo) IncrementHit(id uint) error {
+	return r.db.Model(&models.ReviewCache{}).Where("id = ?", id).
+		UpdateColumn("hit_count", gorm.Expr("hit_count + ?", 1)).Error
+}
+
+// DeleteByRepo 清空仓库的审查结果缓存，返回删除的数量
+func (r *ReviewCacheRepo) DeleteByRepo(repoID uint) (int64, error) {
+	res := r.db.Where("repo_id = ?", repoID).Delete(&models.ReviewCache{})
+	return res.RowsAffected, res.Error
+}
+
+// DeleteBefore 删除 before 之前缓存的结果
+func (r *ReviewCacheRepo) DeleteBefore(before time.Time) (int64, error) {
+	res := r.db.Where("created_at < ?", before).Delete(&models.ReviewCache{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ReviewCommentRepo struct {
+	db *gorm.DB
+}
+
+func NewReviewCommentRepo(db *gorm.DB) *ReviewCommentRepo {
+	return &ReviewCommentRepo{db: db}
+}
+
+// Create 记录已发布的评论
+func (r *ReviewCommentRepo) Create(comment *models.ReviewComment) error {
+	return r.db.Create(comment).Error
+}
+
+// Save 更新评论记录
+func (r *ReviewCommentRepo) Save(comment *models.ReviewComment) error {
+	return r.db.Save(comment).Error
+}
+
+// GetSummary 获取提交在指定位置（提交或 PR/MR）上的汇总评论
+// PR/MR 上的汇总评论按编号查找、不区分提交，推送新提交后原地更新同一条评论。
+func (r *ReviewCommentRepo) GetSummary(repoID uint, commitID, target string, prNumber int) (*models.ReviewComment, error) {
+	var comment models.ReviewComment
+	query := r.db.Where("repo_id = ? AND target = ? AND pr_number = ? AND kind = ?",
+		repoID, target, prNumber, models.CommentKindSummary)
+	if target != models.CommentTargetPullRequest {
+		query = query.Where("commit_id = ?", commitID)
+	}
+	err := query.
+		Order("id DESC").
+		First(&comment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// LineFingerprints 获取提交在指定位置上已发布的行评论摘要
+func (r *ReviewCommentRepo) LineFingerprints(repoID uint, commitID, target string, prNumber int) (map[string]bool, error) {
+	var fingerprints []string
+	err := r.db.Model(&models.ReviewComment{}).
+		Where("repo_id = ? AND commit_id = ? AND target = ? AND pr_number = ? AND kind = ?",
+			repoID, commitID, target, prNumber, models.CommentKindLine).
+		Pluck("fingerprint", &fingerprints).Error
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(fingerprints))
+	for _, fp := range fingerprints {
+		seen[fp] = true
+	}
+	return seen, nil
+}
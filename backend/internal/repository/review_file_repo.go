@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ReviewFileRepo struct {
+	db *gorm.DB
+}
+
+func NewReviewFileRepo(db *gorm.DB) *ReviewFileRepo {
+	return &ReviewFileRepo{db: db}
+}
+
+// ReplaceForCommit 替换提交的文件审查记录（重新审查时覆盖上次结果）
+func (r *ReviewFileRepo) ReplaceForCommit(repoID uint, commitID string, files []models.ReviewFile) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo_id = ? AND commit_id = ?", repoID, commitID).Delete(&models.ReviewFile{}).Error; err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(files, 100).Error
+	})
+}
+
+// GetByCommit 获取提交的文件审查记录，按文件名排序
+func (r *ReviewFileRepo) GetByCommit(repoID uint, commitID string) ([]models.ReviewFile, error) {
+	var files []models.ReviewFile
+	err := r.db.Where("repo_id = ? AND commit_id = ?", repoID, commitID).
+		Order("file_name ASC").
+		Find(&files).Error
+	return files, err
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"time"
+
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ReviewIssueRepo struct {
+	db *gorm.DB
+}
+
+func NewReviewIssueRepo(db *gorm.DB) *ReviewIssueRepo {
+	return &ReviewIssueRepo{db: db}
+}
+
+// ReplaceForCommit 替换提交的审查问题（重新审查时覆盖上次结果），并同步更新推送记录上的问题统计
+func (r *ReviewIssueRepo) ReplaceForCommit(repoID uint, commitID string, issues []models.ReviewIssue) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repo_id = ? AND commit_id = ?", repoID, commitID).Delete(&models.ReviewIssue{}).Error; err != nil {
+			return err
+		}
+		if len(issues) > 0 {
+			if err := tx.CreateInBatches(issues, 100).Error; err != nil {
+				return err
+			}
+		}
+
+		var errorCount, warningCount int
+		for _, issue := range issues {
+			switch issue.Severity {
+			case models.IssueSeverityError:
+				errorCount++
+			case models.IssueSeverityWarning:
+				warningCount++
+			}
+		}
+		return tx.Model(&models.Push{}).
+			Where("repo_id = ? AND commit_id = ?", repoID, commitID).
+			Updates(map[string]interface{}{
+				"issue_count":   len(issues),
+				"error_count":   errorCount,
+				"warning_count": warningCount,
+			}).Error
+	})
+}
+
+// GetByCommit 获取提交的审查问题，按严重程度和位置排序
+func (r *ReviewIssueRepo) GetByCommit(repoID uint, commitID string) ([]models.ReviewIssue, error) {
+	var issues []models.ReviewIssue
+	err := r.db.Where("repo_id = ? AND commit_id = ?", repoID, commitID).
+		Order("CASE severity WHEN 'error' THEN 0 WHEN 'warning' THEN 1 ELSE 2 END, file ASC, line ASC, id ASC").
+		Find(&issues).Error
+	return issues, err
+}
+
+// CountBy 统计 start 之后发现的问题数量，按指定字段（severity 或 category）分组
+func (r *ReviewIssueRepo) CountBy(field string, start time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Name  string
+		Count int64
+	}
+	err := r.db.Model(&models.ReviewIssue{}).
+		Select(field+" AS name, COUNT(*) AS count").
+		Where("created_at >= ?", start).
+		Group(field).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Name] = row.Count
+	}
+	return counts, nil
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"backend/internal/models"
 
 	"gorm.io/gorm"
@@ -51,9 +53,14 @@ func (r *TargetRepo) GetList(page, size int, keyword, targetType, scope string)
 	return targets, total
 }
 
-// Update 更新推送目标
-func (r *TargetRepo) Update(target *models.Target) error {
-	return r.db.Save(target).Error
+// Update 更新推送目标，失败计数由发送结果单独维护，不随编辑覆盖
+// withStatus 为 false 时不写入状态和暂停时间，避免编辑期间熔断器的暂停被读取时的旧状态覆盖。
+func (r *TargetRepo) Update(target *models.Target, withStatus bool) error {
+	omit := []string{"consecutive_failures", "failure_count", "last_failure_at", "last_error"}
+	if !withStatus {
+		omit = append(omit, "status", "paused_at")
+	}
+	return r.db.Omit(omit...).Save(target).Error
 }
 
 // Delete 删除推送目标
@@ -89,16 +96,82 @@ func (r *TargetRepo) GetRepos(targetID uint) ([]models.Repo, error) {
 func (r *TargetRepo) GetByScopeAndRepo(repoID uint) ([]models.Target, error) {
 	var targets []models.Target
 
+	// 已暂停的目标也返回，由调用方记录为死信
+	statuses := []string{models.StatusActive, models.TargetStatusPaused}
+
 	// 获取全局推送目标
 	var globalTargets []models.Target
-	r.db.Where("scope = ? AND status = ?", models.TargetScopeGlobal, models.StatusActive).Find(&globalTargets)
+	r.db.Where("scope = ? AND status IN ?", models.TargetScopeGlobal, statuses).Find(&globalTargets)
 
 	// 获取指定仓库的推送目标
 	var repoTargets []models.Target
 	r.db.Joins("JOIN repo_targets ON repo_targets.target_id = targets.id").
-		Where("repo_targets.repo_id = ? AND targets.status = ?", repoID, models.StatusActive).
+		Where("repo_targets.repo_id = ? AND targets.status IN ?", repoID, statuses).
 		Find(&repoTargets)
 
 	targets = append(globalTargets, repoTargets...)
 	return targets, nil
 }
+
+// GetByDeliveryMode 获取指定投递方式的启用推送目标
+func (r *TargetRepo) GetByDeliveryMode(mode string) ([]models.Target, error) {
+	var targets []models.Target
+	err := r.db.Where("delivery_mode = ? AND status = ?", mode, models.StatusActive).Find(&targets).Error
+	return targets, err
+}
+
+// GetWithQuietHours 获取配置了免打扰的推送目标
+func (r *TargetRepo) GetWithQuietHours() ([]models.Target, error) {
+	var targets []models.Target
+	err := r.db.Where("quiet_hours IS NOT NULL AND quiet_hours != ''").Find(&targets).Error
+	return targets, err
+}
+
+// RecordSuccess 发送成功，清零连续失败次数
+func (r *TargetRepo) RecordSuccess(id uint) error {
+	return r.db.Model(&models.Target{}).
+		Where("id = ? AND consecutive_failures > 0", id).
+		UpdateColumn("consecutive_failures", 0).Error
+}
+
+// RecordFailure 记录一次发送失败，返回最新的连续失败次数
+func (r *TargetRepo) RecordFailure(id uint, errMsg string) (int, error) {
+	err := r.db.Model(&models.Target{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"consecutive_failures": gorm.Expr("consecutive_failures + 1"),
+		"failure_count":        gorm.Expr("failure_count + 1"),
+		"last_failure_at":      time.Now(),
+		"last_error":           errMsg,
+	}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var target models.Target
+	if err := r.db.Select("consecutive_failures").First(&target, id).Error; err != nil {
+		return 0, err
+	}
+	return target.ConsecutiveFailures, nil
+}
+
+// Pause 暂停启用中的推送目标，返回是否由本次调用暂停（避免重复告警）
+func (r *TargetRepo) Pause(id uint) (bool, error) {
+	result := r.db.Model(&models.Target{}).
+		Where("id = ? AND status = ?", id, models.StatusActive).
+		UpdateColumns(map[string]interface{}{
+			"status":    models.TargetStatusPaused,
+			"paused_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Resume 恢复已暂停的推送目标并清零连续失败次数
+func (r *TargetRepo) Resume(id uint) (bool, error) {
+	result := r.db.Model(&models.Target{}).
+		Where("id = ? AND status = ?", id, models.TargetStatusPaused).
+		UpdateColumns(map[string]interface{}{
+			"status":               models.StatusActive,
+			"paused_at":            nil,
+			"consecutive_failures": 0,
+		})
+	return result.RowsAffected > 0, result.Error
+}
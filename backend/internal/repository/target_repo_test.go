@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"testing"
+
+	"backend/internal/models"
+)
+
+// 编辑期间熔断器暂停了目标，保存旧数据时不覆盖暂停状态
+func TestTargetUpdateKeepsConcurrentPause(t *testing.T) {
+	db := newTestDB(t)
+	r := NewTargetRepo(db)
+	target := &models.Target{Name: "hook", Type: models.TargetTypeWebhook, Config: &models.Config{WebhookURL: "http://example.com"}}
+	if err := r.Create(target); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := r.GetByID(target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paused, err := r.Pause(target.ID); err != nil || !paused {
+		t.Fatalf("Pause() = %v, %v", paused, err)
+	}
+	loaded.Name = "renamed"
+	if err := r.Update(loaded, false); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.GetByID(target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "renamed" || got.Status != models.TargetStatusPaused || got.PausedAt == nil {
+		t.Errorf("target = name %q, status %q, paused_at %v; want renamed and still paused", got.Name, got.Status, got.PausedAt)
+	}
+
+	// 明确修改状态时写入
+	got.Status, got.PausedAt = models.StatusActive, nil
+	if err := r.Update(got, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.GetByID(target.ID); got.Status != models.StatusActive || got.PausedAt != nil {
+		t.Errorf("status = %q, paused_at = %v; want active", got.Status, got.PausedAt)
+	}
+}
@@ -1,73 +1,45 @@
 package services
 
 import (
+	"encoding/json"
 	"strconv"
-	"sync"
+
+	"backend/internal/models"
+
+	"gorm.io/gorm"
 )
 
 type CodeReviewJob struct {
-	RepoID   uint
-	PushID   uint
-	CommitID string
-	Branch   string
+	RepoID   uint   `json:"repo_id"`
+	PushID   uint   `json:"push_id"`
+	CommitID string `json:"commit_id"`
+	Branch   string `json:"branch"`
+	Priority int    `json:"priority,omitempty"`
 }
 
+// CodeReviewQueue 代码审查任务队列，同一仓库同一提交未完成时不重复入队
+// 默认 2 个执行者，创建后由所属服务设置回调并调用 Start。
 type CodeReviewQueue struct {
-	jobs     chan CodeReviewJob
-	handler  func(CodeReviewJob)
-	mu       sync.Mutex
-	inFlight map[string]struct{}
+	*JobQueue
 }
 
-func NewCodeReviewQueue(bufferSize int, workerCount int, handler func(CodeReviewJob)) *CodeReviewQueue {
-	if bufferSize <= 0 {
-		bufferSize = 100
-	}
-	if workerCount <= 0 {
-		workerCount = 2
-	}
-
+func NewCodeReviewQueue(db *gorm.DB, opts QueueOptions, handler func(CodeReviewJob) error) *CodeReviewQueue {
 	q := &CodeReviewQueue{
-		jobs:     make(chan CodeReviewJob, bufferSize),
-		handler:  handler,
-		inFlight: make(map[string]struct{}),
-	}
-
-	for i := 0; i < workerCount; i++ {
-		go func() {
-			for job := range q.jobs {
-				q.handler(job)
-				q.finish(job)
+		JobQueue: NewJobQueue(db, models.JobQueueCodeReview, 2, func(job *models.Job) error {
+			var payload CodeReviewJob
+			if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+				return err
 			}
-		}()
+			return handler(payload)
+		}),
 	}
-
+	q.Configure(opts)
 	return q
 }
 
-func (q *CodeReviewQueue) Enqueue(job CodeReviewJob) bool {
-	key := q.key(job)
-	q.mu.Lock()
-	if _, ok := q.inFlight[key]; ok {
-		q.mu.Unlock()
-		return false
-	}
-	q.inFlight[key] = struct{}{}
-	q.mu.Unlock()
-
-	select {
-	case q.jobs <- job:
-		return true
-	default:
-		q.finish(job)
-		return false
-	}
-}
-
-func (q *CodeReviewQueue) finish(job CodeReviewJob) {
-	q.mu.Lock()
-	delete(q.inFlight, q.key(job))
-	q.mu.Unlock()
+// Enqueue 按任务优先级入队，返回 false 表示同一提交的审查已在队列中
+func (q *CodeReviewQueue) Enqueue(job CodeReviewJob) (bool, error) {
+	return q.JobQueue.EnqueuePriority(job, q.key(job), job.Priority)
 }
 
 func (q *CodeReviewQueue) key(job CodeReviewJob) string {
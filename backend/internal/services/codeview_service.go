@@ -3,12 +3,14 @@ package services
 import (
 	"encoding/json"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	"backend/internal/models"
 	"backend/internal/repository"
 	"backend/pkg/ai"
+	"backend/pkg/git"
 	"backend/utils/logger"
 
 	"gorm.io/gorm"
@@ -18,8 +20,14 @@ type CodeViewService struct {
 	db         *gorm.DB
 	promptRepo *repository.PromptRepo
 	modelRepo  *repository.AIModelRepo
+	cacheRepo  *repository.ReviewCacheRepo
 	modelServ  *AIModelService
 	logServ    *LogService
+	// cacheTTL 审查结果缓存的有效期，为 0 时不缓存
+	cacheTTL time.Duration
+	// pruneMu 保护 prunedAt，过期缓存每小时最多清理一次
+	pruneMu  sync.Mutex
+	prunedAt time.Time
 }
 
 func NewCodeViewService(db *gorm.DB) *CodeViewService {
@@ -27,19 +35,29 @@ func NewCodeViewService(db *gorm.DB) *CodeViewService {
 		db:         db,
 		promptRepo: repository.NewPromptRepo(db),
 		modelRepo:  repository.NewAIModelRepo(db),
+		cacheRepo:  repository.NewReviewCacheRepo(db),
 		logServ:    NewLogService(db),
 	}
 }
 
+// WithCache 开启审查结果缓存，相同的文件差异在 ttl 内复用审查结果
+func (s *CodeViewService) WithCache(ttl time.Duration) *CodeViewService {
+	s.cacheTTL = ttl
+	return s
+}
+
 // CodeViewInput CODEVIEW输入
 type CodeViewInput struct {
 	FileName    string
-	FileContent string
+	CommitID    string
+	FileContent string // 变更后的完整文件，审查时按仓库的上下文预算裁剪并加上行号
 	DiffContent string
 	Language    string
 	RepoName    string
 	Branch      string
 	CommitMsg   string
+	// RelatedFiles 相关文件（相对导入的文件、同目录的文件），与 FileContent 共用上下文预算
+	RelatedFiles []RelatedFile
 }
 
 // CodeViewResult CODEVIEW结果
@@ -47,32 +65,35 @@ type CodeViewResult struct {
 	Result  string  `json:"result"` // 通过/有建议/有问题
 	Issues  []Issue `json:"issues"`
 	Summary string  `json:"summary"`
+	Source  string  `json:"source"` // json/text，问题是否来自结构化输出
+	// Chunks 差异超出模型上下文时拆分的段数，SkippedChunks 为超出上限未审查的段数，FailedChunks 为审查失败的段数
+	Chunks        int `json:"chunks,omitempty"`
+	SkippedChunks int `json:"skipped_chunks,omitempty"`
+	FailedChunks  int `json:"failed_chunks,omitempty"`
+	// CachedFrom 复用的审查结果来自的提交，为空表示本次调用模型审查
+	CachedFrom string `json:"cached_from,omitempty"`
+	// 产生审查结果的提示词（PromptMatch 为选择方式）和模型
+	PromptID      uint   `json:"prompt_id,omitempty"`
+	PromptName    string `json:"prompt_name,omitempty"`
+	PromptVersion int    `json:"prompt_version,omitempty"`
+	PromptMatch   string `json:"prompt_match,omitempty"`
+	ModelID       uint   `json:"model_id,omitempty"`
+	ModelName     string `json:"model_name,omitempty"`
 }
 
 // Issue 问题
 type Issue struct {
-	Line     int    `json:"line"`
-	Severity string `json:"severity"` // info/warning/error
-	Message  string `json:"message"`
-	Code     string `json:"code"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Severity   string `json:"severity"` // info/warning/error
+	Category   string `json:"category"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion"`
+	Code       string `json:"code,omitempty"`
 }
 
-// Review 执行代码审查
-func (s *CodeViewService) Review(repoID uint, input CodeViewInput) (*CodeViewResult, error) {
-	// 获取仓库信息
-	repoRepo := repository.NewRepoRepo(s.db)
-	repo, err := repoRepo.GetByID(repoID)
-	if err != nil {
-		return nil, err
-	}
-
-	// 获取提示词
-	prompts, err := s.promptRepo.GetByTypeAndScene(models.PromptTypeCodeView, "")
-	if err != nil || len(prompts) == 0 {
-		// 使用默认提示词 (优先使用diff审查)
-		prompts = []models.Prompt{
-			{
-				Content: `请作为专业的代码审查助手，审查以下代码变更（diff格式）。
+// builtinCodeViewPrompt 没有可用的代码审查提示词时使用的内置提示词 (优先使用diff审查)
+const builtinCodeViewPrompt = `请作为专业的代码审查助手，审查以下代码变更（diff格式）。
 
 审查要点：
 1. 代码规范和最佳实践
@@ -95,68 +116,223 @@ func (s *CodeViewService) Review(repoID uint, input CodeViewInput) (*CodeViewRes
 {{.DiffContent}}
 {{else}}
 {{.FileContent}}
-{{end}}`,
-			},
+{{end}}`
+
+// Review 执行代码审查
+func (s *CodeViewService) Review(repoID uint, input CodeViewInput) (*CodeViewResult, error) {
+	// 获取仓库信息
+	repoRepo := repository.NewRepoRepo(s.db)
+	repo, err := repoRepo.GetByID(repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按仓库、文件语言和审查场景选择提示词
+	prompt, match := s.resolvePrompt(repo, input.Language)
+
+	// 获取模型：提示词指定的模型，其次为仓库的模型和默认模型
+	model := s.resolveModel(repo, &prompt)
+
+	// 如果还是没有AI客户端，返回空结果
+	if model == nil {
+		result := &CodeViewResult{
+			Result:  "跳过",
+			Summary: "未配置AI模型",
 		}
+		result.setSource(&prompt, match, nil)
+		return result, nil
+	}
+	aiClient := newReviewClient(model)
+
+	// 相同的差异、提示词和模型复用缓存的审查结果
+	fingerprint := reviewFingerprint(repo, input, &prompt, model)
+	if cached := s.cachedReview(repo.ID, fingerprint); cached != nil {
+		cached.setSource(&prompt, match, model)
+		logger.Info("CodeView cache hit", map[string]interface{}{
+			"repo_id":     repoID,
+			"file_name":   input.FileName,
+			"cached_from": cached.CachedFrom,
+		})
+		return cached, nil
 	}
 
-	// 获取模型
-	var aiClient *ai.Client
-	if repo.ModelID != nil {
-		model, err := s.modelRepo.GetByID(*repo.ModelID)
-		if err == nil {
-			var params map[string]interface{}
-			json.Unmarshal([]byte(model.Params), &params)
-			modelName := strings.TrimSpace(model.Name)
-			if modelName == "" {
-				modelName = strings.TrimSpace(model.Type)
+	// 渲染提示词，完整文件等上下文在差异放得下时再按预算加入
+	diffInput := input
+	if input.DiffContent != "" {
+		diffInput.FileContent, diffInput.RelatedFiles = "", nil
+	}
+	promptText, err := s.renderPrompt(prompt.Content, diffInput)
+	if err != nil {
+		return nil, err
+	}
+	promptText += reviewOutputSpec
+
+	// 差异超出模型上下文时按块拆分为多次调用，不附带上下文
+	available := aiClient.ContextSize() - aiClient.OutputTokens() - aiClient.EstimateTokens(promptText)
+	if input.DiffContent != "" && available < 0 {
+		merged, err := s.reviewChunks(aiClient, repo, model, prompt.Content, diffInput)
+		if err != nil {
+			return nil, err
+		}
+		merged.setSource(&prompt, match, model)
+		if merged.FailedChunks == 0 {
+			s.saveReviewCache(repo.ID, fingerprint, input, &prompt, model, merged)
+		}
+		return merged, nil
+	}
+
+	// 在仓库的上下文预算和模型剩余上下文内附带完整文件和相关文件
+	if input.DiffContent != "" && repo.ContextBudget > 0 && (input.FileContent != "" || len(input.RelatedFiles) > 0) {
+		fitted := fitReviewContext(input, min(repo.ContextBudget, available), aiClient.EstimateTokens)
+		if fitted.FileContent != "" || len(fitted.RelatedFiles) > 0 {
+			rendered, err := s.renderPrompt(prompt.Content, fitted)
+			if err != nil {
+				return nil, err
 			}
-			aiClient = ai.NewClientWithConfig(ai.Config{
-				APIURL: model.APIURL,
-				APIKey: model.APIKey,
-				Model:  modelName,
-				Params: params,
-			})
+			promptText = rendered + contextSections(rendered, fitted) + reviewOutputSpec
+		}
+	}
+
+	codeViewResult, err := s.callReview(aiClient, model, promptText, input.FileName)
+	if err != nil {
+		return nil, err
+	}
+	codeViewResult.setSource(&prompt, match, model)
+	s.saveReviewCache(repo.ID, fingerprint, input, &prompt, model, codeViewResult)
+
+	logger.Info("CodeView completed", map[string]interface{}{
+		"repo_id":        repoID,
+		"file_name":      input.FileName,
+		"result":         codeViewResult.Result,
+		"prompt_id":      prompt.ID,
+		"prompt_version": prompt.Version,
+		"prompt_match":   match,
+		"model":          model.Name,
+	})
+
+	return codeViewResult, nil
+}
+
+// resolvePrompt 选择文件审查使用的提示词，依次为仓库指定的提示词、适用于文件语言的提示词、
+// 场景与仓库审查场景相同的提示词、未设置语言和场景的默认提示词，都没有时使用内置提示词。
+// 适用于文件语言的提示词中，场景为空或与仓库审查场景相同的才可用，场景相同的优先。
+func (s *CodeViewService) resolvePrompt(repo *models.Repo, language string) (models.Prompt, string) {
+	if repo.PromptID != nil {
+		prompt, err := s.promptRepo.GetByID(*repo.PromptID)
+		if err == nil && prompt.Type == models.PromptTypeCodeView {
+			return *prompt, models.PromptMatchRepo
 		}
+		logger.Warn("Repo prompt not available, falling back", map[string]interface{}{
+			"repo_id":   repo.ID,
+			"prompt_id": *repo.PromptID,
+		})
 	}
 
-	// 如果没有指定模型，使用默认模型
-	if aiClient == nil {
-		model, err := s.modelRepo.GetDefault()
-		if err == nil {
-			var params map[string]interface{}
-			json.Unmarshal([]byte(model.Params), &params)
-			modelName := strings.TrimSpace(model.Name)
-			if modelName == "" {
-				modelName = strings.TrimSpace(model.Type)
+	prompts, _ := s.promptRepo.GetByType(models.PromptTypeCodeView)
+	var byLanguage, byScene, byDefault *models.Prompt
+	for i := range prompts {
+		p := &prompts[i]
+		sceneMatched := repo.ReviewScene != "" && p.Scene == repo.ReviewScene
+		switch {
+		case p.Language != "":
+			if !promptLanguageMatches(p.Language, language) || (p.Scene != "" && !sceneMatched) {
+				continue
+			}
+			if byLanguage == nil || (sceneMatched && byLanguage.Scene == "") {
+				byLanguage = p
+			}
+		case p.Scene != "":
+			if sceneMatched && byScene == nil {
+				byScene = p
+			}
+		default:
+			if byDefault == nil {
+				byDefault = p
 			}
-			aiClient = ai.NewClientWithConfig(ai.Config{
-				APIURL: model.APIURL,
-				APIKey: model.APIKey,
-				Model:  modelName,
-				Params: params,
-			})
 		}
 	}
+	switch {
+	case byLanguage != nil:
+		return *byLanguage, models.PromptMatchLanguage
+	case byScene != nil:
+		return *byScene, models.PromptMatchScene
+	case byDefault != nil:
+		return *byDefault, models.PromptMatchDefault
+	}
+	return models.Prompt{Name: "内置提示词", Type: models.PromptTypeCodeView, Content: builtinCodeViewPrompt}, models.PromptMatchBuiltin
+}
 
-	// 如果还是没有AI客户端，返回空结果
-	if aiClient == nil {
-		return &CodeViewResult{
-			Result:  "跳过",
-			Summary: "未配置AI模型",
-		}, nil
+// promptLanguageMatches 提示词的适用语言是否包含文件语言，多个语言以逗号、顿号或空格分隔，不区分大小写
+func promptLanguageMatches(languages, language string) bool {
+	if language == "" || language == "Unknown" {
+		return false
+	}
+	for _, l := range strings.FieldsFunc(languages, func(r rune) bool {
+		return r == ',' || r == '，' || r == '、' || r == '/' || r == ' '
+	}) {
+		if strings.EqualFold(l, language) {
+			return true
+		}
 	}
+	return false
+}
 
-	// 使用第一个提示词
-	prompt := prompts[0]
+// resolveModel 审查使用的模型：提示词指定的模型，其次为仓库指定的模型，都没有时使用默认模型
+func (s *CodeViewService) resolveModel(repo *models.Repo, prompt *models.Prompt) *models.AIModel {
+	if prompt.ModelID != nil {
+		if model, err := s.modelRepo.GetByID(*prompt.ModelID); err == nil {
+			return model
+		}
+		logger.Warn("Prompt model not available, falling back", map[string]interface{}{
+			"prompt_id": prompt.ID,
+			"model_id":  *prompt.ModelID,
+		})
+	}
+	if repo.ModelID != nil {
+		if model, err := s.modelRepo.GetByID(*repo.ModelID); err == nil {
+			return model
+		}
+	}
+	if model, err := s.modelRepo.GetDefault(); err == nil {
+		return model
+	}
+	return nil
+}
 
-	// 渲染提示词
-	promptText, err := s.renderPrompt(prompt.Content, input)
-	if err != nil {
-		return nil, err
+// newReviewClient 按模型配置创建审查使用的AI客户端，模型名称为空时使用提供商类型
+// 支持结构化输出的模型按审查结果的 JSON Schema 返回。
+func newReviewClient(model *models.AIModel) *ai.Client {
+	var params map[string]interface{}
+	json.Unmarshal([]byte(model.Params), &params)
+	modelName := strings.TrimSpace(model.Name)
+	if modelName == "" {
+		modelName = strings.TrimSpace(model.Type)
 	}
+	return ai.NewClientWithConfig(ai.Config{
+		APIURL:      model.APIURL,
+		APIKey:      model.APIKey,
+		Model:       modelName,
+		Params:      params,
+		ContextSize: model.ContextSize,
+		JSONSchema:  reviewOutputSchema,
+	})
+}
 
+// setSource 记录产生审查结果的提示词和模型
+func (r *CodeViewResult) setSource(prompt *models.Prompt, match string, model *models.AIModel) {
+	r.PromptID = prompt.ID
+	r.PromptName = prompt.Name
+	r.PromptVersion = prompt.Version
+	r.PromptMatch = match
+	r.ModelID, r.ModelName = 0, ""
+	if model != nil {
+		r.ModelID = model.ID
+		r.ModelName = model.Name
+	}
+}
 
+// callReview 调用AI审查并解析结果，调用记录和次数计入实际使用的模型
+func (s *CodeViewService) callReview(aiClient *ai.Client, model *models.AIModel, promptText, fileName string) (*CodeViewResult, error) {
 	// 调用AI
 	// 提示词模板中已经包含代码内容，这里传空字符串避免重复
 	startTime := time.Now()
@@ -164,28 +340,78 @@ func (s *CodeViewService) Review(repoID uint, input CodeViewInput) (*CodeViewRes
 	duration := int(time.Since(startTime).Milliseconds())
 
 	if err != nil {
-		if repo.ModelID != nil {
-			s.logServ.LogAICall(*repo.ModelID, promptText, err.Error(), duration, false)
-		}
+		s.logServ.LogAICall(model.ID, promptText, err.Error(), duration, false)
 		return nil, err
 	}
 
 	// 解析结果
-	codeViewResult := s.parseResult(result)
+	codeViewResult := s.parseResult(result, fileName)
 
 	// 更新模型调用次数
-	if repo.ModelID != nil {
-		s.modelRepo.IncrementCallCount(*repo.ModelID)
-		s.logServ.LogAICall(*repo.ModelID, promptText, result, duration, true)
+	s.modelRepo.IncrementCallCount(model.ID)
+	s.logServ.LogAICall(model.ID, promptText, result, duration, true)
+
+	return codeViewResult, nil
+}
+
+// reviewChunks 在块边界拆分差异，逐段审查后合并结果
+// 超出 maxReviewChunks 的部分不再审查，在结果中注明未审查的行范围。
+func (s *CodeViewService) reviewChunks(aiClient *ai.Client, repo *models.Repo, model *models.AIModel, promptTpl string, input CodeViewInput) (*CodeViewResult, error) {
+	// 提示词本身（不含代码）占用的 token
+	empty := input
+	empty.DiffContent, empty.FileContent, empty.RelatedFiles = "", "", nil
+	overheadText, err := s.renderPrompt(promptTpl, empty)
+	if err != nil {
+		return nil, err
+	}
+	budget := aiClient.ContextSize() - aiClient.OutputTokens() - aiClient.EstimateTokens(overheadText+reviewOutputSpec)
+	if budget < minChunkTokens {
+		budget = minChunkTokens
+	}
+
+	chunks := git.SplitPatch(input.DiffContent, budget, aiClient.EstimateTokens)
+	reviewed := chunks
+	if len(reviewed) > maxReviewChunks {
+		reviewed = reviewed[:maxReviewChunks]
+	}
+
+	results := make([]*CodeViewResult, len(reviewed))
+	var lastErr error
+	failed := 0
+	for i, chunk := range reviewed {
+		chunkInput := input
+		chunkInput.DiffContent = chunk.Patch
+		promptText, err := s.renderPrompt(promptTpl, chunkInput)
+		if err != nil {
+			return nil, err
+		}
+		res, err := s.callReview(aiClient, model, promptText+reviewOutputSpec, input.FileName)
+		if err != nil {
+			logger.Warn("Failed to review chunk", map[string]interface{}{
+				"repo_id":   repo.ID,
+				"file_name": input.FileName,
+				"chunk":     i + 1,
+				"error":     err.Error(),
+			})
+			lastErr = err
+			failed++
+			continue
+		}
+		results[i] = res
+	}
+	if failed == len(reviewed) {
+		return nil, lastErr
 	}
 
+	merged := mergeChunkResults(chunks, results)
 	logger.Info("CodeView completed", map[string]interface{}{
-		"repo_id":   repoID,
+		"repo_id":   repo.ID,
 		"file_name": input.FileName,
-		"result":    codeViewResult.Result,
+		"result":    merged.Result,
+		"chunks":    len(chunks),
+		"skipped":   merged.SkippedChunks,
 	})
-
-	return codeViewResult, nil
+	return merged, nil
 }
 
 // renderPrompt 渲染提示词模板
@@ -206,27 +432,9 @@ func (s *CodeViewService) renderPrompt(promptTpl string, input CodeViewInput) (s
 	return buf.String(), nil
 }
 
-// parseResult 解析AI返回结果
-func (s *CodeViewService) parseResult(result string) *CodeViewResult {
-	// 简化解析逻辑
-	// 实际项目中可以使用更复杂的解析方式
-
-	codeViewResult := &CodeViewResult{
-		Result:  "有建议",
-		Summary: result,
-		Issues:  []Issue{},
-	}
-
-	// 检查结果中是否包含关键信息
-	lowerResult := strings.ToLower(result)
-	if strings.Contains(lowerResult, "通过") || strings.Contains(lowerResult, "pass") {
-		codeViewResult.Result = "通过"
-	}
-	if strings.Contains(lowerResult, "错误") || strings.Contains(lowerResult, "error") {
-		codeViewResult.Result = "有问题"
-	}
-
-	return codeViewResult
+// parseResult 解析AI返回结果，优先按 JSON 解析，模型返回自由文本时逐条提取问题
+func (s *CodeViewService) parseResult(result, fileName string) *CodeViewResult {
+	return parseReviewOutput(result, fileName)
 }
 
 // BatchReview 批量审查
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// newTestAIServer 模拟 AI 接口，fail 为 true 时返回 500
+func newTestAIServer(t *testing.T, fail bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, `{"error":{"message":"unavailable"}}`)
+			return
+		}
+		io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"{\"result\":\"通过\",\"summary\":\"\",\"issues\":[]}"}}]}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// seedReview 创建仓库使用模型 A、提示词指定模型 B 的审查配置，返回仓库和两个模型
+func seedReview(t *testing.T, db *gorm.DB, apiURL string) (*models.Repo, *models.AIModel, *models.AIModel) {
+	t.Helper()
+	repoModel := &models.AIModel{Name: "repo-model", Type: "gpt-4o", APIURL: apiURL, APIKey: "k"}
+	promptModel := &models.AIModel{Name: "prompt-model", Type: "gpt-4o", APIURL: apiURL, APIKey: "k"}
+	for _, m := range []*models.AIModel{repoModel, promptModel} {
+		if err := db.Create(m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	prompt := &models.Prompt{Name: "p", Type: models.PromptTypeCodeView, Content: "review {{.DiffContent}}", ModelID: &promptModel.ID}
+	if err := db.Create(prompt).Error; err != nil {
+		t.Fatal(err)
+	}
+	repo := &models.Repo{Name: "demo", URL: "https://example.com/demo.git", Type: "github", WebhookID: "w1", WebhookURL: "/webhook/w1", ModelID: &repoModel.ID, PromptID: &prompt.ID}
+	if err := db.Create(repo).Error; err != nil {
+		t.Fatal(err)
+	}
+	return repo, repoModel, promptModel
+}
+
+// aiCallModelIDs 调用日志中记录的模型 ID
+func aiCallModelIDs(t *testing.T, db *gorm.DB) []uint {
+	t.Helper()
+	var logs []models.Log
+	db.Where("type = ?", models.LogTypeAICall).Find(&logs)
+	var ids []uint
+	for _, l := range logs {
+		var detail struct {
+			ModelID uint `json:"model_id"`
+		}
+		json.Unmarshal([]byte(l.Detail), &detail)
+		ids = append(ids, detail.ModelID)
+	}
+	return ids
+}
+
+func callCount(t *testing.T, db *gorm.DB, id uint) int {
+	t.Helper()
+	var m models.AIModel
+	if err := db.First(&m, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return m.CallCount
+}
+
+// 提示词指定的模型与仓库模型不同时，调用次数和日志计入实际使用的提示词模型
+func TestReviewCountsResolvedModel(t *testing.T) {
+	db := newTestDB(t)
+	repo, repoModel, promptModel := seedReview(t, db, newTestAIServer(t, false).URL)
+
+	res, err := NewCodeViewService(db).Review(repo.ID, CodeViewInput{FileName: "main.go", DiffContent: "@@ -1 +1 @@\n-a\n+b\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ModelID != promptModel.ID {
+		t.Errorf("result model = %d, want prompt model %d", res.ModelID, promptModel.ID)
+	}
+	if got := callCount(t, db, promptModel.ID); got != 1 {
+		t.Errorf("prompt model call count = %d, want 1", got)
+	}
+	if got := callCount(t, db, repoModel.ID); got != 0 {
+		t.Errorf("repo model call count = %d, want 0", got)
+	}
+	if ids := aiCallModelIDs(t, db); len(ids) != 1 || ids[0] != promptModel.ID {
+		t.Errorf("logged model ids = %v, want [%d]", ids, promptModel.ID)
+	}
+}
+
+func TestReviewLogsFailureForResolvedModel(t *testing.T) {
+	db := newTestDB(t)
+	repo, _, promptModel := seedReview(t, db, newTestAIServer(t, true).URL)
+
+	if _, err := NewCodeViewService(db).Review(repo.ID, CodeViewInput{FileName: "main.go", DiffContent: "@@ -1 +1 @@\n-a\n+b\n"}); err == nil {
+		t.Fatal("expected review error")
+	}
+	if got := callCount(t, db, promptModel.ID); got != 0 {
+		t.Errorf("call count = %d, want 0 for failed call", got)
+	}
+	if ids := aiCallModelIDs(t, db); len(ids) != 1 || ids[0] != promptModel.ID {
+		t.Errorf("logged model ids = %v, want [%d]", ids, promptModel.ID)
+	}
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+
+	"backend/internal/models"
+	"backend/pkg/git"
+	"backend/utils/logger"
+)
+
+// commitStatusContext 提交状态名称，在平台分支保护中按该名称设置必需检查
+const commitStatusContext = "ai-code-review"
+
+// CommitStatusService 将代码审查结论发布为提交状态
+// 审查任务入队时为 pending，完成后按仓库的失败阈值设为 success 或 failure，审查出错或取消时为 error。
+type CommitStatusService struct {
+	baseURL string
+}
+
+func NewCommitStatusService(baseURL string) *CommitStatusService {
+	return &CommitStatusService{baseURL: baseURL}
+}
+
+// Pending 审查任务已入队
+func (s *CommitStatusService) Pending(repo *models.Repo, pushID uint, commitID string) {
+	s.set(repo, pushID, commitID, git.StatusPending, "AI 代码审查排队中")
+}
+
+// Report 按审查问题和仓库的失败阈值设置审查结论
+func (s *CommitStatusService) Report(repo *models.Repo, push *models.Push, issues []models.ReviewIssue) {
+	state, description := reviewVerdict(repo, issues)
+	s.set(repo, push.ID, push.CommitID, state, description)
+}
+
+// Skip 审查已跳过（无代码文件等），不阻止合并
+func (s *CommitStatusService) Skip(repo *models.Repo, pushID uint, commitID, reason string) {
+	s.set(repo, pushID, commitID, git.StatusSuccess, reason)
+}
+
+// Error 审查失败或已取消
+func (s *CommitStatusService) Error(repo *models.Repo, pushID uint, commitID, reason string) {
+	s.set(repo, pushID, commitID, git.StatusError, reason)
+}
+
+// set 设置提交状态，未开启提交状态的仓库不处理，失败只记录日志
+func (s *CommitStatusService) set(repo *models.Repo, pushID uint, commitID, state, description string) {
+	if !repo.PublishStatus {
+		return
+	}
+	client, err := newForgeClient(repo)
+	if err == nil {
+		status := git.CommitStatus{
+			State:       state,
+			Context:     commitStatusContext,
+			Description: description,
+		}
+		if s.baseURL != "" {
+			status.TargetURL = fmt.Sprintf("%s/web/#/pushes/review?id=%d", s.baseURL, pushID)
+		}
+		err = client.SetCommitStatus(commitID, status)
+	}
+	if err != nil {
+		logger.Error("Failed to set commit status", map[string]interface{}{
+			"repo_id":   repo.ID,
+			"commit_id": commitID,
+			"state":     state,
+			"error":     err.Error(),
+		})
+	}
+}
+
+// thresholdLabels 失败阈值的严重程度在状态描述中的显示名称
+var thresholdLabels = map[string]string{
+	models.IssueSeverityError:   "错误",
+	models.IssueSeverityWarning: "警告及以上",
+	models.IssueSeverityInfo:    "",
+}
+
+// reviewVerdict 达到仓库设置的严重程度的问题超过允许数量时为 failure
+func reviewVerdict(repo *models.Repo, issues []models.ReviewIssue) (string, string) {
+	severity := repo.StatusSeverity
+	label, ok := thresholdLabels[severity]
+	if !ok {
+		severity = models.IssueSeverityError
+		label = thresholdLabels[severity]
+	}
+
+	minRank := severityRank(severity)
+	counted := 0
+	for _, issue := range issues {
+		if severityRank(issue.Severity) >= minRank {
+			counted++
+		}
+	}
+	if counted > repo.StatusMaxIssues {
+		return git.StatusFailure, fmt.Sprintf("发现 %d 个%s问题（允许 %d 个）", counted, label, repo.StatusMaxIssues)
+	}
+	if len(issues) == 0 {
+		return git.StatusSuccess, "审查通过，未发现问题"
+	}
+	return git.StatusSuccess, fmt.Sprintf("审查通过，共 %d 个问题，其中%s问题 %d 个", len(issues), label, counted)
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/pkg/markdown"
+	"backend/pkg/notifier"
+	"backend/pkg/ratelimit"
+	"backend/utils/logger"
+
+	"gorm.io/gorm"
+
+	// 注册推送渠道
+	_ "backend/pkg/notifier/dingtalk"
+	_ "backend/pkg/notifier/slack"
+	_ "backend/pkg/notifier/webhook"
+	_ "backend/pkg/notifier/wecom"
+)
+
+// ErrTargetPaused 推送目标因连续失败已暂停
+var ErrTargetPaused = errors.New("推送目标连续发送失败已暂停，测试发送成功后自动恢复")
+
+// maxAttemptBodyBytes 发送记录中请求和响应内容的保存上限
+const maxAttemptBodyBytes = 4096
+
+// AttemptRef 发送记录关联的业务对象
+type AttemptRef struct {
+	Kind     string // push, review, digest, message
+	PushID   *uint
+	DigestID *uint
+}
+
+// CircuitBreaker 推送目标连续失败后的熔断配置
+type CircuitBreaker struct {
+	Threshold     int  // 连续失败多少次后暂停推送目标，负数表示关闭
+	AlertTargetID uint // 默认告警目标，0 表示只记日志
+}
+
+// DeliveryService 按推送目标类型选择渠道并发送消息
+type DeliveryService struct {
+	attemptRepo *repository.DeliveryAttemptRepo
+	targetRepo  *repository.TargetRepo
+	limiter     *ratelimit.Limiter
+	rateLimits  map[string]ratelimit.Limit // 按推送类型的默认限流
+	breaker     CircuitBreaker
+}
+
+// NewDeliveryService 创建投递服务，rateLimits 为按推送类型的默认限流
+func NewDeliveryService(db *gorm.DB, rateLimits map[string]ratelimit.Limit, breaker CircuitBreaker) *DeliveryService {
+	if rateLimits == nil {
+		rateLimits = make(map[string]ratelimit.Limit)
+	}
+	return &DeliveryService{
+		attemptRepo: repository.NewDeliveryAttemptRepo(db),
+		targetRepo:  repository.NewTargetRepo(db),
+		limiter:     ratelimit.NewLimiter(),
+		rateLimits:  rateLimits,
+		breaker:     breaker,
+	}
+}
+
+// limitFor 获取推送目标的限流配置，目标自身配置优先于类型默认值
+func (s *DeliveryService) limitFor(target *models.Target) ratelimit.Limit {
+	limit := s.rateLimits[target.Type]
+	if target.RateLimit > 0 {
+		burst := limit.Burst
+		if burst <= 0 || burst > target.RateLimit {
+			burst = (target.RateLimit + 3) / 4
+		}
+		limit = ratelimit.Limit{PerMinute: target.RateLimit, Burst: burst}
+	}
+	return limit
+}
+
+// Send 通过推送目标对应的渠道发送消息
+// content 为模板渲染后的标准 Markdown，发送前转换为渠道支持的方言；
+// 超出渠道长度上限时按章节拆分，依次发送并返回每段的结果。
+// 某段失败后不再发送后续分段，避免接收方看到不完整且乱序的内容。
+// 超出目标限流的消息会排队等待令牌而不是直接失败，等待时长记录在每段结果中。
+// 每次请求的请求与响应保存为发送记录，ref 指定关联的推送记录或摘要。
+// 推送目标连续失败达到阈值后自动暂停，暂停期间直接返回 ErrTargetPaused。
+func (s *DeliveryService) Send(target *models.Target, title, content string, ref AttemptRef) (models.PartResults, error) {
+	return s.Resend(target, title, content, nil, ref)
+}
+
+// Resend 重新发送消息，previous 为上次的分段结果
+// 分段数不变时跳过上次已成功的分段，避免重试时接收方收到重复内容。
+func (s *DeliveryService) Resend(target *models.Target, title, content string, previous models.PartResults, ref AttemptRef) (models.PartResults, error) {
+	if target.Status == models.TargetStatusPaused {
+		return nil, ErrTargetPaused
+	}
+	n, err := notifier.Get(target.Type)
+	if err != nil {
+		return nil, err
+	}
+	if target.Config == nil {
+		return nil, errors.New("config is required for " + target.Type + " target")
+	}
+
+	cfg := notifier.Config(target.Config.ToMap())
+	limit := s.limitFor(target)
+	limiterKey := strconv.FormatUint(uint64(target.ID), 10)
+	parts := markdown.ConvertAndSplit(content, n.Dialect())
+	results := make(models.PartResults, len(parts))
+	if len(previous) != len(parts) {
+		previous = nil
+	}
+
+	var sendErr error
+	for i, part := range parts {
+		if previous != nil && previous[i].Status == models.PartStatusSuccess {
+			results[i] = previous[i]
+			results[i].WaitMs = 0
+			continue
+		}
+		results[i] = models.PartResult{
+			Index: i + 1,
+			Total: len(parts),
+			Bytes: len(part),
+		}
+		if sendErr != nil {
+			results[i].Status = models.PartStatusSkipped
+			continue
+		}
+
+		if wait := s.limiter.Wait(limiterKey, limit); wait > 0 {
+			results[i].WaitMs = wait.Milliseconds()
+			logger.Info("Delivery delayed by rate limit", map[string]interface{}{
+				"target_id": target.ID,
+				"wait_ms":   results[i].WaitMs,
+			})
+		}
+
+		trace, err := n.Send(cfg, notifier.Message{Title: title, Content: part})
+		s.recordAttempt(target, ref, i+1, trace, err)
+		if err != nil {
+			results[i].Status = models.PartStatusFailed
+			results[i].ErrorMsg = err.Error()
+			sendErr = err
+			if len(parts) > 1 {
+				sendErr = fmt.Errorf("第 %d/%d 段发送失败: %w", i+1, len(parts), err)
+			}
+			continue
+		}
+
+		now := time.Now()
+		results[i].Status = models.PartStatusSuccess
+		results[i].SentAt = &now
+	}
+
+	s.recordOutcome(target, sendErr)
+	return results, sendErr
+}
+
+// recordOutcome 更新推送目标的连续失败次数，达到阈值时暂停目标并告警
+func (s *DeliveryService) recordOutcome(target *models.Target, sendErr error) {
+	if sendErr == nil {
+		s.targetRepo.RecordSuccess(target.ID)
+		return
+	}
+
+	failures, err := s.targetRepo.RecordFailure(target.ID, sendErr.Error())
+	if err != nil {
+		return
+	}
+	threshold := s.breaker.Threshold
+	if target.FailureThreshold > 0 {
+		threshold = target.FailureThreshold
+	}
+	if threshold <= 0 || failures < threshold {
+		return
+	}
+
+	paused, err := s.targetRepo.Pause(target.ID)
+	if err != nil || !paused {
+		return
+	}
+	target.Status = models.TargetStatusPaused
+	logger.Warn("Target paused after consecutive failures", map[string]interface{}{
+		"target_id": target.ID,
+		"failures":  failures,
+		"error":     sendErr.Error(),
+	})
+	s.alert(target, failures, sendErr)
+}
+
+// alert 通过告警目标通知管理员推送目标已暂停
+func (s *DeliveryService) alert(target *models.Target, failures int, sendErr error) {
+	alertID := s.breaker.AlertTargetID
+	if target.AlertTargetID != nil {
+		alertID = *target.AlertTargetID
+	}
+	if alertID == 0 || alertID == target.ID {
+		return
+	}
+	alertTarget, err := s.targetRepo.GetByID(alertID)
+	if err != nil {
+		logger.Error("Alert target not found", map[string]interface{}{
+			"alert_target_id": alertID,
+		})
+		return
+	}
+
+	var content strings.Builder
+	content.WriteString("### ⚠️ 推送目标已暂停\n\n")
+	content.WriteString("**推送目标：** " + target.Name + "（" + target.Type + "）\n")
+	content.WriteString(fmt.Sprintf("**连续失败：** %d 次\n", failures))
+	content.WriteString("**最近错误：** " + sendErr.Error() + "\n")
+	content.WriteString("**暂停时间：** " + time.Now().Format("2006-01-02 15:04:05") + "\n\n")
+	content.WriteString("---\n")
+	content.WriteString("暂停期间发往该目标的推送记录为死信（dead）。修复配置后在推送目标页面发送测试消息即可自动恢复，死信可在推送记录中手动重试。\n")
+
+	if _, err := s.Send(alertTarget, "推送目标已暂停", content.String(), AttemptRef{Kind: models.AttemptKindAlert}); err != nil {
+		logger.Error("Failed to send target alert", map[string]interface{}{
+			"target_id":       target.ID,
+			"alert_target_id": alertID,
+			"error":           err.Error(),
+		})
+	}
+}
+
+// recordAttempt 保存一次发送请求的记录，保存失败只记日志不影响发送结果
+func (s *DeliveryService) recordAttempt(target *models.Target, ref AttemptRef, part int, trace *notifier.Trace, sendErr error) {
+	kind := ref.Kind
+	if kind == "" {
+		kind = models.AttemptKindMessage
+	}
+	attempt := &models.DeliveryAttempt{
+		TargetID: target.ID,
+		Kind:     kind,
+		PushID:   ref.PushID,
+		DigestID: ref.DigestID,
+		Part:     part,
+		Success:  sendErr == nil,
+	}
+	if trace != nil {
+		attempt.Method = trace.Method
+		attempt.URL = trace.URL
+		attempt.RequestBody = notifier.Truncate(trace.RequestBody, maxAttemptBodyBytes)
+		attempt.StatusCode = trace.StatusCode
+		attempt.ResponseBody = notifier.Truncate(trace.ResponseBody, maxAttemptBodyBytes)
+		attempt.ErrCode = trace.ErrCode
+		attempt.LatencyMs = trace.Latency.Milliseconds()
+	}
+	if sendErr != nil {
+		attempt.ErrorMsg = sendErr.Error()
+	}
+
+	if err := s.attemptRepo.Create(attempt); err != nil {
+		logger.Error("Failed to save delivery attempt", map[string]interface{}{
+			"target_id": target.ID,
+			"error":     err.Error(),
+		})
+	}
+}
+
+// TotalWait 汇总分段的限流等待时长（毫秒）
+func TotalWait(results models.PartResults) int64 {
+	var total int64
+	for _, r := range results {
+		total += r.WaitMs
+	}
+	return total
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"backend/internal/models"
+	"backend/pkg/git"
+	"backend/utils/logger"
+)
+
+// diffSources 获取提交差异的方式及顺序：仓库设置的方式优先，失败时依次改用克隆仓库和仓库类型对应的平台 API
+func diffSources(repo *models.Repo) []string {
+	first := repo.DiffSource
+	if first == "" {
+		first = models.DiffSourceGit
+	}
+	sources := []string{first}
+	for _, source := range []string{models.DiffSourceGit, repo.Type} {
+		if source == first {
+			continue
+		}
+		if source == models.DiffSourceGit || source == models.DiffSourceGitHub || source == models.DiffSourceGitLab {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// commitDiff 获取到的提交差异
+type commitDiff struct {
+	Files  []git.DiffFile
+	Source string        // 成功使用的差异来源
+	Client git.GitClient // 获取差异的客户端，可继续读取该提交中的文件
+}
+
+// getCommitDiff 按差异来源依次获取提交差异；全部失败时返回各来源的错误
+func (s *WebhookService) getCommitDiff(repo *models.Repo, branch, commitID string) (*commitDiff, error) {
+	var errs []string
+	for _, source := range diffSources(repo) {
+		client, err := s.newDiffClient(repo, source, branch)
+		if err == nil {
+			var files []git.DiffFile
+			if files, err = client.GetSingleCommitDiff(commitID); err == nil {
+				return &commitDiff{Files: files, Source: source, Client: client}, nil
+			}
+		}
+		logger.Warn("Failed to get diff from source", map[string]interface{}{
+			"repo_id":   repo.ID,
+			"commit_id": commitID,
+			"source":    source,
+			"error":     err.Error(),
+		})
+		errs = append(errs, fmt.Sprintf("%s: %s", source, err.Error()))
+	}
+	return nil, errors.New(strings.Join(errs, "; "))
+}
+
+// newDiffClient 创建指定差异来源的客户端
+func (s *WebhookService) newDiffClient(repo *models.Repo, source, branch string) (git.GitClient, error) {
+	if source == models.DiffSourceGit {
+		gitClient := git.NewGoGitClient(repo.URL, repo.AccessToken)
+		gitClient.SetContextLines(s.reviewOpts.ContextLines)
+		if s.reviewOpts.Mirror != nil {
+			gitClient.UseMirror(s.reviewOpts.Mirror, branch)
+		}
+		// SSH 地址的仓库使用部署密钥拉取，并校验平台主机公钥
+		if git.IsSSHURL(repo.URL) {
+			if err := s.useSSHAuth(gitClient, repo); err != nil {
+				return nil, fmt.Errorf("SSH 认证失败: %w", err)
+			}
+		}
+		return gitClient, nil
+	}
+	return newForgeClientFor(repo, source)
+}
+
+// useSSHAuth 为 SSH 仓库设置部署密钥认证
+func (s *WebhookService) useSSHAuth(gitClient *git.GoGitClient, repo *models.Repo) error {
+	if repo.SSHPrivateKey == "" {
+		return errors.New("未配置 SSH 部署密钥")
+	}
+	auth, err := git.SSHAuth(repo.URL, repo.SSHPrivateKey, repo.SSHKnownHosts, s.reviewOpts.KnownHostsFile)
+	if err != nil {
+		return err
+	}
+	gitClient.SetAuth(auth)
+	return nil
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/utils/logger"
+
+	"gorm.io/gorm"
+)
+
+var ErrDigestNotFound = errors.New("摘要不存在")
+
+// defaultDigestInterval 未配置间隔时的默认摘要间隔（分钟）
+const defaultDigestInterval = 60
+
+// digestReviewWait 审查中的推送等待审查结论的最长时间，超时后不再等待，以审查中合并到摘要
+const digestReviewWait = 30 * time.Minute
+
+// DigestService 摘要模式：按推送目标周期性合并推送记录和审查结论后统一发送
+type DigestService struct {
+	digestRepo   *repository.DigestRepo
+	pushRepo     *repository.PushRepo
+	targetRepo   *repository.TargetRepo
+	templateRepo *repository.TemplateRepo
+	deliveryServ *DeliveryService
+	quietServ    *QuietHoursService
+	baseURL      string
+
+	mu     sync.Mutex
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+func NewDigestService(db *gorm.DB, deliveryServ *DeliveryService, quietServ *QuietHoursService, baseURL string) *DigestService {
+	return &DigestService{
+		digestRepo:   repository.NewDigestRepo(db),
+		pushRepo:     repository.NewPushRepo(db),
+		targetRepo:   repository.NewTargetRepo(db),
+		templateRepo: repository.NewTemplateRepo(db),
+		deliveryServ: deliveryServ,
+		quietServ:    quietServ,
+		baseURL:      baseURL,
+	}
+}
+
+// Start 启动定时检查，每个周期检查一次到期的摘要
+func (s *DigestService) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopCh != nil {
+		return
+	}
+	s.stopCh = make(chan struct{})
+	s.doneCh = make(chan struct{})
+
+	go func(stopCh, doneCh chan struct{}) {
+		defer close(doneCh)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case now := <-ticker.C:
+				s.RunDue(now)
+			}
+		}
+	}(s.stopCh, s.doneCh)
+}
+
+// Stop 停止定时检查，等待正在发送的摘要完成
+func (s *DigestService) Stop() {
+	s.mu.Lock()
+	stopCh, doneCh := s.stopCh, s.doneCh
+	s.stopCh, s.doneCh = nil, nil
+	s.mu.Unlock()
+
+	if stopCh == nil {
+		return
+	}
+	close(stopCh)
+	<-doneCh
+}
+
+// RunDue 发送所有到期的摘要
+func (s *DigestService) RunDue(now time.Time) {
+	targets, err := s.targetRepo.GetByDeliveryMode(models.DeliveryModeDigest)
+	if err != nil {
+		logger.Error("Failed to get digest targets", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	for _, target := range targets {
+		t := target
+		// 免打扰期间继续累积，结束后的下一次检查再发送
+		if s.quietServ.IsQuiet(&t, now) {
+			continue
+		}
+		pushes, err := s.pushRepo.GetDigestPending(t.ID, now.Add(-digestReviewWait))
+		if err != nil || len(pushes) == 0 {
+			continue
+		}
+		if !s.isDue(&t, pushes, now) {
+			continue
+		}
+		if _, err := s.send(&t, pushes, now); err != nil {
+			logger.Error("Digest send failed", map[string]interface{}{
+				"target_id": t.ID,
+				"error":     err.Error(),
+			})
+		}
+	}
+}
+
+// isDue 判断推送目标的摘要是否到期
+func (s *DigestService) isDue(target *models.Target, pending []models.Push, now time.Time) bool {
+	var lastAt time.Time
+	if last, err := s.digestRepo.GetLatestByTarget(target.ID); err == nil {
+		lastAt = last.CreatedAt
+	}
+
+	// 每日定时：最近一个已过去的发送时刻之后尚未发送过
+	if target.DigestAt != "" {
+		at, err := time.ParseInLocation("15:04", target.DigestAt, now.Location())
+		if err != nil {
+			return false
+		}
+		scheduled := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+		if now.Before(scheduled) {
+			scheduled = scheduled.AddDate(0, 0, -1)
+		}
+		return lastAt.Before(scheduled)
+	}
+
+	// 固定间隔：距上次摘要（或最早的待发送推送）已满一个间隔
+	interval := target.DigestInterval
+	if interval <= 0 {
+		interval = defaultDigestInterval
+	}
+	since := lastAt
+	if since.IsZero() {
+		since = pending[0].CreatedAt
+	}
+	return !now.Before(since.Add(time.Duration(interval) * time.Minute))
+}
+
+// Flush 立即发送推送目标当前累积的摘要
+func (s *DigestService) Flush(targetID uint) (*models.Digest, error) {
+	target, err := s.targetRepo.GetByID(targetID)
+	if err != nil {
+		return nil, err
+	}
+	pushes, err := s.pushRepo.GetDigestPending(target.ID, time.Now().Add(-digestReviewWait))
+	if err != nil {
+		return nil, err
+	}
+	if len(pushes) == 0 {
+		return nil, errors.New("没有待发送的推送记录（审查中的提交在审查完成后合并）")
+	}
+	return s.send(target, pushes, time.Now())
+}
+
+// send 生成并发送摘要，发送结果同步到每条推送记录
+func (s *DigestService) send(target *models.Target, pushes []models.Push, now time.Time) (*models.Digest, error) {
+	periodStart := pushes[0].CreatedAt
+	if last, err := s.digestRepo.GetLatestByTarget(target.ID); err == nil && last.PeriodEnd.Before(periodStart) {
+		periodStart = last.PeriodEnd
+	}
+
+	digest := &models.Digest{
+		TargetID:    target.ID,
+		Status:      models.PushStatusPending,
+		PushCount:   len(pushes),
+		PeriodStart: periodStart,
+		PeriodEnd:   now,
+	}
+	if err := s.digestRepo.Create(digest); err != nil {
+		return nil, err
+	}
+
+	template, _ := s.templateRepo.GetByTypeAndScene(models.TemplateTypeDingTalk, models.TemplateSceneDigestNotify)
+	digest.Content = s.buildDigestContent(target, pushes, digest, template)
+
+	title := "推送摘要"
+	if template != nil && template.Title != "" {
+		title = template.Title
+	}
+
+	parts, sendErr := s.deliveryServ.Send(target, title, digest.Content, AttemptRef{Kind: models.AttemptKindDigest, DigestID: &digest.ID})
+	digest.Parts = parts
+
+	ids := make([]uint, 0, len(pushes))
+	for _, p := range pushes {
+		ids = append(ids, p.ID)
+	}
+
+	pushStatus := models.PushStatusSuccess
+	errorMsg := ""
+	if sendErr != nil {
+		digest.Status = models.PushStatusFailed
+		digest.ErrorMsg = sendErr.Error()
+		pushStatus = models.PushStatusFailed
+		errorMsg = "摘要发送失败: " + sendErr.Error()
+	} else {
+		sentAt := time.Now()
+		digest.Status = models.PushStatusSuccess
+		digest.SentAt = &sentAt
+	}
+
+	s.digestRepo.Update(digest)
+	s.pushRepo.MarkDigested(ids, digest.ID, pushStatus, errorMsg)
+
+	logger.Info("Digest processed", map[string]interface{}{
+		"digest_id": digest.ID,
+		"target_id": target.ID,
+		"pushes":    len(pushes),
+		"status":    digest.Status,
+	})
+
+	return digest, sendErr
+}
+
+// buildDigestContent 构建摘要消息内容
+func (s *DigestService) buildDigestContent(target *models.Target, pushes []models.Push, digest *models.Digest, template *models.Template) string {
+	// 按仓库分组
+	groups := make(map[string][]models.Push)
+	var repoNames []string
+	for _, p := range pushes {
+		name := p.Repo.Name
+		if _, ok := groups[name]; !ok {
+			repoNames = append(repoNames, name)
+		}
+		groups[name] = append(groups[name], p)
+	}
+	sort.Strings(repoNames)
+
+	var list strings.Builder
+	var stats digestStats
+	for _, name := range repoNames {
+		list.WriteString("#### " + name + "\n")
+		for _, p := range groups[name] {
+			stats.add(&p)
+			shortID := p.CommitID
+			if len(shortID) > 7 {
+				shortID = shortID[:7]
+			}
+			line := fmt.Sprintf("- `%s` %s — %s", shortID, firstLine(p.CommitMsg), codeviewVerdict(&p))
+			if s.baseURL != "" && p.CodeviewStatus == models.CodeviewStatusSuccess {
+				line += fmt.Sprintf(" [详情](%s/web/#/pushes/review?id=%d)", s.baseURL, p.ID)
+			}
+			list.WriteString(line + "\n")
+		}
+		list.WriteString("\n")
+	}
+
+	periodStart := digest.PeriodStart.Format("2006-01-02 15:04")
+	periodEnd := digest.PeriodEnd.Format("2006-01-02 15:04")
+
+	if template == nil || template.Content == "" {
+		var content strings.Builder
+		content.WriteString("### 📬 推送摘要\n\n")
+		content.WriteString("**推送目标：** " + target.Name + "\n")
+		content.WriteString("**时间范围：** " + periodStart + " ~ " + periodEnd + "\n")
+		content.WriteString(fmt.Sprintf("**提交数：** %d（%d 个仓库）\n", len(pushes), len(repoNames)))
+		if stats.reviewed > 0 {
+			content.WriteString(fmt.Sprintf("**审查结论：** 通过 %d，有问题 %d；问题共 %d 个（错误 %d，警告 %d）\n",
+				stats.passed, stats.problems, stats.issues, stats.errors, stats.warnings))
+		}
+		content.WriteString("\n")
+		content.WriteString("---\n")
+		content.WriteString(list.String())
+		return content.String()
+	}
+
+	content := template.Content
+	content = strings.ReplaceAll(content, "{{.TargetName}}", target.Name)
+	content = strings.ReplaceAll(content, "{{.PeriodStart}}", periodStart)
+	content = strings.ReplaceAll(content, "{{.PeriodEnd}}", periodEnd)
+	content = strings.ReplaceAll(content, "{{.PushCount}}", fmt.Sprintf("%d", len(pushes)))
+	content = strings.ReplaceAll(content, "{{.RepoCount}}", fmt.Sprintf("%d", len(repoNames)))
+	content = strings.ReplaceAll(content, "{{.ReviewedCount}}", strconv.Itoa(stats.reviewed))
+	content = strings.ReplaceAll(content, "{{.PassCount}}", strconv.Itoa(stats.passed))
+	content = strings.ReplaceAll(content, "{{.ProblemCount}}", strconv.Itoa(stats.problems))
+	content = strings.ReplaceAll(content, "{{.IssueCount}}", strconv.Itoa(stats.issues))
+	content = strings.ReplaceAll(content, "{{.ErrorCount}}", strconv.Itoa(stats.errors))
+	content = strings.ReplaceAll(content, "{{.WarningCount}}", strconv.Itoa(stats.warnings))
+	content = strings.ReplaceAll(content, "{{.PushList}}", list.String())
+	return content
+}
+
+// digestStats 摘要中已完成审查的推送的结论和问题统计
+type digestStats struct {
+	reviewed int // 审查完成的提交数
+	passed   int // 没有错误的提交数
+	problems int // 有错误的提交数
+	issues   int
+	errors   int
+	warnings int
+}
+
+func (st *digestStats) add(p *models.Push) {
+	if p.CodeviewStatus != models.CodeviewStatusSuccess {
+		return
+	}
+	st.reviewed++
+	if p.ErrorCount > 0 {
+		st.problems++
+	} else {
+		st.passed++
+	}
+	st.issues += p.IssueCount
+	st.errors += p.ErrorCount
+	st.warnings += p.WarningCount
+}
+
+// GetByID 获取摘要详情
+func (s *DigestService) GetByID(id uint) (*models.Digest, error) {
+	digest, err := s.digestRepo.GetByID(id)
+	if err != nil {
+		return nil, ErrDigestNotFound
+	}
+	return digest, nil
+}
+
+// GetList 获取摘要列表
+func (s *DigestService) GetList(page, size int, targetID uint, status string) ([]models.Digest, int64, error) {
+	digests, total := s.digestRepo.GetList(page, size, targetID, status)
+	return digests, total, nil
+}
+
+// codeviewVerdict 推送的审查结论：审查完成时为结论和问题统计，否则为审查状态
+func codeviewVerdict(p *models.Push) string {
+	switch p.CodeviewStatus {
+	case models.CodeviewStatusSuccess:
+		switch {
+		case p.ErrorCount > 0:
+			return fmt.Sprintf("❌ %s（错误 %d，警告 %d，共 %d 个问题）", ReviewResultProblem, p.ErrorCount, p.WarningCount, p.IssueCount)
+		case p.IssueCount > 0:
+			return fmt.Sprintf("⚠️ %s（警告 %d，共 %d 个问题）", ReviewResultSuggest, p.WarningCount, p.IssueCount)
+		default:
+			return "✅ " + ReviewResultPass
+		}
+	case models.CodeviewStatusFailed:
+		return "审查失败"
+	case models.CodeviewStatusSkipped:
+		return "未审查"
+	default:
+		if p.Repo.ID != 0 && p.Repo.ModelID == nil {
+			return "未审查"
+		}
+		return "审查中"
+	}
+}
+
+// firstLine 取提交信息首行
+func firstLine(msg string) string {
+	msg = strings.TrimSpace(msg)
+	if idx := strings.Index(msg, "\n"); idx >= 0 {
+		return strings.TrimSpace(msg[:idx])
+	}
+	return msg
+}
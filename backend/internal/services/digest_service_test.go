@@ -0,0 +1,101 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+)
+
+// 摘要中每个提交附带审查结论和问题统计；审查中的提交等审查完成或超过等待时间后再合并
+func TestDigestIncludesReviewVerdicts(t *testing.T) {
+	db := newTestDB(t)
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	modelID := uint(1)
+	if err := db.Create(&models.AIModel{ID: modelID, Name: "m", Type: "gpt-4o", APIURL: srv.URL, APIKey: "k"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	reviewed := &models.Repo{Name: "reviewed", URL: "https://github.com/acme/a", Type: "github", WebhookID: "w1", WebhookURL: "/webhook/w1", ModelID: &modelID}
+	plain := &models.Repo{Name: "plain", URL: "https://github.com/acme/b", Type: "github", WebhookID: "w2", WebhookURL: "/webhook/w2"}
+	target := &models.Target{Name: "digest", Type: models.TargetTypeWebhook, Config: &models.Config{WebhookURL: srv.URL}, DeliveryMode: models.DeliveryModeDigest}
+	for _, v := range []interface{}{reviewed, plain, target} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	pushes := []*models.Push{
+		{RepoID: reviewed.ID, CommitID: "aaaaaaa1", CommitMsg: "fix bug", CodeviewStatus: models.CodeviewStatusSuccess, IssueCount: 3, ErrorCount: 1, WarningCount: 2},
+		{RepoID: reviewed.ID, CommitID: "bbbbbbb2", CommitMsg: "clean up", CodeviewStatus: models.CodeviewStatusSuccess},
+		{RepoID: reviewed.ID, CommitID: "ccccccc3", CommitMsg: "in review", CodeviewStatus: models.CodeviewStatusPending},
+		{RepoID: reviewed.ID, CommitID: "ddddddd4", CommitMsg: "stuck review", CodeviewStatus: models.CodeviewStatusPending, CreatedAt: now.Add(-2 * digestReviewWait)},
+		{RepoID: plain.ID, CommitID: "eeeeeee5", CommitMsg: "no model", CodeviewStatus: models.CodeviewStatusPending},
+	}
+	for _, p := range pushes {
+		p.TargetID, p.Status, p.Content = target.ID, models.PushStatusDigest, "c"
+		if err := db.Create(p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewDigestService(db, NewDeliveryService(db, nil, CircuitBreaker{}), nil, "")
+	digest, err := s.Flush(target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest.PushCount != 4 {
+		t.Errorf("push count = %d, want 4 (review in progress held back)", digest.PushCount)
+	}
+	for _, want := range []string{
+		"`aaaaaaa` fix bug — ❌ 有问题（错误 1，警告 2，共 3 个问题）",
+		"`bbbbbbb` clean up — ✅ 通过",
+		"`ddddddd` stuck review — 审查中",
+		"`eeeeeee` no model — 未审查",
+		"通过 1，有问题 1；问题共 3 个（错误 1，警告 2）",
+	} {
+		if !strings.Contains(digest.Content, want) {
+			t.Errorf("digest content missing %q:\n%s", want, digest.Content)
+		}
+	}
+	if strings.Contains(digest.Content, "ccccccc") {
+		t.Errorf("digest should not include push still in review:\n%s", digest.Content)
+	}
+	if body == "" {
+		t.Error("digest was not sent")
+	}
+
+	// 审查完成后在下一次摘要中合并
+	db.Model(&models.Push{}).Where("id = ?", pushes[2].ID).Updates(map[string]interface{}{"codeview_status": models.CodeviewStatusSuccess})
+	digest, err = s.Flush(target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest.PushCount != 1 || !strings.Contains(digest.Content, "`ccccccc` in review — ✅ 通过") {
+		t.Errorf("second digest = %d pushes:\n%s", digest.PushCount, digest.Content)
+	}
+}
+
+func TestDigestTemplateReviewVariables(t *testing.T) {
+	s := &DigestService{}
+	pushes := []models.Push{
+		{CommitID: "a1", CodeviewStatus: models.CodeviewStatusSuccess, IssueCount: 4, ErrorCount: 2, WarningCount: 1},
+		{CommitID: "b2", CodeviewStatus: models.CodeviewStatusSuccess, IssueCount: 1, WarningCount: 1},
+		{CommitID: "c3", CodeviewStatus: models.CodeviewStatusFailed},
+	}
+	tpl := &models.Template{Content: "{{.ReviewedCount}}/{{.PassCount}}/{{.ProblemCount}}/{{.IssueCount}}/{{.ErrorCount}}/{{.WarningCount}}"}
+	got := s.buildDigestContent(&models.Target{}, pushes, &models.Digest{}, tpl)
+	if got != "2/1/1/5/2/2" {
+		t.Errorf("content = %q, want 2/1/1/5/2/2", got)
+	}
+}
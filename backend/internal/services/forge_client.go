@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"backend/internal/models"
+	"backend/pkg/git"
+)
+
+// ErrForgeUnsupported 仓库类型不支持通过 API 访问
+var ErrForgeUnsupported = errors.New("仓库类型不支持该操作")
+
+// forgeProject 从仓库地址解析平台地址和项目路径，支持 HTTP(S) 和 SSH（git@host:path、ssh://）地址
+// SSH 地址的平台地址按 HTTPS 处理。
+func forgeProject(repoURL string) (baseURL, projectPath string, err error) {
+	raw := strings.TrimSpace(repoURL)
+	if strings.HasPrefix(raw, "git@") {
+		hostPath := strings.TrimPrefix(raw, "git@")
+		host, path, ok := strings.Cut(hostPath, ":")
+		if !ok {
+			return "", "", ErrInvalidRepoURL
+		}
+		return "https://" + host, strings.TrimSuffix(strings.Trim(path, "/"), ".git"), nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", "", ErrInvalidRepoURL
+	}
+	scheme := u.Scheme
+	if scheme != "http" {
+		scheme = "https"
+	}
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if path == "" {
+		return "", "", ErrInvalidRepoURL
+	}
+	return scheme + "://" + u.Hostname() + portSuffix(u), path, nil
+}
+
+// portSuffix SSH 地址的端口不是平台 API 的端口，只保留 HTTP(S) 地址中的端口
+func portSuffix(u *url.URL) string {
+	if u.Port() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return ":" + u.Port()
+}
+
+// githubAPIBase github.com 使用 api.github.com，GitHub Enterprise 使用 /api/v3
+func githubAPIBase(baseURL string) string {
+	if baseURL == "https://github.com" || baseURL == "https://www.github.com" {
+		return "https://api.github.com"
+	}
+	return baseURL + "/api/v3"
+}
+
+// forgeClient 平台 API 客户端，用于获取差异、发布审查评论和提交状态
+type forgeClient interface {
+	git.GitClient
+	git.CommentClient
+	git.StatusClient
+}
+
+// newForgeClient 按仓库类型创建平台 API 客户端，使用仓库的访问令牌
+func newForgeClient(repo *models.Repo) (forgeClient, error) {
+	return newForgeClientFor(repo, repo.Type)
+}
+
+// newForgeClientFor 按平台类型创建 API 客户端，API 地址优先使用仓库配置的地址
+// 平台类型可以与仓库类型不同，如通过 GitHub API 获取差异的镜像仓库。
+func newForgeClientFor(repo *models.Repo, forgeType string) (forgeClient, error) {
+	baseURL, path, err := forgeProject(repo.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch forgeType {
+	case models.RepoTypeGitHub:
+		owner, name, ok := strings.Cut(path, "/")
+		if !ok {
+			return nil, ErrInvalidRepoURL
+		}
+		apiURL := repo.APIURL
+		if apiURL == "" {
+			apiURL = githubAPIBase(baseURL)
+		}
+		return git.NewClient(apiURL, repo.AccessToken, owner, name), nil
+	case models.RepoTypeGitLab:
+		apiURL := repo.APIURL
+		if apiURL == "" {
+			apiURL = extractGitLabBaseURL(repo.URL) + "/api/v4"
+		}
+		return git.NewGitLabClient(apiURL, repo.AccessToken, url.PathEscape(path)), nil
+	}
+	return nil, ErrForgeUnsupported
+}
@@ -124,6 +124,23 @@ func (s *GenerateTemplateService) buildPrompt(input GenerateTemplateInput) strin
 		sb.WriteString("- {{.CommitID}}: 提交ID\n")
 		sb.WriteString("- {{.CommitMsg}}: 提交信息\n")
 		sb.WriteString("- {{.Issues}}: 审查问题列表\n")
+		sb.WriteString("- {{.IssueCount}}: 问题总数\n")
+		sb.WriteString("- {{.ErrorCount}}: 错误数\n")
+		sb.WriteString("- {{.WarningCount}}: 警告数\n")
+		sb.WriteString("- {{.InfoCount}}: 提示数\n")
+	} else if input.Scene == models.TemplateSceneDigestNotify {
+		sb.WriteString("- {{.TargetName}}: 推送目标名称\n")
+		sb.WriteString("- {{.PeriodStart}}: 摘要开始时间\n")
+		sb.WriteString("- {{.PeriodEnd}}: 摘要结束时间\n")
+		sb.WriteString("- {{.PushCount}}: 提交数\n")
+		sb.WriteString("- {{.RepoCount}}: 仓库数\n")
+		sb.WriteString("- {{.ReviewedCount}}: 审查完成的提交数\n")
+		sb.WriteString("- {{.PassCount}}: 审查通过（无错误）的提交数\n")
+		sb.WriteString("- {{.ProblemCount}}: 审查有错误的提交数\n")
+		sb.WriteString("- {{.IssueCount}}: 问题总数\n")
+		sb.WriteString("- {{.ErrorCount}}: 错误数\n")
+		sb.WriteString("- {{.WarningCount}}: 警告数\n")
+		sb.WriteString("- {{.PushList}}: 按仓库分组的提交列表（含审查结论）\n")
 	}
 
 	sb.WriteString("\n要求：\n")
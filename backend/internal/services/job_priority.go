@@ -0,0 +1,43 @@
+package services
+
+import (
+	"path"
+	"strings"
+
+	"backend/internal/models"
+)
+
+// jobPriority 按仓库的优先级规则计算推送相关任务的优先级
+// 整个仓库为高优先级，或推送的分支/标签匹配规则时进入高优先级通道。
+func jobPriority(repo *models.Repo, payload *UnifiedPushPayload) int {
+	if repo.HighPriority {
+		return models.JobPriorityHigh
+	}
+	if tag, ok := strings.CutPrefix(payload.Ref, "refs/tags/"); ok {
+		if matchPatterns(repo.PriorityTags, tag) {
+			return models.JobPriorityHigh
+		}
+		return models.JobPriorityNormal
+	}
+	if matchPatterns(repo.PriorityBranches, payload.Branch) {
+		return models.JobPriorityHigh
+	}
+	return models.JobPriorityNormal
+}
+
+// matchPatterns 判断名称是否匹配逗号分隔的通配符规则之一，如 main,release/*
+func matchPatterns(patterns, name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, p := range strings.Split(patterns, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,578 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/pkg/retry"
+	"backend/utils/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultJobLease        = 2 * time.Minute
+	defaultJobPollInterval = time.Second
+	defaultJobMaxAttempts  = 3
+	defaultJobRetention    = 7 * 24 * time.Hour
+	jobPurgeInterval       = time.Hour
+)
+
+// JobHandler 任务处理函数，返回错误时按重试策略重新排队
+type JobHandler func(job *models.Job) error
+
+// maxJobWorkers 单个队列的执行者数量上限
+const maxJobWorkers = 64
+
+var (
+	ErrJobNotFound       = errors.New("任务不存在")
+	ErrJobNotActive      = errors.New("任务已结束")
+	ErrJobNotWaiting     = errors.New("只有等待中的任务可以调整优先级")
+	ErrInvalidJobWorker  = fmt.Errorf("执行者数量应在 1 到 %d 之间", maxJobWorkers)
+	ErrInvalidHighWorker = fmt.Errorf("高优先级执行者数量应在 0 到 %d 之间", maxJobWorkers)
+)
+
+// JobQueueStats 队列运行状态
+type JobQueueStats struct {
+	Name         string     `json:"name"`
+	Workers      int        `json:"workers"`
+	HighWorkers  int        `json:"high_workers"` // 高优先级通道的专用执行者
+	Paused       bool       `json:"paused"`
+	Queued       int64      `json:"queued"`       // 等待执行
+	HighWaiting  int64      `json:"high_waiting"` // 高优先级通道中等待执行（含等待重试）
+	Retrying     int64      `json:"retrying"`     // 失败后等待重试
+	Running      int64      `json:"running"`      // 执行中（含其他实例）
+	Succeeded    int64      `json:"succeeded"`    // 已成功
+	Dead         int64      `json:"dead"`         // 重试次数用尽
+	Canceled     int64      `json:"canceled"`     // 已取消
+	Deduplicated int64      `json:"deduplicated"` // 本次启动以来因去重合并的入队次数
+	OldestAt     *time.Time `json:"oldest_at"`    // 等待最久的任务的入队时间
+}
+
+// QueueOptions 任务队列的配置，执行者数量按队列名配置，未配置的使用各队列的默认值
+type QueueOptions struct {
+	Workers     map[string]int // 普通执行者数量
+	HighWorkers map[string]int // 高优先级通道的专用执行者数量
+	// Retention 已结束任务的保留时长，不大于 0 时不清理
+	Retention time.Duration
+}
+
+// JobQueue 基于数据库的持久化任务队列
+// 入队即落库，执行者领取任务时加租约并定期心跳续约；进程崩溃或重启后，
+// 租约过期的任务会被重新排队，因此不会丢失任务。
+// 普通执行者按优先级从高到低领取所有任务；另有专用执行者只领取高优先级任务，
+// 保证大量普通任务积压时高优先级任务仍能及时执行。
+type JobQueue struct {
+	name         string
+	repo         *repository.JobRepo
+	handler      JobHandler
+	workers      int
+	highWorkers  int
+	owner        string
+	lease        time.Duration
+	pollInterval time.Duration
+	maxAttempts  int
+	retention    time.Duration // 已结束任务的保留时长，0 表示不清理
+	onCancel     func(job *models.Job)
+
+	mu           sync.Mutex
+	started      bool
+	paused       bool
+	workerStops  []chan struct{}
+	highStops    []chan struct{}
+	deduplicated int64
+
+	wakeCh chan struct{}
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+func NewJobQueue(db *gorm.DB, name string, workers int, handler JobHandler) *JobQueue {
+	if workers <= 0 {
+		workers = 1
+	}
+	hostname, _ := os.Hostname()
+	return &JobQueue{
+		name:         name,
+		repo:         repository.NewJobRepo(db),
+		handler:      handler,
+		workers:      workers,
+		highWorkers:  1,
+		owner:        fmt.Sprintf("%s:%d:%d", hostname, os.Getpid(), rand.Int63()),
+		lease:        defaultJobLease,
+		pollInterval: defaultJobPollInterval,
+		maxAttempts:  defaultJobMaxAttempts,
+		retention:    defaultJobRetention,
+		wakeCh:       make(chan struct{}, 1),
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Enqueue 任务入队，dedupKey 非空时同一队列中未结束的同键任务只保留一个
+// 返回 false 表示因去重未入队；返回错误表示落库失败，调用方需要感知。
+func (q *JobQueue) Enqueue(payload interface{}, dedupKey string) (bool, error) {
+	return q.enqueue(payload, dedupKey, time.Now(), models.JobPriorityNormal)
+}
+
+// EnqueuePriority 按指定优先级入队，已有同键任务时提升其优先级
+func (q *JobQueue) EnqueuePriority(payload interface{}, dedupKey string, priority int) (bool, error) {
+	return q.enqueue(payload, dedupKey, time.Now(), priority)
+}
+
+// EnqueueAt 任务入队，runAt 之前不会被执行
+func (q *JobQueue) EnqueueAt(payload interface{}, dedupKey string, runAt time.Time) (bool, error) {
+	return q.enqueue(payload, dedupKey, runAt, models.JobPriorityNormal)
+}
+
+func (q *JobQueue) enqueue(payload interface{}, dedupKey string, runAt time.Time, priority int) (bool, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+
+	job := &models.Job{
+		Queue:       q.name,
+		Status:      models.JobStatusQueued,
+		Payload:     string(data),
+		DedupKey:    dedupKey,
+		Priority:    priority,
+		MaxAttempts: q.maxAttempts,
+		RunAt:       runAt,
+	}
+	created, err := q.repo.CreateUnique(job)
+	if err != nil {
+		return false, err
+	}
+	if !created && dedupKey != "" {
+		atomic.AddInt64(&q.deduplicated, 1)
+		// 已有同键任务时按更早的时间、更高的优先级执行
+		if err := q.repo.Promote(q.name, dedupKey, runAt, priority); err != nil {
+			return false, err
+		}
+	}
+	q.wake()
+	return created, nil
+}
+
+// Start 回收上次运行遗留的任务并启动执行者，执行者数量和回调应在此之前设置好
+func (q *JobQueue) Start() {
+	if n, err := q.repo.RecoverExpired(q.name, time.Now()); err != nil {
+		logger.Error("Failed to recover jobs", map[string]interface{}{
+			"queue": q.name,
+			"error": err.Error(),
+		})
+	} else if n > 0 {
+		logger.Info("Recovered jobs", map[string]interface{}{
+			"queue": q.name,
+			"count": n,
+		})
+	}
+
+	q.mu.Lock()
+	q.started = true
+	for i := 0; i < q.workers; i++ {
+		q.addWorker(&q.workerStops, models.JobPriorityNormal)
+	}
+	for i := 0; i < q.highWorkers; i++ {
+		q.addWorker(&q.highStops, models.JobPriorityHigh)
+	}
+	q.mu.Unlock()
+
+	q.wg.Add(1)
+	go q.reap()
+}
+
+// Name 队列名称
+func (q *JobQueue) Name() string {
+	return q.name
+}
+
+// OnCancel 设置任务被取消时的回调，用于同步业务记录的状态，需在 Start 之前调用
+func (q *JobQueue) OnCancel(fn func(job *models.Job)) {
+	q.onCancel = fn
+}
+
+// Configure 按配置设置执行者数量和已结束任务的保留时长，需在 Start 之前调用
+// 配置的执行者数量无效时保留默认值。
+func (q *JobQueue) Configure(opts QueueOptions) {
+	if n, ok := opts.Workers[q.name]; ok {
+		if err := q.SetWorkers(n); err != nil {
+			logger.Warn("Invalid queue workers config", map[string]interface{}{
+				"queue":   q.name,
+				"workers": n,
+				"error":   err.Error(),
+			})
+		}
+	}
+	if n, ok := opts.HighWorkers[q.name]; ok {
+		if err := q.SetHighWorkers(n); err != nil {
+			logger.Warn("Invalid queue high_workers config", map[string]interface{}{
+				"queue":   q.name,
+				"workers": n,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	q.mu.Lock()
+	q.retention = opts.Retention
+	if q.retention < 0 {
+		q.retention = 0
+	}
+	q.mu.Unlock()
+}
+
+// Pause 暂停领取新任务，执行中的任务继续完成；只影响当前实例
+func (q *JobQueue) Pause() {
+	q.mu.Lock()
+	q.paused = true
+	q.mu.Unlock()
+	logger.Info("Job queue paused", map[string]interface{}{
+		"queue": q.name,
+	})
+}
+
+// Resume 恢复领取任务
+func (q *JobQueue) Resume() {
+	q.mu.Lock()
+	q.paused = false
+	q.mu.Unlock()
+	q.wake()
+	logger.Info("Job queue resumed", map[string]interface{}{
+		"queue": q.name,
+	})
+}
+
+// SetWorkers 调整普通执行者数量，减少时多出的执行者在当前任务完成后退出
+func (q *JobQueue) SetWorkers(n int) error {
+	if n <= 0 || n > maxJobWorkers {
+		return ErrInvalidJobWorker
+	}
+	q.resize(&q.workers, &q.workerStops, n, models.JobPriorityNormal)
+	return nil
+}
+
+// SetHighWorkers 调整高优先级通道的专用执行者数量，0 表示不保留专用执行者
+func (q *JobQueue) SetHighWorkers(n int) error {
+	if n < 0 || n > maxJobWorkers {
+		return ErrInvalidHighWorker
+	}
+	q.resize(&q.highWorkers, &q.highStops, n, models.JobPriorityHigh)
+	return nil
+}
+
+// resize 调整一组执行者的数量；队列尚未启动时只记录数量
+func (q *JobQueue) resize(count *int, stops *[]chan struct{}, n, minPriority int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case <-q.stopCh:
+		return
+	default:
+	}
+
+	old := *count
+	*count = n
+	if !q.started {
+		return
+	}
+	for len(*stops) < n {
+		q.addWorker(stops, minPriority)
+	}
+	for len(*stops) > n {
+		last := len(*stops) - 1
+		close((*stops)[last])
+		*stops = (*stops)[:last]
+	}
+
+	if old != n {
+		logger.Info("Job queue workers changed", map[string]interface{}{
+			"queue":        q.name,
+			"min_priority": minPriority,
+			"from":         old,
+			"to":           n,
+		})
+	}
+}
+
+// addWorker 启动一个只领取优先级不低于 minPriority 的任务的执行者，调用方需持有 q.mu
+func (q *JobQueue) addWorker(stops *[]chan struct{}, minPriority int) {
+	quit := make(chan struct{})
+	*stops = append(*stops, quit)
+	q.wg.Add(1)
+	go q.work(quit, minPriority)
+}
+
+func (q *JobQueue) isPaused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.paused
+}
+
+// Stats 获取队列运行状态
+func (q *JobQueue) Stats() (*JobQueueStats, error) {
+	counts, err := q.repo.CountByStatus(q.name)
+	if err != nil {
+		return nil, err
+	}
+	oldest, err := q.repo.OldestWaiting(q.name)
+	if err != nil {
+		return nil, err
+	}
+	highWaiting, err := q.repo.CountWaitingHigh(q.name)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	workers, highWorkers, paused := q.workers, q.highWorkers, q.paused
+	q.mu.Unlock()
+
+	return &JobQueueStats{
+		Name:         q.name,
+		Workers:      workers,
+		HighWorkers:  highWorkers,
+		Paused:       paused,
+		Queued:       counts[models.JobStatusQueued],
+		HighWaiting:  highWaiting,
+		Retrying:     counts[models.JobStatusFailed],
+		Running:      counts[models.JobStatusRunning],
+		Succeeded:    counts[models.JobStatusSucceeded],
+		Dead:         counts[models.JobStatusDead],
+		Canceled:     counts[models.JobStatusCanceled],
+		Deduplicated: atomic.LoadInt64(&q.deduplicated),
+		OldestAt:     oldest,
+	}, nil
+}
+
+// Cancel 取消任务，执行中的任务取消后执行结果不再记录，已产生的副作用不会撤回
+func (q *JobQueue) Cancel(job *models.Job) error {
+	canceled, err := q.repo.Cancel(job.ID)
+	if err != nil {
+		return err
+	}
+	if !canceled {
+		return ErrJobNotActive
+	}
+	logger.Info("Job canceled", map[string]interface{}{
+		"queue":  q.name,
+		"job_id": job.ID,
+		"status": job.Status,
+	})
+	if q.onCancel != nil {
+		q.onCancel(job)
+	}
+	return nil
+}
+
+// SetPriority 调整等待中任务的优先级
+func (q *JobQueue) SetPriority(job *models.Job, priority int) error {
+	ok, err := q.repo.SetPriority(job.ID, priority)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrJobNotWaiting
+	}
+	q.wake()
+	return nil
+}
+
+// Stop 停止领取新任务并等待执行中的任务完成
+func (q *JobQueue) Stop() {
+	q.Shutdown(context.Background())
+}
+
+// Shutdown 停止领取新任务，在 ctx 结束前等待执行中的任务完成
+// 超时仍未完成的任务重新排队（不计入执行次数），下次启动后由任意执行者继续处理。
+func (q *JobQueue) Shutdown(ctx context.Context) error {
+	q.once.Do(func() {
+		close(q.stopCh)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	n, err := q.repo.ReleaseOwner(q.name, q.owner)
+	if err != nil {
+		logger.Error("Failed to requeue unfinished jobs", map[string]interface{}{
+			"queue": q.name,
+			"error": err.Error(),
+		})
+		return err
+	}
+	logger.Warn("Requeued unfinished jobs on shutdown", map[string]interface{}{
+		"queue": q.name,
+		"count": n,
+	})
+	return ctx.Err()
+}
+
+func (q *JobQueue) wake() {
+	select {
+	case q.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// work 执行者循环：有任务时连续处理，无任务或暂停时等待唤醒或轮询
+func (q *JobQueue) work(quit chan struct{}, minPriority int) {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stopCh:
+			return
+		case <-quit:
+			return
+		default:
+		}
+
+		if !q.isPaused() {
+			job, err := q.repo.Claim(q.name, q.owner, q.lease, minPriority)
+			if err != nil {
+				logger.Error("Failed to claim job", map[string]interface{}{
+					"queue": q.name,
+					"error": err.Error(),
+				})
+			}
+			if job != nil {
+				q.run(job)
+				continue
+			}
+		}
+
+		select {
+		case <-q.stopCh:
+			return
+		case <-quit:
+			return
+		case <-q.wakeCh:
+		case <-time.After(q.pollInterval):
+		}
+	}
+}
+
+// reap 定期回收租约过期的任务，并清理超过保留时长的已结束任务
+func (q *JobQueue) reap() {
+	defer q.wg.Done()
+	ticker := time.NewTicker(q.lease / 2)
+	defer ticker.Stop()
+	q.purge(time.Now())
+	purgedAt := time.Now()
+	for {
+		select {
+		case <-q.stopCh:
+			return
+		case now := <-ticker.C:
+			if n, err := q.repo.RecoverExpired(q.name, now); err == nil && n > 0 {
+				logger.Warn("Recovered expired jobs", map[string]interface{}{
+					"queue": q.name,
+					"count": n,
+				})
+				q.wake()
+			}
+			if now.Sub(purgedAt) >= jobPurgeInterval {
+				q.purge(now)
+				purgedAt = now
+			}
+		}
+	}
+}
+
+// purge 删除结束时间早于保留时长的任务，保留时长为 0 时不清理
+func (q *JobQueue) purge(now time.Time) {
+	q.mu.Lock()
+	retention := q.retention
+	q.mu.Unlock()
+	if retention <= 0 {
+		return
+	}
+	n, err := q.repo.PurgeFinished(q.name, now.Add(-retention))
+	if err != nil {
+		logger.Error("Failed to purge finished jobs", map[string]interface{}{
+			"queue": q.name,
+			"error": err.Error(),
+		})
+		return
+	}
+	if n > 0 {
+		logger.Info("Purged finished jobs", map[string]interface{}{
+			"queue": q.name,
+			"count": n,
+		})
+	}
+}
+
+// run 执行任务，执行期间定期心跳续约
+func (q *JobQueue) run(job *models.Job) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(q.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if ok, err := q.repo.Heartbeat(job.ID, q.owner, q.lease); err != nil || !ok {
+					logger.Warn("Job heartbeat failed", map[string]interface{}{
+						"queue":  q.name,
+						"job_id": job.ID,
+					})
+				}
+			}
+		}
+	}()
+
+	err := q.safeHandle(job)
+	close(done)
+
+	if err == nil {
+		q.repo.Complete(job.ID, q.owner)
+		return
+	}
+
+	var retryAt *time.Time
+	if job.Attempts < job.MaxAttempts {
+		t := time.Now().Add(retry.DefaultPolicy.Delay(job.Attempts))
+		retryAt = &t
+	}
+	q.repo.Fail(job.ID, q.owner, err.Error(), retryAt)
+
+	logger.Error("Job failed", map[string]interface{}{
+		"queue":    q.name,
+		"job_id":   job.ID,
+		"attempts": job.Attempts,
+		"dead":     retryAt == nil,
+		"error":    err.Error(),
+	})
+}
+
+// safeHandle 执行处理函数，panic 视为失败
+func (q *JobQueue) safeHandle(job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return q.handler(job)
+}
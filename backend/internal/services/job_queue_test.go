@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+)
+
+func TestJobQueueConfigure(t *testing.T) {
+	q := NewJobQueue(newTestDB(t), models.JobQueueCodeReview, 2, func(job *models.Job) error { return nil })
+	q.Configure(QueueOptions{
+		Workers:     map[string]int{models.JobQueueCodeReview: 4, models.JobQueuePushNotify: 9},
+		HighWorkers: map[string]int{models.JobQueueCodeReview: maxJobWorkers + 1},
+		Retention:   time.Hour,
+	})
+
+	stats, err := q.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Workers != 4 {
+		t.Errorf("workers = %d, want 4", stats.Workers)
+	}
+	// 无效配置保留默认值
+	if stats.HighWorkers != 1 {
+		t.Errorf("high workers = %d, want 1", stats.HighWorkers)
+	}
+	if q.retention != time.Hour {
+		t.Errorf("retention = %v, want 1h", q.retention)
+	}
+}
+
+// 创建队列不启动执行者，入队的任务在 Start 之后才执行
+func TestJobQueueRunsAfterStart(t *testing.T) {
+	done := make(chan uint, 1)
+	q := NewJobQueue(newTestDB(t), models.JobQueueCodeReview, 1, func(job *models.Job) error {
+		done <- job.ID
+		return nil
+	})
+	q.pollInterval = 10 * time.Millisecond
+	defer q.Shutdown(context.Background())
+
+	if _, err := q.Enqueue(map[string]int{"n": 1}, ""); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+		t.Fatal("job ran before Start")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.Start()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not run after Start")
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"backend/pkg/git"
+	"backend/utils/logger"
+)
+
+// MirrorService 定期维护代码审查使用的仓库镜像缓存
+type MirrorService struct {
+	cache *git.MirrorCache
+
+	mu     sync.Mutex
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+func NewMirrorService(cache *git.MirrorCache) *MirrorService {
+	return &MirrorService{cache: cache}
+}
+
+// Start 按间隔维护镜像，重复调用无效
+func (s *MirrorService) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopCh != nil {
+		return
+	}
+	s.stopCh = make(chan struct{})
+	s.doneCh = make(chan struct{})
+
+	go func(stopCh, doneCh chan struct{}) {
+		defer close(doneCh)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				s.Maintain()
+			}
+		}
+	}(s.stopCh, s.doneCh)
+}
+
+// Stop 停止定时维护，等待正在进行的维护完成
+func (s *MirrorService) Stop() {
+	s.mu.Lock()
+	stopCh, doneCh := s.stopCh, s.doneCh
+	s.stopCh, s.doneCh = nil, nil
+	s.mu.Unlock()
+
+	if stopCh == nil {
+		return
+	}
+	close(stopCh)
+	<-doneCh
+}
+
+// Maintain 维护所有镜像并记录结果
+func (s *MirrorService) Maintain() {
+	stats := s.cache.Maintain()
+	for _, err := range stats.Errors {
+		logger.Warn("Failed to maintain git mirror", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	logger.Info("Git mirrors maintained", map[string]interface{}{
+		"mirrors":  stats.Mirrors,
+		"repacked": stats.Repacked,
+		"pruned":   stats.Pruned,
+		"evicted":  stats.Evicted,
+		"size_mb":  stats.Size >> 20,
+	})
+}
@@ -40,6 +40,9 @@ func (s *AIModelService) Create(data map[string]interface{}) (*models.AIModel, e
 		Status:    models.StatusActive,
 		CallCount: 0,
 	}
+	if contextSize, ok := data["context_size"].(float64); ok && contextSize > 0 {
+		model.ContextSize = int(contextSize)
+	}
 
 	if params, ok := data["params"].(map[string]interface{}); ok {
 		if paramsStr, err := json.Marshal(params); err == nil {
@@ -89,6 +92,10 @@ func (s *AIModelService) Update(id uint, data map[string]interface{}) error {
 	if status, ok := data["status"].(string); ok && status != "" {
 		model.Status = status
 	}
+	// 0 表示恢复按模型名称推断
+	if contextSize, ok := data["context_size"].(float64); ok && contextSize >= 0 {
+		model.ContextSize = int(contextSize)
+	}
 	if params, ok := data["params"].(map[string]interface{}); ok {
 		if paramsStr, err := json.Marshal(params); err == nil {
 			model.Params = string(paramsStr)
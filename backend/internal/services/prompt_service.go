@@ -49,8 +49,11 @@ func (s *PromptService) Create(data map[string]interface{}) (*models.Prompt, err
 		Version:  1,
 	}
 
-	if modelID, ok := data["model_id"].(uint); ok && modelID > 0 {
-		prompt.ModelID = &modelID
+	if modelID, ok := getModelID(data); ok && modelID != nil {
+		if _, err := s.modelRepo.GetByID(*modelID); err != nil {
+			return nil, ErrModelNotFound
+		}
+		prompt.ModelID = modelID
 	}
 
 	if err := s.promptRepo.Create(prompt); err != nil {
@@ -109,8 +112,15 @@ func (s *PromptService) Update(id uint, data map[string]interface{}) error {
 		if content, ok := data["content"].(string); ok && content != "" {
 			prompt.Content = content
 		}
-		if modelID, ok := data["model_id"].(uint); ok {
-			prompt.ModelID = &modelID
+		if modelID, ok := getModelID(data); ok {
+			if modelID != nil {
+				if _, err := s.modelRepo.GetByID(*modelID); err != nil {
+					return ErrModelNotFound
+				}
+			}
+			// 清除预加载的模型，避免保存时按关联覆盖 model_id
+			prompt.ModelID = modelID
+			prompt.Model = nil
 		}
 
 		prompt.Version++
@@ -118,6 +128,19 @@ func (s *PromptService) Update(id uint, data map[string]interface{}) error {
 	})
 }
 
+// getModelID 解析请求中的 model_id，JSON 数字解码为 float64，null 或 0 表示不指定模型（使用仓库的模型）
+func getModelID(data map[string]interface{}) (*uint, bool) {
+	val, ok := data["model_id"]
+	if !ok {
+		return nil, false
+	}
+	if id, ok := val.(float64); ok && id > 0 {
+		modelID := uint(id)
+		return &modelID, true
+	}
+	return nil, true
+}
+
 // Rollback 回滚版本
 func (s *PromptService) Rollback(id uint, version int) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
@@ -1,51 +1,51 @@
 package services
 
 import (
+	"encoding/json"
+
 	"backend/internal/models"
+
+	"gorm.io/gorm"
 )
 
+// PushNotifyJob 推送通知任务，只保存ID，执行时重新加载仓库、推送目标和模板
 type PushNotifyJob struct {
-	Repo     *models.Repo
-	Target   *models.Target
-	Payload  *UnifiedPushPayload
-	Template *models.Template
-	Provider WebhookProvider
+	RepoID     uint                `json:"repo_id"`
+	TargetID   uint                `json:"target_id"`
+	TemplateID *uint               `json:"template_id,omitempty"`
+	Provider   string              `json:"provider"`
+	Payload    *UnifiedPushPayload `json:"payload"`
+	DedupKey   string              `json:"dedup_key,omitempty"`
+	Priority   int                 `json:"priority,omitempty"`
+	JobID      uint                `json:"-"` // 执行时由队列填充为任务ID，任务重试时不变
 }
 
+// PushNotifyQueue 推送通知任务队列
+// 默认 5 个执行者，创建后由所属服务调用 Start。
 type PushNotifyQueue struct {
-	jobs    chan PushNotifyJob
-	handler func(PushNotifyJob)
+	*JobQueue
 }
 
-func NewPushNotifyQueue(bufferSize int, workerCount int, handler func(PushNotifyJob)) *PushNotifyQueue {
-	if bufferSize <= 0 {
-		bufferSize = 100
-	}
-	if workerCount <= 0 {
-		workerCount = 5
-	}
-
+func NewPushNotifyQueue(db *gorm.DB, opts QueueOptions, handler func(PushNotifyJob) error) *PushNotifyQueue {
 	q := &PushNotifyQueue{
-		jobs:    make(chan PushNotifyJob, bufferSize),
-		handler: handler,
-	}
-
-	for i := 0; i < workerCount; i++ {
-		go func() {
-			for job := range q.jobs {
-				q.handler(job)
+		JobQueue: NewJobQueue(db, models.JobQueuePushNotify, 5, func(job *models.Job) error {
+			var payload PushNotifyJob
+			if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+				return err
 			}
-		}()
+			payload.JobID = job.ID
+			return handler(payload)
+		}),
 	}
-
+	q.Configure(opts)
 	return q
 }
 
-func (q *PushNotifyQueue) Enqueue(job PushNotifyJob) bool {
-	select {
-	case q.jobs <- job:
-		return true
-	default:
-		return false
+// Enqueue 按任务优先级入队，同一去重键同一推送目标未完成时不重复入队，仓库不去重时总是入队
+func (q *PushNotifyQueue) Enqueue(job PushNotifyJob) (bool, error) {
+	key := ""
+	if job.DedupKey != "" {
+		key = fmtKey(job.TargetID, job.DedupKey)
 	}
+	return q.JobQueue.EnqueuePriority(job, key, job.Priority)
 }
@@ -1,27 +1,74 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
 	"backend/internal/models"
 	"backend/internal/repository"
+	"backend/pkg/notifier"
+	"backend/pkg/retry"
 	"backend/utils/logger"
 
 	"gorm.io/gorm"
 )
 
 var (
-	ErrPushNotFound = errors.New("推送记录不存在")
+	ErrPushNotFound     = errors.New("推送记录不存在")
+	ErrPushNotRetryable = errors.New("只有发送失败或死信状态的推送可以重试")
+	ErrPushNotRecorded  = errors.New("推送结果保存失败")
 )
 
+// pushTitle 提交通知的消息标题
+const pushTitle = "代码提交通知"
+
+// PushRetryJob 推送重试任务
+type PushRetryJob struct {
+	PushID uint `json:"push_id"`
+}
+
 type PushService struct {
-	pushRepo *repository.PushRepo
+	pushRepo     *repository.PushRepo
+	targetRepo   *repository.TargetRepo
+	issueRepo    *repository.ReviewIssueRepo
+	fileRepo     *repository.ReviewFileRepo
+	deliveryServ *DeliveryService
+	retryPolicy  retry.Policy
+	retryQ       *JobQueue
 }
 
-func NewPushService(db *gorm.DB) *PushService {
-	return &PushService{
-		pushRepo: repository.NewPushRepo(db),
+func NewPushService(db *gorm.DB, deliveryServ *DeliveryService, retryPolicy retry.Policy, queueOpts QueueOptions) *PushService {
+	s := &PushService{
+		pushRepo:     repository.NewPushRepo(db),
+		targetRepo:   repository.NewTargetRepo(db),
+		issueRepo:    repository.NewReviewIssueRepo(db),
+		fileRepo:     repository.NewReviewFileRepo(db),
+		deliveryServ: deliveryServ,
+		retryPolicy:  retryPolicy,
 	}
+	s.retryQ = NewJobQueue(db, models.JobQueuePushRetry, 2, s.processRetryJob)
+	s.retryQ.Configure(queueOpts)
+	s.retryQ.OnCancel(s.onRetryCanceled)
+	return s
+}
+
+// Start 启动重试队列
+func (s *PushService) Start() {
+	s.retryQ.Start()
+}
+
+// Shutdown 停止重试队列，ctx 结束前未完成的重试任务重新排队
+func (s *PushService) Shutdown(ctx context.Context) error {
+	return s.retryQ.Shutdown(ctx)
+}
+
+// Queues 推送服务使用的任务队列
+func (s *PushService) Queues() []*JobQueue {
+	return []*JobQueue{s.retryQ}
 }
 
 // Create 创建推送记录
@@ -52,78 +99,66 @@ func (s *PushService) GetByID(id uint) (*models.Push, error) {
 	return s.pushRepo.GetByID(id)
 }
 
+// GetDetail 获取推送记录详情，包含发送记录和审查问题
+func (s *PushService) GetDetail(id uint) (*models.Push, error) {
+	push, err := s.pushRepo.GetDetail(id)
+	if err != nil {
+		return nil, err
+	}
+	if push.IssueCount > 0 {
+		issues, err := s.issueRepo.GetByCommit(push.RepoID, push.CommitID)
+		if err != nil {
+			return nil, err
+		}
+		push.ReviewIssues = issues
+	}
+	files, err := s.fileRepo.GetByCommit(push.RepoID, push.CommitID)
+	if err != nil {
+		return nil, err
+	}
+	push.ReviewFiles = files
+	return push, nil
+}
+
 // GetList 获取推送记录列表
 func (s *PushService) GetList(page, size int, repoID, targetID uint, status, keyword, startTime, endTime string) ([]models.Push, int64, error) {
 	pushes, total := s.pushRepo.GetList(page, size, repoID, targetID, status, keyword, startTime, endTime)
 	return pushes, total, nil
 }
 
-// Retry 重试推送
-func (s *PushService) Retry(id uint) (uint, error) {
+// Retry 手动重试推送：在原记录上重新发送，保留已发送次数和分段结果
+func (s *PushService) Retry(id uint) error {
 	push, err := s.pushRepo.GetByID(id)
 	if err != nil {
-		return 0, err
-	}
-
-	// 使用事务确保先删后加的原子性
-	var newID uint
-	err = s.pushRepo.Transaction(func(txRepo *repository.PushRepo) error {
-		// 1. 准备新数据
-		newPushData := map[string]interface{}{
-			"repo_id":    push.RepoID,
-			"target_id":  push.TargetID,
-			"commit_id":  push.CommitID,
-			"commit_msg": push.CommitMsg,
-			"content":    push.Content,
-		}
-		if push.TemplateID != nil {
-			newPushData["template_id"] = *push.TemplateID
-		}
-
-		// 2. 物理删除旧记录
-		if err := txRepo.Delete(id); err != nil {
-			return err
-		}
-
-		// 3. 创建新记录
-		// 这里直接构造模型对象以避免依赖 Service 的 Create 方法（可能涉及不同的 Repo 实例）
-		newPush := &models.Push{
-			RepoID:     newPushData["repo_id"].(uint),
-			TargetID:   newPushData["target_id"].(uint),
-			CommitID:   newPushData["commit_id"].(string),
-			CommitMsg:  newPushData["commit_msg"].(string),
-			Content:    newPushData["content"].(string),
-			Status:     models.PushStatusPending,
-			RetryCount: 0,
-		}
-		if tid, ok := newPushData["template_id"].(uint); ok {
-			newPush.TemplateID = &tid
-		}
-
-		if err := txRepo.Create(newPush); err != nil {
-			return err
-		}
-		newID = newPush.ID
-		return nil
-	})
+		return ErrPushNotFound
+	}
+	if push.Status != models.PushStatusFailed && push.Status != models.PushStatusRetry && push.Status != models.PushStatusDead {
+		return ErrPushNotRetryable
+	}
 
-	if err != nil {
-		return 0, err
+	now := time.Now()
+	push.Status = models.PushStatusRetry
+	push.NextRetryAt = &now
+	if err := s.pushRepo.SaveDelivery(push); err != nil {
+		return err
+	}
+	if _, err := s.retryQ.EnqueueAt(PushRetryJob{PushID: push.ID}, retryKey(push.ID, push.RetryCount+2), now); err != nil {
+		return err
 	}
 
-	logger.Info("Push retried and old record physically removed", map[string]interface{}{
-		"original_id": id,
-		"new_id":      newID,
+	logger.Info("Push retry queued", map[string]interface{}{
+		"push_id":     id,
+		"retry_count": push.RetryCount,
 	})
 
-	return newID, nil
+	return nil
 }
 
 // BatchRetry 批量重试
 func (s *PushService) BatchRetry(ids []uint) (int, error) {
 	count := 0
 	for _, id := range ids {
-		_, err := s.Retry(id)
+		err := s.Retry(id)
 		if err == nil {
 			count++
 		}
@@ -131,6 +166,143 @@ func (s *PushService) BatchRetry(ids []uint) (int, error) {
 	return count, nil
 }
 
+// Deliver 通过推送目标的渠道发送推送记录并保存结果
+// 遇到临时错误（超时、5xx、限流）且未超过推送目标的最多发送次数时，
+// 按指数退避安排自动重试，推送记录状态为 retrying；
+// 重试次数用尽或推送目标已暂停时记为死信（dead）。
+// 推送记录保存失败时返回包装了 ErrPushNotRecorded 的错误，此时发送结果和重试安排都未记录。
+func (s *PushService) Deliver(push *models.Push, target *models.Target) error {
+	parts, err := s.deliveryServ.Resend(target, pushTitle, push.Content, push.Parts, AttemptRef{Kind: models.AttemptKindPush, PushID: &push.ID})
+	push.Parts = parts
+	push.QueueWaitMs = TotalWait(parts)
+	push.NextRetryAt = nil
+
+	if err == nil {
+		now := time.Now()
+		push.Status = models.PushStatusSuccess
+		push.ErrorMsg = ""
+		push.PushedAt = &now
+		logger.Info("Push succeeded", map[string]interface{}{
+			"push_id":   push.ID,
+			"target_id": target.ID,
+			"attempts":  push.RetryCount + 1,
+		})
+		return s.saveDelivery(push)
+	}
+
+	push.Status = models.PushStatusFailed
+	push.ErrorMsg = err.Error()
+
+	policy := s.policyFor(target)
+	attempts := push.RetryCount + 1
+	switch {
+	case errors.Is(err, ErrTargetPaused):
+		push.Status = models.PushStatusDead
+	case notifier.IsRetryable(err) && policy.CanRetry(attempts):
+		retryAt := time.Now().Add(policy.Delay(attempts))
+		_, qerr := s.retryQ.EnqueueAt(PushRetryJob{PushID: push.ID}, retryKey(push.ID, attempts+1), retryAt)
+		if qerr != nil {
+			// 重试任务入队失败时推送记录保持失败状态，可手动重试
+			logger.Error("Failed to schedule push retry", map[string]interface{}{
+				"push_id": push.ID,
+				"error":   qerr.Error(),
+			})
+		} else {
+			push.Status = models.PushStatusRetry
+			push.NextRetryAt = &retryAt
+		}
+	case notifier.IsRetryable(err):
+		push.Status = models.PushStatusDead
+	}
+
+	logger.Error("Push failed", map[string]interface{}{
+		"push_id":       push.ID,
+		"target_id":     target.ID,
+		"attempts":      attempts,
+		"next_retry_at": push.NextRetryAt,
+		"error":         err.Error(),
+	})
+
+	if serr := s.saveDelivery(push); serr != nil {
+		return serr
+	}
+	return err
+}
+
+// saveDelivery 保存发送结果，失败时包装为 ErrPushNotRecorded
+func (s *PushService) saveDelivery(push *models.Push) error {
+	if err := s.pushRepo.SaveDelivery(push); err != nil {
+		return fmt.Errorf("%w: %v", ErrPushNotRecorded, err)
+	}
+	return nil
+}
+
+// processRetryJob 执行重试任务
+func (s *PushService) processRetryJob(job *models.Job) error {
+	var payload PushRetryJob
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return err
+	}
+
+	push, err := s.pushRepo.GetByID(payload.PushID)
+	if err != nil {
+		// 推送记录已删除
+		return nil
+	}
+	if push.Status != models.PushStatusRetry {
+		return nil
+	}
+
+	target, err := s.targetRepo.GetByID(push.TargetID)
+	if err != nil {
+		push.Status = models.PushStatusFailed
+		push.ErrorMsg = "推送目标不存在"
+		push.NextRetryAt = nil
+		return s.pushRepo.SaveDelivery(push)
+	}
+
+	push.RetryCount++
+	// 推送记录是重试的唯一依据：发送失败时 Deliver 已按推送目标的策略安排下一次重试（或记为失败、死信），
+	// 任务本身不再重试，否则会与推送记录上的重试重复发送；只有推送记录保存失败时才交给任务队列重试。
+	if err := s.Deliver(push, target); errors.Is(err, ErrPushNotRecorded) {
+		return err
+	}
+	return nil
+}
+
+// onRetryCanceled 重试任务被取消时，等待重试的推送记录标记为失败
+func (s *PushService) onRetryCanceled(job *models.Job) {
+	var payload PushRetryJob
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return
+	}
+	push, err := s.pushRepo.GetByID(payload.PushID)
+	if err != nil || push.Status != models.PushStatusRetry {
+		return
+	}
+	push.Status = models.PushStatusFailed
+	push.NextRetryAt = nil
+	if push.ErrorMsg == "" {
+		push.ErrorMsg = "重试已取消"
+	}
+	s.pushRepo.SaveDelivery(push)
+}
+
+// policyFor 推送目标的重试策略，目标自身的最多次数优先于全局配置
+func (s *PushService) policyFor(target *models.Target) retry.Policy {
+	policy := s.retryPolicy
+	if target.MaxAttempts > 0 {
+		policy.MaxAttempts = target.MaxAttempts
+	}
+	return policy
+}
+
+// retryKey 重试任务的去重键，按推送记录和第几次发送区分
+// 手动重试与已安排的自动重试合并为同一任务，执行中的任务可以安排下一次重试。
+func retryKey(pushID uint, attempt int) string {
+	return "push:" + strconv.FormatUint(uint64(pushID), 10) + ":" + strconv.Itoa(attempt)
+}
+
 // BatchDelete 批量删除
 func (s *PushService) BatchDelete(ids []uint, beforeDate string) error {
 	if len(ids) > 0 {
@@ -144,9 +316,28 @@ func (s *PushService) Delete(id uint) error {
 	return s.pushRepo.Delete(id)
 }
 
-// GetStats 获取统计
+// GetStats 获取统计，附带本月审查问题按严重程度和类别的分布
 func (s *PushService) GetStats(startDate, endDate string) (map[string]interface{}, error) {
-	return s.pushRepo.GetStats(startDate, endDate)
+	stats, err := s.pushRepo.GetStats(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	bySeverity, err := s.issueRepo.CountBy("severity", monthStart)
+	if err != nil {
+		return nil, err
+	}
+	byCategory, err := s.issueRepo.CountBy("category", monthStart)
+	if err != nil {
+		return nil, err
+	}
+	stats["review_issues"] = map[string]interface{}{
+		"by_severity": bySeverity,
+		"by_category": byCategory,
+	}
+	return stats, nil
 }
 
 // UpdateStatus 更新推送状态
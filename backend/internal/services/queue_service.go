@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var ErrQueueNotFound = errors.New("任务队列不存在")
+
+// JobView 任务列表项，附带等待和执行时长
+type JobView struct {
+	models.Job
+	AgeSeconds     int64 `json:"age_seconds"`               // 入队至今
+	RunningSeconds int64 `json:"running_seconds,omitempty"` // 执行中的任务已执行时长
+}
+
+// QueueService 任务队列管理：查看队列状态和任务，取消任务、调整优先级，暂停/恢复队列和调整执行者数量
+// 暂停和执行者数量只作用于当前实例，重启后恢复为配置值。
+type QueueService struct {
+	jobRepo *repository.JobRepo
+	queues  map[string]*JobQueue
+	names   []string
+}
+
+func NewQueueService(db *gorm.DB, queues ...*JobQueue) *QueueService {
+	s := &QueueService{
+		jobRepo: repository.NewJobRepo(db),
+		queues:  make(map[string]*JobQueue, len(queues)),
+	}
+	for _, q := range queues {
+		s.queues[q.Name()] = q
+		s.names = append(s.names, q.Name())
+	}
+	return s
+}
+
+// List 获取所有队列的运行状态
+func (s *QueueService) List() ([]*JobQueueStats, error) {
+	list := make([]*JobQueueStats, 0, len(s.names))
+	for _, name := range s.names {
+		stats, err := s.queues[name].Stats()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, stats)
+	}
+	return list, nil
+}
+
+// Jobs 获取队列中的任务，status 为空时返回未结束的任务（等待、重试、执行中）
+func (s *QueueService) Jobs(name, status string, page, size int) ([]JobView, int64, error) {
+	if _, ok := s.queues[name]; !ok {
+		return nil, 0, ErrQueueNotFound
+	}
+	statuses := []string{models.JobStatusQueued, models.JobStatusFailed, models.JobStatusRunning}
+	if status != "" {
+		statuses = []string{status}
+	}
+
+	jobs, total := s.jobRepo.List(name, statuses, page, size)
+	now := time.Now()
+	list := make([]JobView, 0, len(jobs))
+	for _, job := range jobs {
+		view := JobView{Job: job, AgeSeconds: int64(now.Sub(job.CreatedAt).Seconds())}
+		if job.Status == models.JobStatusRunning && job.StartedAt != nil {
+			view.RunningSeconds = int64(now.Sub(*job.StartedAt).Seconds())
+		}
+		list = append(list, view)
+	}
+	return list, total, nil
+}
+
+// Cancel 取消任务
+func (s *QueueService) Cancel(id uint) error {
+	job, q, err := s.getJob(id)
+	if err != nil {
+		return err
+	}
+	return q.Cancel(job)
+}
+
+// SetPriority 调整等待中任务的优先级，越大越先执行
+func (s *QueueService) SetPriority(id uint, priority int) error {
+	job, q, err := s.getJob(id)
+	if err != nil {
+		return err
+	}
+	return q.SetPriority(job, priority)
+}
+
+// Has 是否存在指定名称的队列
+func (s *QueueService) Has(name string) bool {
+	_, ok := s.queues[name]
+	return ok
+}
+
+// Pause 暂停队列
+func (s *QueueService) Pause(name string) error {
+	q, ok := s.queues[name]
+	if !ok {
+		return ErrQueueNotFound
+	}
+	q.Pause()
+	return nil
+}
+
+// Resume 恢复队列
+func (s *QueueService) Resume(name string) error {
+	q, ok := s.queues[name]
+	if !ok {
+		return ErrQueueNotFound
+	}
+	q.Resume()
+	return nil
+}
+
+// SetWorkers 调整队列的执行者数量
+func (s *QueueService) SetWorkers(name string, workers int) error {
+	q, ok := s.queues[name]
+	if !ok {
+		return ErrQueueNotFound
+	}
+	return q.SetWorkers(workers)
+}
+
+// SetHighWorkers 调整队列高优先级通道的专用执行者数量
+func (s *QueueService) SetHighWorkers(name string, workers int) error {
+	q, ok := s.queues[name]
+	if !ok {
+		return ErrQueueNotFound
+	}
+	return q.SetHighWorkers(workers)
+}
+
+// getJob 获取任务及其所属队列
+func (s *QueueService) getJob(id uint) (*models.Job, *JobQueue, error) {
+	job, err := s.jobRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, ErrJobNotFound
+	}
+	q, ok := s.queues[job.Queue]
+	if !ok {
+		return nil, nil, ErrQueueNotFound
+	}
+	return job, q, nil
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/pkg/calendar"
+	"backend/utils/logger"
+
+	"gorm.io/gorm"
+)
+
+var ErrCalendarNameRequired = errors.New("日历名称不能为空")
+
+// QuietHoursService 免打扰：暂存免打扰期间的非紧急消息，结束后补发
+type QuietHoursService struct {
+	targetRepo   *repository.TargetRepo
+	pushRepo     *repository.PushRepo
+	holidayRepo  *repository.HolidayRepo
+	heldRepo     *repository.HeldMessageRepo
+	deliveryServ *DeliveryService
+	pushServ     *PushService
+
+	mu     sync.Mutex
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+func NewQuietHoursService(db *gorm.DB, deliveryServ *DeliveryService, pushServ *PushService) *QuietHoursService {
+	return &QuietHoursService{
+		targetRepo:   repository.NewTargetRepo(db),
+		pushRepo:     repository.NewPushRepo(db),
+		holidayRepo:  repository.NewHolidayRepo(db),
+		heldRepo:     repository.NewHeldMessageRepo(db),
+		deliveryServ: deliveryServ,
+		pushServ:     pushServ,
+	}
+}
+
+// IsQuiet 判断推送目标当前是否处于免打扰时段
+func (s *QuietHoursService) IsQuiet(target *models.Target, now time.Time) bool {
+	q := target.QuietHours
+	if q == nil || !q.Enabled {
+		return false
+	}
+	return q.IsQuiet(now, func(date string) bool {
+		return s.holidayRepo.IsHoliday(q.Calendar, date)
+	})
+}
+
+// IsUrgentPush 判断推送是否满足推送目标的紧急条件
+func (s *QuietHoursService) IsUrgentPush(target *models.Target, payload *UnifiedPushPayload) bool {
+	if target.QuietHours == nil {
+		return false
+	}
+	rules := target.QuietHours.Urgent
+
+	if payload.Forced {
+		for _, pattern := range rules.ForcePushBranches {
+			if pattern == "*" || pattern == payload.Branch {
+				return true
+			}
+			if ok, _ := path.Match(pattern, payload.Branch); ok {
+				return true
+			}
+		}
+	}
+
+	msg := strings.ToLower(payload.CommitMsg)
+	for _, kw := range rules.Keywords {
+		if kw != "" && strings.Contains(msg, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUrgentReview 判断审查结果是否满足推送目标的紧急条件
+func (s *QuietHoursService) IsUrgentReview(target *models.Target, hasErrors bool) bool {
+	return target.QuietHours != nil && target.QuietHours.Urgent.ReviewErrors && hasErrors
+}
+
+// Hold 暂存消息，等待免打扰结束后补发
+func (s *QuietHoursService) Hold(target *models.Target, kind string, pushID *uint, title, content string) error {
+	msg := &models.HeldMessage{
+		TargetID: target.ID,
+		PushID:   pushID,
+		Kind:     kind,
+		Title:    title,
+		Content:  content,
+		Status:   models.HeldStatusHeld,
+	}
+	if err := s.heldRepo.Create(msg); err != nil {
+		return err
+	}
+
+	logger.Info("Message held for quiet hours", map[string]interface{}{
+		"held_id":   msg.ID,
+		"target_id": target.ID,
+		"kind":      kind,
+	})
+	return nil
+}
+
+// Start 启动定时检查，免打扰结束后补发暂存的消息
+func (s *QuietHoursService) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopCh != nil {
+		return
+	}
+	s.stopCh = make(chan struct{})
+	s.doneCh = make(chan struct{})
+
+	go func(stopCh, doneCh chan struct{}) {
+		defer close(doneCh)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case now := <-ticker.C:
+				s.Release(now)
+			}
+		}
+	}(s.stopCh, s.doneCh)
+}
+
+// Stop 停止定时检查，等待正在补发的消息完成
+func (s *QuietHoursService) Stop() {
+	s.mu.Lock()
+	stopCh, doneCh := s.stopCh, s.doneCh
+	s.stopCh, s.doneCh = nil, nil
+	s.mu.Unlock()
+
+	if stopCh == nil {
+		return
+	}
+	close(stopCh)
+	<-doneCh
+}
+
+// Release 补发已结束免打扰的推送目标的暂存消息
+func (s *QuietHoursService) Release(now time.Time) {
+	msgs, err := s.heldRepo.GetHeld()
+	if err != nil {
+		logger.Error("Failed to get held messages", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	quiet := make(map[uint]bool)
+	for i := range msgs {
+		msg := &msgs[i]
+		q, ok := quiet[msg.TargetID]
+		if !ok {
+			q = s.IsQuiet(&msg.Target, now)
+			quiet[msg.TargetID] = q
+		}
+		if q {
+			continue
+		}
+		s.release(msg)
+	}
+}
+
+// release 发送一条暂存消息，推送类消息通过推送记录发送（失败时按策略自动重试）
+func (s *QuietHoursService) release(msg *models.HeldMessage) {
+	var err error
+	if msg.Kind == models.HeldKindPush && msg.PushID != nil {
+		push, perr := s.pushRepo.GetByID(*msg.PushID)
+		if perr != nil {
+			err = perr
+		} else {
+			err = s.pushServ.Deliver(push, &msg.Target)
+		}
+	} else {
+		_, err = s.deliveryServ.Send(&msg.Target, msg.Title, msg.Content, AttemptRef{Kind: models.AttemptKindReview, PushID: msg.PushID})
+	}
+
+	releasedAt := time.Now()
+	msg.ReleasedAt = &releasedAt
+	if err != nil {
+		msg.Status = models.HeldStatusFailed
+		msg.ErrorMsg = err.Error()
+		logger.Error("Held message release failed", map[string]interface{}{
+			"held_id":   msg.ID,
+			"target_id": msg.TargetID,
+			"error":     err.Error(),
+		})
+	} else {
+		msg.Status = models.HeldStatusReleased
+	}
+	s.heldRepo.Update(msg)
+}
+
+// GetHeldList 获取暂存消息列表
+func (s *QuietHoursService) GetHeldList(page, size int, targetID uint, status string) ([]models.HeldMessage, int64, error) {
+	msgs, total := s.heldRepo.GetList(page, size, targetID, status)
+	return msgs, total, nil
+}
+
+// ImportICS 从 ICS 文件导入节假日，替换同名日历
+func (s *QuietHoursService) ImportICS(calendarName string, r io.Reader) (int, error) {
+	calendarName = strings.TrimSpace(calendarName)
+	if calendarName == "" {
+		return 0, ErrCalendarNameRequired
+	}
+
+	events, err := calendar.Parse(r)
+	if err != nil {
+		return 0, err
+	}
+
+	seen := make(map[string]bool)
+	var holidays []models.Holiday
+	for _, e := range events {
+		for _, date := range e.Dates() {
+			if seen[date] {
+				continue
+			}
+			seen[date] = true
+			holidays = append(holidays, models.Holiday{
+				Calendar: calendarName,
+				Date:     date,
+				Name:     e.Summary,
+			})
+		}
+	}
+
+	if err := s.holidayRepo.ReplaceCalendar(calendarName, holidays); err != nil {
+		return 0, err
+	}
+
+	logger.Info("Holiday calendar imported", map[string]interface{}{
+		"calendar": calendarName,
+		"events":   len(events),
+		"days":     len(holidays),
+	})
+	return len(holidays), nil
+}
+
+// GetHolidays 获取节假日列表
+func (s *QuietHoursService) GetHolidays(calendarName string) ([]models.Holiday, error) {
+	return s.holidayRepo.GetList(calendarName)
+}
+
+// GetCalendars 获取节假日日历名称列表
+func (s *QuietHoursService) GetCalendars() ([]string, error) {
+	return s.holidayRepo.GetCalendars()
+}
+
+// DeleteHoliday 删除节假日
+func (s *QuietHoursService) DeleteHoliday(id uint) error {
+	return s.holidayRepo.Delete(id)
+}
+
+// Enabled 是否有推送目标启用了免打扰
+func (s *QuietHoursService) Enabled() bool {
+	targets, err := s.targetRepo.GetWithQuietHours()
+	if err != nil {
+		return false
+	}
+	for _, t := range targets {
+		if t.QuietHours != nil && t.QuietHours.Enabled {
+			return true
+		}
+	}
+	return false
+}
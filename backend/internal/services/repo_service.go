@@ -8,6 +8,7 @@ import (
 	"backend/internal/models"
 	"backend/internal/repository"
 	"backend/internal/utils"
+	"backend/pkg/git"
 	"backend/utils/logger"
 
 	"github.com/google/uuid"
@@ -15,15 +16,25 @@ import (
 )
 
 var (
-	ErrRepoNotFound      = errors.New("仓库不存在")
-	ErrRepoAlreadyExists = errors.New("仓库名称已存在")
-	ErrInvalidRepoURL    = errors.New("无效的仓库地址")
+	ErrRepoNotFound       = errors.New("仓库不存在")
+	ErrRepoAlreadyExists  = errors.New("仓库名称已存在")
+	ErrInvalidRepoURL     = errors.New("无效的仓库地址")
+	ErrInvalidDedupPolicy = errors.New("无效的去重策略")
+	ErrInvalidCommentMode = errors.New("无效的审查评论设置")
+	ErrInvalidStatusRule  = errors.New("无效的提交状态设置")
+	ErrInvalidKnownHosts  = errors.New("无效的 SSH 主机公钥（known_hosts）")
+	ErrNotSSHRepo         = errors.New("仓库地址不是 SSH 地址")
+	ErrInvalidDiffSource  = errors.New("无效的差异来源设置")
+	ErrInvalidContext     = errors.New("无效的审查上下文预算")
+	ErrInvalidPrompt      = errors.New("审查提示词不存在或不是代码审查类型")
 )
 
 type RepoService struct {
 	db         *gorm.DB
 	repoRepo   *repository.RepoRepo
 	targetRepo *repository.TargetRepo
+	cacheRepo  *repository.ReviewCacheRepo
+	promptRepo *repository.PromptRepo
 }
 
 func NewRepoService(db *gorm.DB) *RepoService {
@@ -31,6 +42,8 @@ func NewRepoService(db *gorm.DB) *RepoService {
 		db:         db,
 		repoRepo:   repository.NewRepoRepo(db),
 		targetRepo: repository.NewTargetRepo(db),
+		cacheRepo:  repository.NewReviewCacheRepo(db),
+		promptRepo: repository.NewPromptRepo(db),
 	}
 }
 
@@ -52,6 +65,27 @@ func (s *RepoService) Create(data models.CreateRepo) (*models.Repo, error) {
 	if !isValidGitURL(url) {
 		return nil, ErrInvalidRepoURL
 	}
+	if !isValidDedupPolicy(data.DedupPolicy) {
+		return nil, ErrInvalidDedupPolicy
+	}
+	if !isValidCommentSettings(data.CommentMode, data.CommentSeverity) {
+		return nil, ErrInvalidCommentMode
+	}
+	if !isValidStatusRule(data.StatusSeverity, data.StatusMaxIssues) {
+		return nil, ErrInvalidStatusRule
+	}
+	if !isValidKnownHosts(data.SSHKnownHosts) {
+		return nil, ErrInvalidKnownHosts
+	}
+	if !isValidDiffSource(data.DiffSource, data.APIURL) {
+		return nil, ErrInvalidDiffSource
+	}
+	if data.ContextBudget != nil && *data.ContextBudget < 0 {
+		return nil, ErrInvalidContext
+	}
+	if !s.isValidPrompt(data.PromptID) {
+		return nil, ErrInvalidPrompt
+	}
 
 	// 生成Webhook URL
 	webhookID := uuid.New().String()
@@ -73,6 +107,28 @@ func (s *RepoService) Create(data models.CreateRepo) (*models.Repo, error) {
 
 	repo.ModelID = data.ModelID
 	repo.CommitTemplateID = data.CommitTemplateID
+	repo.DedupPolicy = data.DedupPolicy
+	if repo.DedupPolicy == "" {
+		repo.DedupPolicy = models.DedupPolicyCommit
+	}
+	if data.DedupWindow != nil && *data.DedupWindow > 0 {
+		repo.DedupWindow = *data.DedupWindow
+	}
+	repo.PriorityBranches = models.DefaultPriorityBranches
+	applyPriorityRules(repo, data.PriorityBranches, data.PriorityTags, data.HighPriority)
+	repo.CommentMode = models.CommentModeAuto
+	repo.CommentSeverity = models.IssueSeverityWarning
+	applyCommentSettings(repo, data.PublishComments, data.CommentMode, data.CommentSeverity)
+	repo.StatusSeverity = models.IssueSeverityError
+	applyStatusRule(repo, data.PublishStatus, data.StatusSeverity, data.StatusMaxIssues)
+	if data.SSHKnownHosts != nil {
+		repo.SSHKnownHosts = strings.TrimSpace(*data.SSHKnownHosts)
+	}
+	repo.DiffSource = models.DiffSourceGit
+	applyDiffSource(repo, data.DiffSource, data.APIURL)
+	applyReviewContext(repo, data.ContextBudget, data.ContextRelated)
+	repo.PromptID = data.PromptID
+	applyReviewScene(repo, data.ReviewScene)
 
 	if err := s.repoRepo.Create(repo); err != nil {
 		return nil, err
@@ -128,6 +184,28 @@ func (s *RepoService) GetList(page, size int, keyword string) ([]models.Repo, in
 
 // Update 更新仓库
 func (s *RepoService) Update(id uint, data models.UpdateRepo) error {
+	if !isValidDedupPolicy(data.DedupPolicy) {
+		return ErrInvalidDedupPolicy
+	}
+	if !isValidCommentSettings(data.CommentMode, data.CommentSeverity) {
+		return ErrInvalidCommentMode
+	}
+	if !isValidStatusRule(data.StatusSeverity, data.StatusMaxIssues) {
+		return ErrInvalidStatusRule
+	}
+	if !isValidKnownHosts(data.SSHKnownHosts) {
+		return ErrInvalidKnownHosts
+	}
+	if !isValidDiffSource(data.DiffSource, data.APIURL) {
+		return ErrInvalidDiffSource
+	}
+	if data.ContextBudget != nil && *data.ContextBudget < 0 {
+		return ErrInvalidContext
+	}
+	if !s.isValidPrompt(data.PromptID) {
+		return ErrInvalidPrompt
+	}
+
 	return s.db.Transaction(func(tx *gorm.DB) error {
 		txRepo := s.repoRepo.WithTx(tx)
 
@@ -151,6 +229,22 @@ func (s *RepoService) Update(id uint, data models.UpdateRepo) error {
 		if data.AccessToken != "" {
 			repo.AccessToken = data.AccessToken
 		}
+		if data.DedupPolicy != "" {
+			repo.DedupPolicy = data.DedupPolicy
+		}
+		if data.DedupWindow != nil && *data.DedupWindow >= 0 {
+			repo.DedupWindow = *data.DedupWindow
+		}
+		applyPriorityRules(repo, data.PriorityBranches, data.PriorityTags, data.HighPriority)
+		applyCommentSettings(repo, data.PublishComments, data.CommentMode, data.CommentSeverity)
+		applyStatusRule(repo, data.PublishStatus, data.StatusSeverity, data.StatusMaxIssues)
+		if data.SSHKnownHosts != nil {
+			repo.SSHKnownHosts = strings.TrimSpace(*data.SSHKnownHosts)
+		}
+		applyDiffSource(repo, data.DiffSource, data.APIURL)
+		applyReviewContext(repo, data.ContextBudget, data.ContextRelated)
+		repo.PromptID = data.PromptID
+		applyReviewScene(repo, data.ReviewScene)
 
 		// 处理推送目标
 		if err := txRepo.DeleteTargets(repo.ID); err != nil {
@@ -218,6 +312,55 @@ func (s *RepoService) TestWebhook(id uint) (map[string]interface{}, error) {
 	return result, nil
 }
 
+// GenerateSSHKey 为仓库生成新的 ed25519 部署密钥，替换已有密钥，返回需添加到平台的公钥
+func (s *RepoService) GenerateSSHKey(id uint) (string, error) {
+	repo, err := s.repoRepo.GetByID(id)
+	if err != nil {
+		return "", err
+	}
+	privateKey, publicKey, err := git.GenerateDeployKey("codeview-" + repo.Name)
+	if err != nil {
+		return "", err
+	}
+	if err := s.repoRepo.UpdateSSHKey(id, privateKey, publicKey); err != nil {
+		return "", err
+	}
+
+	logger.Info("SSH deploy key generated", map[string]interface{}{
+		"repo_id": repo.ID,
+		"name":    repo.Name,
+	})
+	return publicKey, nil
+}
+
+// DeleteSSHKey 删除仓库的部署密钥
+func (s *RepoService) DeleteSSHKey(id uint) error {
+	if _, err := s.repoRepo.GetByID(id); err != nil {
+		return err
+	}
+	return s.repoRepo.UpdateSSHKey(id, "", "")
+}
+
+// ClearReviewCache 清空仓库缓存的审查结果，之后的提交重新调用模型审查，返回删除的数量
+func (s *RepoService) ClearReviewCache(id uint) (int64, error) {
+	if _, err := s.repoRepo.GetByID(id); err != nil {
+		return 0, err
+	}
+	return s.cacheRepo.DeleteByRepo(id)
+}
+
+// ScanHostKeys 获取仓库所在平台的 SSH 主机公钥，供核对指纹后填入 known_hosts
+func (s *RepoService) ScanHostKeys(id uint) ([]git.HostKey, error) {
+	repo, err := s.repoRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !git.IsSSHURL(repo.URL) {
+		return nil, ErrNotSSHRepo
+	}
+	return git.ScanHostKeys(repo.URL)
+}
+
 // AddTarget 关联推送目标
 func (s *RepoService) AddTarget(repoID, targetID uint) error {
 	_, err := s.repoRepo.GetByID(repoID)
@@ -250,6 +393,141 @@ func getString(data map[string]interface{}, key string) string {
 	return ""
 }
 
+// isValidDedupPolicy 校验去重策略，空表示使用默认（按提交）或保持不变
+func isValidDedupPolicy(policy string) bool {
+	switch policy {
+	case "", models.DedupPolicyCommit, models.DedupPolicyCommitBranch, models.DedupPolicyDelivery, models.DedupPolicyNone:
+		return true
+	}
+	return false
+}
+
+// applyPriorityRules 设置任务优先级规则，未传的字段保持不变
+func applyPriorityRules(repo *models.Repo, branches, tags *string, high *bool) {
+	if branches != nil {
+		repo.PriorityBranches = normalizePatterns(*branches)
+	}
+	if tags != nil {
+		repo.PriorityTags = normalizePatterns(*tags)
+	}
+	if high != nil {
+		repo.HighPriority = *high
+	}
+}
+
+// isValidCommentSettings 校验审查评论的发布位置和最低严重程度，空值表示不修改
+func isValidCommentSettings(mode, severity string) bool {
+	switch mode {
+	case "", models.CommentModeAuto, models.CommentModeCommit:
+	default:
+		return false
+	}
+	switch severity {
+	case "", models.IssueSeverityError, models.IssueSeverityWarning, models.IssueSeverityInfo:
+		return true
+	}
+	return false
+}
+
+// applyCommentSettings 设置审查评论，未传的字段保持不变
+func applyCommentSettings(repo *models.Repo, publish *bool, mode, severity string) {
+	if publish != nil {
+		repo.PublishComments = *publish
+	}
+	if mode != "" {
+		repo.CommentMode = mode
+	}
+	if severity != "" {
+		repo.CommentSeverity = severity
+	}
+}
+
+// isValidStatusRule 校验提交状态的失败阈值，空值表示不修改
+func isValidStatusRule(severity string, maxIssues *int) bool {
+	if maxIssues != nil && *maxIssues < 0 {
+		return false
+	}
+	switch severity {
+	case "", models.IssueSeverityError, models.IssueSeverityWarning, models.IssueSeverityInfo:
+		return true
+	}
+	return false
+}
+
+// applyStatusRule 设置提交状态，未传的字段保持不变
+func applyStatusRule(repo *models.Repo, publish *bool, severity string, maxIssues *int) {
+	if publish != nil {
+		repo.PublishStatus = *publish
+	}
+	if severity != "" {
+		repo.StatusSeverity = severity
+	}
+	if maxIssues != nil {
+		repo.StatusMaxIssues = *maxIssues
+	}
+}
+
+// isValidKnownHosts 校验 known_hosts 格式，未传表示不修改
+func isValidKnownHosts(knownHosts *string) bool {
+	return knownHosts == nil || git.ValidateKnownHosts(*knownHosts) == nil
+}
+
+// isValidDiffSource 校验差异来源和平台 API 地址，空值表示不修改
+func isValidDiffSource(source string, apiURL *string) bool {
+	if apiURL != nil && *apiURL != "" && !strings.HasPrefix(*apiURL, "http://") && !strings.HasPrefix(*apiURL, "https://") {
+		return false
+	}
+	switch source {
+	case "", models.DiffSourceGit, models.DiffSourceGitHub, models.DiffSourceGitLab:
+		return true
+	}
+	return false
+}
+
+// applyDiffSource 设置差异来源，未传的字段保持不变
+func applyDiffSource(repo *models.Repo, source string, apiURL *string) {
+	if source != "" {
+		repo.DiffSource = source
+	}
+	if apiURL != nil {
+		repo.APIURL = strings.TrimSuffix(strings.TrimSpace(*apiURL), "/")
+	}
+}
+
+// applyReviewContext 设置审查上下文，未传的字段保持不变
+func applyReviewContext(repo *models.Repo, budget *int, related *bool) {
+	if budget != nil {
+		repo.ContextBudget = *budget
+	}
+	if related != nil {
+		repo.ContextRelated = *related
+	}
+}
+
+// isValidPrompt 校验仓库指定的审查提示词，未指定表示按语言和场景匹配
+func (s *RepoService) isValidPrompt(promptID *uint) bool {
+	if promptID == nil {
+		return true
+	}
+	prompt, err := s.promptRepo.GetByID(*promptID)
+	return err == nil && prompt.Type == models.PromptTypeCodeView
+}
+
+// applyReviewScene 设置审查场景，未传时保持不变
+func applyReviewScene(repo *models.Repo, scene *string) {
+	if scene != nil {
+		repo.ReviewScene = strings.TrimSpace(*scene)
+	}
+}
+
+// normalizePatterns 将逗号、换行分隔的匹配规则整理为逗号分隔
+func normalizePatterns(s string) string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n' || r == ' '
+	})
+	return strings.Join(fields, ",")
+}
+
 func isValidGitURL(url string) bool {
 	// 简单的URL验证
 	return strings.HasPrefix(url, "http://") ||
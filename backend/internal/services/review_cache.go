@@ -0,0 +1,97 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+	"backend/utils/logger"
+)
+
+// reviewCachePruneInterval 清理过期缓存的间隔
+const reviewCachePruneInterval = time.Hour
+
+// reviewCacheVersion 审查输出格式或解析方式变化时递增，使旧的缓存失效
+const reviewCacheVersion = 1
+
+// reviewFingerprint 文件审查输入的指纹：文件名、差异、提示词（ID、版本和内容）、模型（ID、名称和参数）及审查上下文
+// 差异和上下文相同但提交不同（cherry-pick、rebase、推送到其他分支）时指纹相同。
+func reviewFingerprint(repo *models.Repo, input CodeViewInput, prompt *models.Prompt, model *models.AIModel) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "v%d\x00%s\x00%s\x00", reviewCacheVersion, input.FileName, input.DiffContent)
+	fmt.Fprintf(h, "prompt:%d:%d\x00%s\x00", prompt.ID, prompt.Version, prompt.Content)
+	fmt.Fprintf(h, "model:%d:%s:%s:%s\x00", model.ID, model.Name, model.Type, model.Params)
+	fmt.Fprintf(h, "context:%d:%t\x00%s\x00", repo.ContextBudget, repo.ContextRelated, input.FileContent)
+	for _, rf := range input.RelatedFiles {
+		fmt.Fprintf(h, "%s\x00%s\x00", rf.Path, rf.Content)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedReview 获取有效期内缓存的审查结果，未开启缓存或未命中时返回 nil
+func (s *CodeViewService) cachedReview(repoID uint, fingerprint string) *CodeViewResult {
+	if s.cacheTTL <= 0 {
+		return nil
+	}
+	cache, err := s.cacheRepo.Get(repoID, fingerprint, time.Now().Add(-s.cacheTTL))
+	if err != nil {
+		return nil
+	}
+	var result CodeViewResult
+	if err := json.Unmarshal([]byte(cache.Result), &result); err != nil {
+		return nil
+	}
+	s.cacheRepo.IncrementHit(cache.ID)
+	result.CachedFrom = cache.CommitID
+	return &result
+}
+
+// saveReviewCache 缓存审查结果，复用的结果和没有提交的审查（如提示词测试）不缓存
+func (s *CodeViewService) saveReviewCache(repoID uint, fingerprint string, input CodeViewInput, prompt *models.Prompt, model *models.AIModel, result *CodeViewResult) {
+	if s.cacheTTL <= 0 || input.CommitID == "" || result.CachedFrom != "" {
+		return
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	cache := &models.ReviewCache{
+		RepoID:        repoID,
+		Fingerprint:   fingerprint,
+		CommitID:      input.CommitID,
+		FileName:      input.FileName,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		ModelID:       model.ID,
+		Result:        string(data),
+		CreatedAt:     time.Now(),
+	}
+	if err := s.cacheRepo.Save(cache); err != nil {
+		logger.Warn("Failed to save review cache", map[string]interface{}{
+			"repo_id":   repoID,
+			"file_name": input.FileName,
+			"error":     err.Error(),
+		})
+	}
+	s.pruneReviewCache()
+}
+
+// pruneReviewCache 删除过期的缓存
+func (s *CodeViewService) pruneReviewCache() {
+	s.pruneMu.Lock()
+	if time.Since(s.prunedAt) < reviewCachePruneInterval {
+		s.pruneMu.Unlock()
+		return
+	}
+	s.prunedAt = time.Now()
+	s.pruneMu.Unlock()
+
+	if n, err := s.cacheRepo.DeleteBefore(time.Now().Add(-s.cacheTTL)); err == nil && n > 0 {
+		logger.Info("Pruned expired review cache", map[string]interface{}{
+			"count": n,
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"backend/internal/models"
+	"backend/pkg/git"
+)
+
+// maxReviewChunks 单个文件最多拆分审查的段数，超出部分不再调用模型
+const maxReviewChunks = 8
+
+// minChunkTokens 每段差异至少可用的 token 数，提示词占满上下文时仍按该大小拆分
+const minChunkTokens = 512
+
+// resultRank 审查结论的严重程度
+var resultRank = map[string]int{
+	ReviewResultPass:    1,
+	ReviewResultSuggest: 2,
+	ReviewResultProblem: 3,
+}
+
+// mergeChunkResults 合并各段的审查结果
+// 问题按文件、行号和内容去重，结论取最严重的一段，摘要按段列出；results 中审查失败的段为 nil，
+// 超出 results 的段为未审查的部分，在摘要末尾注明行范围。
+func mergeChunkResults(chunks []git.PatchChunk, results []*CodeViewResult) *CodeViewResult {
+	merged := &CodeViewResult{
+		Issues:        []Issue{},
+		Source:        models.IssueSourceJSON,
+		Chunks:        len(chunks),
+		SkippedChunks: len(chunks) - len(results),
+	}
+
+	seen := make(map[string]bool)
+	result := ReviewResultPass
+	var b strings.Builder
+	for i, res := range results {
+		chunk := chunks[i]
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "**第 %d/%d 段（第 %d-%d 行）**\n", i+1, len(chunks), chunk.StartLine, chunk.EndLine)
+		if res == nil {
+			b.WriteString("审查失败")
+			merged.FailedChunks++
+			continue
+		}
+		b.WriteString(strings.TrimSpace(res.Summary))
+
+		if resultRank[res.Result] > resultRank[result] {
+			result = res.Result
+		}
+		if res.Source != models.IssueSourceJSON {
+			merged.Source = res.Source
+		}
+		for _, issue := range res.Issues {
+			key := fmt.Sprintf("%s:%d:%s", issue.File, issue.Line, issue.Message)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged.Issues = append(merged.Issues, issue)
+		}
+	}
+
+	if merged.SkippedChunks > 0 {
+		ranges := make([]string, 0, merged.SkippedChunks)
+		for _, chunk := range chunks[len(results):] {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", chunk.StartLine, chunk.EndLine))
+		}
+		fmt.Fprintf(&b, "\n\n> ⚠️ 变更过大，共拆分为 %d 段，仅审查了前 %d 段，第 %s 行的变更未审查",
+			len(chunks), len(results), strings.Join(ranges, "、"))
+	}
+
+	merged.Result = normalizeResult(result, merged.Issues)
+	merged.Summary = b.String()
+	return merged
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/pkg/git"
+	"backend/utils/logger"
+
+	"gorm.io/gorm"
+)
+
+// maxLineComments 一次审查最多发布的行评论数量，其余问题只在汇总评论中列出
+const maxLineComments = 30
+
+// reviewSummaryMarker 汇总评论的标记，便于在平台上识别
+const reviewSummaryMarker = "<!-- git-notify:review-summary -->"
+
+// ReviewCommentService 将审查问题发布到 Git 平台
+// 定位到变更行的问题作为行评论发布到提交或 PR/MR，汇总评论在重新审查时原地更新。
+type ReviewCommentService struct {
+	commentRepo *repository.ReviewCommentRepo
+	baseURL     string
+}
+
+func NewReviewCommentService(db *gorm.DB, baseURL string) *ReviewCommentService {
+	return &ReviewCommentService{
+		commentRepo: repository.NewReviewCommentRepo(db),
+		baseURL:     baseURL,
+	}
+}
+
+// Publish 发布提交的审查问题，files 为审查的差异文件，用于定位行评论
+func (s *ReviewCommentService) Publish(repo *models.Repo, push *models.Push, issues []models.ReviewIssue, files []git.DiffFile) error {
+	client, err := newForgeClient(repo)
+	if err != nil {
+		return err
+	}
+
+	var pr *git.PullRequest
+	if repo.CommentMode != models.CommentModeCommit {
+		pr, err = client.FindPullRequest(push.CommitID)
+		if err != nil {
+			// 查询失败时发布到提交
+			logger.Warn("Failed to find pull request for commit", map[string]interface{}{
+				"repo_id":   repo.ID,
+				"commit_id": push.CommitID,
+				"error":     err.Error(),
+			})
+			pr = nil
+		}
+	}
+	target, prNumber := models.CommentTargetCommit, 0
+	if pr != nil {
+		target, prNumber = models.CommentTargetPullRequest, pr.Number
+	}
+
+	// 按严重程度和位置排序，行评论数量超出上限时优先发布严重的问题
+	issues = append([]models.ReviewIssue(nil), issues...)
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if severityRank(a.Severity) != severityRank(b.Severity) {
+			return severityRank(a.Severity) > severityRank(b.Severity)
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+
+	if err := s.publishLineComments(client, repo, push, pr, target, prNumber, issues, files); err != nil {
+		return err
+	}
+	return s.publishSummary(client, repo, push, pr, target, prNumber, issues)
+}
+
+// publishLineComments 发布行评论，已发布过的问题不重复发布
+func (s *ReviewCommentService) publishLineComments(client git.CommentClient, repo *models.Repo, push *models.Push, pr *git.PullRequest, target string, prNumber int, issues []models.ReviewIssue, files []git.DiffFile) error {
+	patches := make(map[string]string, len(files))
+	for _, f := range files {
+		patches[f.Filename] = f.Patch
+	}
+	posted, err := s.commentRepo.LineFingerprints(repo.ID, push.CommitID, target, prNumber)
+	if err != nil {
+		return err
+	}
+
+	minRank := severityRank(repo.CommentSeverity)
+	count := 0
+	for _, issue := range issues {
+		if count >= maxLineComments {
+			break
+		}
+		if severityRank(issue.Severity) < minRank {
+			continue
+		}
+		line, ok := git.LocateLine(patches[issue.File], issue.Line)
+		if !ok {
+			continue
+		}
+		fingerprint := issueFingerprint(issue)
+		if posted[fingerprint] {
+			continue
+		}
+
+		comment := git.Comment{Path: issue.File, Line: line, Body: formatLineComment(issue)}
+		var externalID string
+		if pr != nil {
+			externalID, err = client.CreatePullRequestComment(pr, push.CommitID, comment)
+		} else {
+			externalID, err = client.CreateCommitComment(push.CommitID, comment)
+		}
+		if err != nil {
+			// 单条行评论失败（如行不在平台计算的差异中）不影响其他评论
+			logger.Warn("Failed to publish review line comment", map[string]interface{}{
+				"repo_id":   repo.ID,
+				"commit_id": push.CommitID,
+				"file":      issue.File,
+				"line":      issue.Line,
+				"error":     err.Error(),
+			})
+			continue
+		}
+		count++
+		posted[fingerprint] = true
+		s.commentRepo.Create(&models.ReviewComment{
+			RepoID:      repo.ID,
+			CommitID:    push.CommitID,
+			Target:      target,
+			PRNumber:    prNumber,
+			Kind:        models.CommentKindLine,
+			Fingerprint: fingerprint,
+			ExternalID:  externalID,
+		})
+	}
+	return nil
+}
+
+// publishSummary 发布或更新汇总评论，平台上的评论已被删除时重新发布
+func (s *ReviewCommentService) publishSummary(client git.CommentClient, repo *models.Repo, push *models.Push, pr *git.PullRequest, target string, prNumber int, issues []models.ReviewIssue) error {
+	body := s.formatSummary(push, issues)
+
+	existing, err := s.commentRepo.GetSummary(repo.ID, push.CommitID, target, prNumber)
+	if err == nil && existing.ExternalID != "" {
+		if pr != nil {
+			err = client.UpdatePullRequestComment(pr, existing.ExternalID, body)
+		} else {
+			err = client.UpdateCommitComment(push.CommitID, existing.ExternalID, body)
+		}
+		if err == nil {
+			existing.CommitID = push.CommitID
+			return s.commentRepo.Save(existing)
+		}
+		if !errors.Is(err, git.ErrCommentNotFound) {
+			return err
+		}
+	}
+
+	var externalID string
+	comment := git.Comment{Body: body}
+	if pr != nil {
+		externalID, err = client.CreatePullRequestComment(pr, push.CommitID, comment)
+	} else {
+		externalID, err = client.CreateCommitComment(push.CommitID, comment)
+	}
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		existing.CommitID, existing.ExternalID = push.CommitID, externalID
+		return s.commentRepo.Save(existing)
+	}
+	return s.commentRepo.Create(&models.ReviewComment{
+		RepoID:     repo.ID,
+		CommitID:   push.CommitID,
+		Target:     target,
+		PRNumber:   prNumber,
+		Kind:       models.CommentKindSummary,
+		ExternalID: externalID,
+	})
+}
+
+// formatSummary 汇总评论内容：问题统计、问题列表和审查详情链接
+func (s *ReviewCommentService) formatSummary(push *models.Push, issues []models.ReviewIssue) string {
+	var b strings.Builder
+	b.WriteString(reviewSummaryMarker + "\n")
+	b.WriteString("### 🔍 AI 代码审查\n\n")
+
+	errorCount, warningCount, infoCount := countIssues(issues)
+	if len(issues) == 0 {
+		b.WriteString("未发现问题\n")
+	} else {
+		fmt.Fprintf(&b, "共发现 %d 个问题：错误 %d，警告 %d，提示 %d\n\n", len(issues), errorCount, warningCount, infoCount)
+		for _, issue := range issues {
+			location := issue.File
+			if issue.Line > 0 {
+				location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
+			}
+			fmt.Fprintf(&b, "- **%s** `%s` %s\n", severityLabels[issue.Severity], location, issue.Message)
+		}
+	}
+
+	if s.baseURL != "" {
+		fmt.Fprintf(&b, "\n[查看审查详情](%s/web/#/pushes/review?id=%d)\n", s.baseURL, push.ID)
+	}
+	return b.String()
+}
+
+// formatLineComment 行评论内容
+func formatLineComment(issue models.ReviewIssue) string {
+	var b strings.Builder
+	label := severityLabels[issue.Severity]
+	if issue.Category != "" {
+		label += " · " + issue.Category
+	}
+	fmt.Fprintf(&b, "**[%s]** %s", label, issue.Message)
+	if issue.Suggestion != "" {
+		fmt.Fprintf(&b, "\n\n建议：%s", issue.Suggestion)
+	}
+	return b.String()
+}
+
+// issueFingerprint 按文件、行号和问题内容区分行评论
+func issueFingerprint(issue models.ReviewIssue) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s:%d:%s:%s", issue.File, issue.Line, issue.Severity, issue.Message)))
+	return hex.EncodeToString(sum[:])
+}
+
+// severityRank 严重程度的比较顺序
+func severityRank(severity string) int {
+	switch severity {
+	case models.IssueSeverityError:
+		return 3
+	case models.IssueSeverityWarning:
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"backend/internal/models"
+)
+
+// 同一个 PR 推送新提交后原地更新汇总评论，不重复发布
+func TestPublishSummaryUpdatesPullRequestAcrossCommits(t *testing.T) {
+	db := newTestDB(t)
+	var mu sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/pulls"):
+			io.WriteString(w, `[{"number":7,"state":"open","head":{"sha":"x"}}]`)
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/demo/issues/7/comments":
+			io.WriteString(w, `{"id":43}`)
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/acme/demo/issues/comments/43":
+			io.WriteString(w, `{"id":43}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	repo := &models.Repo{ID: 1, URL: "https://github.com/acme/demo", Type: models.RepoTypeGitHub, APIURL: srv.URL, CommentMode: models.CommentModeAuto}
+	s := NewReviewCommentService(db, "")
+	for _, sha := range []string{"c1", "c2"} {
+		if err := s.Publish(repo, &models.Push{ID: 1, CommitID: sha}, nil, nil); err != nil {
+			t.Fatalf("Publish(%s) error = %v", sha, err)
+		}
+	}
+
+	var posts, patches int
+	for _, r := range requests {
+		switch r {
+		case "POST /repos/acme/demo/issues/7/comments":
+			posts++
+		case "PATCH /repos/acme/demo/issues/comments/43":
+			patches++
+		}
+	}
+	if posts != 1 || patches != 1 {
+		t.Errorf("summary posted %d times and updated %d times, want 1 and 1: %v", posts, patches, requests)
+	}
+
+	var comments []models.ReviewComment
+	db.Where("kind = ?", models.CommentKindSummary).Find(&comments)
+	if len(comments) != 1 || comments[0].CommitID != "c2" || comments[0].PRNumber != 7 {
+		t.Errorf("summary records = %+v, want one for PR 7 at c2", comments)
+	}
+}
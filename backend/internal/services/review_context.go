@@ -0,0 +1,341 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"backend/internal/models"
+	"backend/pkg/git"
+	"backend/utils/logger"
+)
+
+// maxRelatedFiles 每个文件最多附带的相关文件数
+const maxRelatedFiles = 3
+
+// maxRelatedCandidates 按内容挑选同目录相关文件时最多读取的文件数
+const maxRelatedCandidates = 10
+
+// maxContextFileBytes 超出该大小的文件不作为上下文
+const maxContextFileBytes = 512 << 10
+
+// contextExcerptRadii 完整文件超出预算时，依次尝试的变更块前后保留行数
+var contextExcerptRadii = []int{50, 20, 5}
+
+// RelatedFile 为审查附带的相关文件
+type RelatedFile struct {
+	Path    string
+	Content string
+}
+
+var (
+	// jsImportPattern JS/TS/Vue 的相对导入：import ... from './x'、import('./x')、require('./x')
+	jsImportPattern = regexp.MustCompile(`(?:from|import|require)\s*\(?\s*['"](\.{1,2}/[^'"]+)['"]`)
+	// pyImportPattern Python 的相对导入：from .x import y、from ..x.y import z
+	pyImportPattern = regexp.MustCompile(`(?m)^\s*from\s+(\.+)([\w.]*)\s+import`)
+	// identPattern 变更行中的标识符，用于挑选同目录的相关文件
+	identPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]{3,}`)
+)
+
+// identStopWords 常见关键字和内置名称，不作为挑选相关文件的依据
+var identStopWords = map[string]bool{
+	"async": true, "await": true, "break": true, "case": true, "class": true, "const": true, "continue": true,
+	"default": true, "defer": true, "elif": true, "else": true, "error": true, "except": true, "export": true,
+	"extends": true, "false": true, "False": true, "final": true, "func": true, "function": true, "import": true,
+	"interface": true, "lambda": true, "make": true, "null": true, "None": true, "package": true, "print": true,
+	"private": true, "public": true, "range": true, "return": true, "self": true, "static": true, "string": true,
+	"struct": true, "switch": true, "this": true, "throw": true, "true": true, "True": true, "type": true,
+	"undefined": true, "void": true, "while": true, "with": true, "yield": true,
+}
+
+// importExtensions 相对导入省略扩展名时尝试的扩展名
+var importExtensions = []string{"", ".ts", ".tsx", ".js", ".jsx", ".vue", "/index.ts", "/index.js"}
+
+// loadReviewContext 读取变更后的完整文件，仓库开启时一并读取相关文件，失败时只审查差异
+func loadReviewContext(client git.ContentClient, repo *models.Repo, commitID string, file git.DiffFile, input *CodeViewInput) {
+	content, err := client.GetFileContent(file.Filename, commitID)
+	if err != nil {
+		logger.Warn("Failed to load file content for review", map[string]interface{}{
+			"repo_id":   repo.ID,
+			"file_name": file.Filename,
+			"error":     err.Error(),
+		})
+		return
+	}
+	if !isContextText(content) {
+		return
+	}
+	input.FileContent = content
+
+	if repo.ContextRelated {
+		input.RelatedFiles = relatedFiles(client, commitID, file, content)
+	}
+}
+
+// relatedFiles 挑选相关文件：先取相对导入的文件，再取同目录同类型的文件中与变更行共用标识符最多的
+func relatedFiles(client git.ContentClient, commitID string, file git.DiffFile, content string) []RelatedFile {
+	listings := make(map[string][]string)
+	list := func(dir string) []string {
+		if files, ok := listings[dir]; ok {
+			return files
+		}
+		files, _ := client.ListFiles(dir, commitID)
+		listings[dir] = files
+		return files
+	}
+	read := func(p string) (RelatedFile, bool) {
+		c, err := client.GetFileContent(p, commitID)
+		if err != nil || !isContextText(c) {
+			return RelatedFile{}, false
+		}
+		return RelatedFile{Path: p, Content: c}, true
+	}
+
+	var related []RelatedFile
+	seen := map[string]bool{file.Filename: true}
+	for _, p := range resolveImports(file.Filename, content, list) {
+		if len(related) >= maxRelatedFiles {
+			return related
+		}
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		if rf, ok := read(p); ok {
+			related = append(related, rf)
+		}
+	}
+
+	// 同目录同类型的文件（Go 为同一个包），按与变更行共用的标识符数量排序
+	idents := changedIdents(file.Patch)
+	if len(idents) == 0 {
+		return related
+	}
+	ext := path.Ext(file.Filename)
+	type candidate struct {
+		file  RelatedFile
+		score int
+	}
+	var candidates []candidate
+	reads := 0
+	for _, p := range list(cleanDir(path.Dir(file.Filename))) {
+		if reads >= maxRelatedCandidates {
+			break
+		}
+		if seen[p] || path.Ext(p) != ext || isTestFile(p) {
+			continue
+		}
+		seen[p] = true
+		// 按读取次数计数，不相关的文件同样计入，避免大目录逐个读取
+		reads++
+		rf, ok := read(p)
+		if !ok {
+			continue
+		}
+		score := 0
+		for _, ident := range idents {
+			if strings.Contains(rf.Content, ident) {
+				score++
+			}
+		}
+		if score > 0 {
+			candidates = append(candidates, candidate{rf, score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	for _, c := range candidates {
+		if len(related) >= maxRelatedFiles {
+			break
+		}
+		related = append(related, c.file)
+	}
+	return related
+}
+
+// resolveImports 解析文件中的相对导入，按目录下实际存在的文件补全扩展名
+func resolveImports(fileName, content string, list func(dir string) []string) []string {
+	dir := path.Dir(fileName)
+	var targets []string
+	for _, m := range jsImportPattern.FindAllStringSubmatch(content, -1) {
+		targets = append(targets, path.Join(dir, m[1]))
+	}
+	for _, m := range pyImportPattern.FindAllStringSubmatch(content, -1) {
+		base := dir
+		for i := 1; i < len(m[1]); i++ {
+			base = path.Dir(base)
+		}
+		if m[2] == "" {
+			continue
+		}
+		target := path.Join(base, strings.ReplaceAll(m[2], ".", "/"))
+		targets = append(targets, target+".py", target+"/__init__.py")
+	}
+
+	var files []string
+	for _, target := range targets {
+		for _, ext := range importExtensions {
+			p := target + ext
+			if containsString(list(cleanDir(path.Dir(p))), p) {
+				files = append(files, p)
+				break
+			}
+		}
+	}
+	return files
+}
+
+// changedIdents 差异中新增行的标识符，去重
+func changedIdents(patch string) []string {
+	seen := make(map[string]bool)
+	var idents []string
+	for _, line := range strings.Split(patch, "\n") {
+		if !strings.HasPrefix(line, "+") || strings.HasPrefix(line, "+++") {
+			continue
+		}
+		for _, ident := range identPattern.FindAllString(line, -1) {
+			if !seen[ident] && !identStopWords[ident] {
+				seen[ident] = true
+				idents = append(idents, ident)
+			}
+		}
+	}
+	return idents
+}
+
+// fitReviewContext 在预算内放入上下文：完整文件放不下时改为变更块附近的片段，相关文件按顺序放入剩余预算
+// 返回的 FileContent 带行号，放不下的部分丢弃。
+func fitReviewContext(input CodeViewInput, budget int, estimate func(string) int) CodeViewInput {
+	fitted := input
+	fitted.FileContent = ""
+	fitted.RelatedFiles = nil
+	if budget <= 0 {
+		return fitted
+	}
+
+	if input.FileContent != "" {
+		lines := strings.Split(strings.TrimSuffix(input.FileContent, "\n"), "\n")
+		text := numberLines(lines, 1, len(lines))
+		if estimate(text) > budget {
+			text = ""
+			hunks := git.ParseHunks(input.DiffContent)
+			for _, radius := range contextExcerptRadii {
+				if len(hunks) == 0 {
+					break
+				}
+				if excerpt := excerptLines(lines, hunks, radius); estimate(excerpt) <= budget {
+					text = excerpt
+					break
+				}
+			}
+		}
+		if text != "" {
+			fitted.FileContent = text
+			budget -= estimate(text)
+		}
+	}
+
+	for _, rf := range input.RelatedFiles {
+		size := estimate(relatedSection(rf))
+		if size > budget {
+			continue
+		}
+		fitted.RelatedFiles = append(fitted.RelatedFiles, rf)
+		budget -= size
+	}
+	return fitted
+}
+
+// contextSections 提示词模板中没有引用的上下文，追加到提示词末尾
+func contextSections(rendered string, input CodeViewInput) string {
+	var b strings.Builder
+	if input.FileContent != "" && !strings.Contains(rendered, input.FileContent) {
+		b.WriteString("\n\n--- 变更后的文件内容（行号| 代码，仅供理解上下文，只审查差异中的变更） ---\n")
+		b.WriteString(input.FileContent)
+	}
+	for _, rf := range input.RelatedFiles {
+		if !strings.Contains(rendered, rf.Content) {
+			b.WriteString(relatedSection(rf))
+		}
+	}
+	return b.String()
+}
+
+// relatedSection 相关文件在提示词中的内容
+func relatedSection(rf RelatedFile) string {
+	return fmt.Sprintf("\n\n--- 相关文件：%s（仅供参考，不审查） ---\n%s", rf.Path, rf.Content)
+}
+
+// numberLines 为第 from 到 to 行加上行号
+func numberLines(lines []string, from, to int) string {
+	var b strings.Builder
+	for i := from; i <= to && i <= len(lines); i++ {
+		fmt.Fprintf(&b, "%5d| %s\n", i, lines[i-1])
+	}
+	return b.String()
+}
+
+// excerptLines 变更块前后各 radius 行的片段，相邻的片段合并，片段之间以 ... 分隔
+func excerptLines(lines []string, hunks []git.DiffHunk, radius int) string {
+	type span struct{ from, to int }
+	var spans []span
+	for _, h := range hunks {
+		s := span{h.NewStart - radius, h.NewStart + h.NewLines - 1 + radius}
+		if s.from < 1 {
+			s.from = 1
+		}
+		if s.to > len(lines) {
+			s.to = len(lines)
+		}
+		if n := len(spans); n > 0 && s.from <= spans[n-1].to+1 {
+			if s.to > spans[n-1].to {
+				spans[n-1].to = s.to
+			}
+			continue
+		}
+		spans = append(spans, s)
+	}
+
+	var b strings.Builder
+	for i, s := range spans {
+		if i > 0 || s.from > 1 {
+			b.WriteString("  ...\n")
+		}
+		b.WriteString(numberLines(lines, s.from, s.to))
+	}
+	if n := len(spans); n > 0 && spans[n-1].to < len(lines) {
+		b.WriteString("  ...\n")
+	}
+	return b.String()
+}
+
+// isContextText 是否可作为上下文的文本文件
+func isContextText(content string) bool {
+	return len(content) <= maxContextFileBytes && !strings.ContainsRune(content, 0)
+}
+
+// isTestFile 测试文件不作为相关文件
+func isTestFile(p string) bool {
+	name := path.Base(p)
+	return strings.HasSuffix(name, "_test.go") || strings.Contains(name, ".test.") || strings.Contains(name, ".spec.") ||
+		strings.HasPrefix(name, "test_")
+}
+
+// cleanDir path.Dir 的根目录为 "."，ListFiles 使用空字符串
+func cleanDir(dir string) string {
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// containsString 列表中是否包含 s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
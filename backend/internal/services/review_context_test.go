@@ -0,0 +1,48 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"backend/pkg/git"
+)
+
+// fakeContentClient 内存中的仓库文件，记录读取次数
+type fakeContentClient struct {
+	files map[string]string
+	reads int
+}
+
+func (c *fakeContentClient) GetFileContent(filePath, ref string) (string, error) {
+	c.reads++
+	content, ok := c.files[filePath]
+	if !ok {
+		return "", fmt.Errorf("%s not found", filePath)
+	}
+	return content, nil
+}
+
+func (c *fakeContentClient) ListFiles(dir, ref string) ([]string, error) {
+	var files []string
+	for p := range c.files {
+		files = append(files, p)
+	}
+	return files, nil
+}
+
+// 同目录中与变更无关的文件同样计入读取上限
+func TestRelatedFilesCapsReads(t *testing.T) {
+	client := &fakeContentClient{files: map[string]string{}}
+	for i := 0; i < 50; i++ {
+		client.files[fmt.Sprintf("pkg/unrelated%02d.go", i)] = "package pkg\n\nfunc other() {}\n"
+	}
+	file := git.DiffFile{Filename: "pkg/main.go", Patch: "@@ -1 +1 @@\n-a\n+result := computeTotal(order)\n"}
+
+	related := relatedFiles(client, "abc", file, "package pkg\n")
+	if len(related) != 0 {
+		t.Errorf("related = %d files, want 0", len(related))
+	}
+	if client.reads != maxRelatedCandidates {
+		t.Errorf("read %d files, want %d", client.reads, maxRelatedCandidates)
+	}
+}
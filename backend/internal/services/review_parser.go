@@ -0,0 +1,343 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"backend/internal/models"
+	"backend/pkg/ai"
+)
+
+// 审查结论
+const (
+	ReviewResultPass    = "通过"
+	ReviewResultSuggest = "有建议"
+	ReviewResultProblem = "有问题"
+)
+
+// reviewOutputSpec 追加在提示词后的输出格式要求，要求模型返回 JSON，便于解析为结构化问题
+const reviewOutputSpec = `
+
+--- 输出格式 ---
+只输出一个 JSON 对象，不要输出其他内容，格式如下：
+{"result":"通过|有建议|有问题","summary":"一句话总结","issues":[{"file":"文件路径","line":行号,"severity":"error|warning|info","category":"bug|security|performance|logic|style|maintainability|other","message":"问题描述","suggestion":"修改建议"}]}
+没有问题时 issues 为空数组，result 为"通过"。line 为变更后文件中的行号（从差异块头 @@ -a,b +c,d @@ 的 c 起算），无法确定时填 0。`
+
+// reviewOutputSchema 审查结果的 JSON Schema，与 reviewOutputSpec 描述的格式一致
+// 支持的模型按此结构化输出；不支持的模型仍按提示词中的格式说明返回。
+var reviewOutputSchema = &ai.JSONSchema{
+	Name:   "code_review",
+	Strict: true,
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"result":  map[string]interface{}{"type": "string", "enum": []string{ReviewResultPass, ReviewResultSuggest, ReviewResultProblem}},
+			"summary": map[string]interface{}{"type": "string"},
+			"issues": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"file":       map[string]interface{}{"type": "string"},
+						"line":       map[string]interface{}{"type": "integer"},
+						"severity":   map[string]interface{}{"type": "string", "enum": []string{"error", "warning", "info"}},
+						"category":   map[string]interface{}{"type": "string", "enum": []string{"bug", "security", "performance", "logic", "style", "maintainability", "other"}},
+						"message":    map[string]interface{}{"type": "string"},
+						"suggestion": map[string]interface{}{"type": "string"},
+					},
+					"required":             []string{"file", "line", "severity", "category", "message", "suggestion"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"result", "summary", "issues"},
+		"additionalProperties": false,
+	},
+}
+
+// reviewOutput 模型返回的 JSON 结构
+type reviewOutput struct {
+	Result  string        `json:"result"`
+	Summary string        `json:"summary"`
+	Issues  []reviewIssue `json:"issues"`
+}
+
+type reviewIssue struct {
+	File       string          `json:"file"`
+	Line       json.RawMessage `json:"line"`
+	Severity   string          `json:"severity"`
+	Category   string          `json:"category"`
+	Message    string          `json:"message"`
+	Suggestion string          `json:"suggestion"`
+}
+
+var (
+	issueLinePattern   = regexp.MustCompile(`(?i)(?:第\s*(\d+)\s*行|\blines?\s*:?\s*(\d+)|\bL(\d+)\b)`)
+	issueBulletPattern = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)、])\s+`)
+)
+
+// parseReviewOutput 解析模型返回的审查结果
+// 优先按 JSON 解析；模型返回自由文本时按列表项逐行提取问题，严重程度按关键词推断。
+func parseReviewOutput(raw, fileName string) *CodeViewResult {
+	if out, ok := decodeReviewOutput(raw); ok {
+		return buildReviewResult(out, fileName, models.IssueSourceJSON)
+	}
+	return parseReviewText(raw, fileName)
+}
+
+// decodeReviewOutput 从返回内容中提取 JSON，兼容 ```json 代码块和前后附带说明文字的情况
+func decodeReviewOutput(raw string) (*reviewOutput, bool) {
+	text := strings.TrimSpace(raw)
+	if i := strings.Index(text, "```"); i >= 0 {
+		block := text[i+3:]
+		if nl := strings.IndexByte(block, '\n'); nl >= 0 {
+			block = block[nl+1:]
+		}
+		if end := strings.Index(block, "```"); end >= 0 {
+			text = strings.TrimSpace(block[:end])
+		}
+	}
+
+	var out reviewOutput
+	if start, end := strings.IndexByte(text, '{'), strings.LastIndexByte(text, '}'); start >= 0 && end > start {
+		if err := json.Unmarshal([]byte(text[start:end+1]), &out); err == nil && (out.Result != "" || out.Summary != "" || out.Issues != nil) {
+			return &out, true
+		}
+	}
+	// 部分模型只返回问题数组
+	if start, end := strings.IndexByte(text, '['), strings.LastIndexByte(text, ']'); start >= 0 && end > start {
+		var issues []reviewIssue
+		if err := json.Unmarshal([]byte(text[start:end+1]), &issues); err == nil {
+			return &reviewOutput{Issues: issues}, true
+		}
+	}
+	return nil, false
+}
+
+// buildReviewResult 规范化问题字段，缺少结论时按问题的严重程度推断
+func buildReviewResult(out *reviewOutput, fileName, source string) *CodeViewResult {
+	res := &CodeViewResult{Issues: make([]Issue, 0, len(out.Issues)), Source: source}
+	for _, item := range out.Issues {
+		message := strings.TrimSpace(item.Message)
+		if message == "" {
+			continue
+		}
+		file := strings.TrimSpace(item.File)
+		if file == "" {
+			file = fileName
+		}
+		res.Issues = append(res.Issues, Issue{
+			File:       file,
+			Line:       parseIssueLine(item.Line),
+			Severity:   normalizeSeverity(item.Severity),
+			Category:   normalizeCategory(item.Category),
+			Message:    message,
+			Suggestion: strings.TrimSpace(item.Suggestion),
+		})
+	}
+
+	res.Result = normalizeResult(out.Result, res.Issues)
+	res.Summary = formatReviewSummary(strings.TrimSpace(out.Summary), res.Issues)
+	return res
+}
+
+// parseReviewText 解析自由文本的审查结果，每个列表项作为一个问题
+func parseReviewText(raw, fileName string) *CodeViewResult {
+	res := &CodeViewResult{Summary: raw, Issues: []Issue{}, Source: models.IssueSourceText}
+	for _, line := range strings.Split(raw, "\n") {
+		if !issueBulletPattern.MatchString(line) {
+			continue
+		}
+		message := strings.TrimSpace(issueBulletPattern.ReplaceAllString(line, ""))
+		message = strings.Trim(message, "*` ")
+		if message == "" || strings.HasSuffix(message, "：") || strings.HasSuffix(message, ":") {
+			continue
+		}
+		issue := Issue{
+			File:     fileName,
+			Severity: guessSeverity(message),
+			Category: guessCategory(message),
+			Message:  message,
+		}
+		if m := issueLinePattern.FindStringSubmatch(message); m != nil {
+			for _, g := range m[1:] {
+				if g != "" {
+					issue.Line, _ = strconv.Atoi(g)
+					break
+				}
+			}
+		}
+		res.Issues = append(res.Issues, issue)
+	}
+
+	res.Result = normalizeResult("", res.Issues)
+	if len(res.Issues) == 0 {
+		// 没有列表项时沿用关键词判断
+		lower := strings.ToLower(raw)
+		res.Result = ReviewResultSuggest
+		if strings.Contains(lower, "通过") || strings.Contains(lower, "pass") {
+			res.Result = ReviewResultPass
+		}
+		if strings.Contains(lower, "错误") || strings.Contains(lower, "error") {
+			res.Result = ReviewResultProblem
+		}
+	}
+	return res
+}
+
+// parseIssueLine 行号兼容数字和字符串（如 "12"、"12-15"）
+func parseIssueLine(raw json.RawMessage) int {
+	var n float64
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return int(n)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		digits := strings.TrimLeft(strings.TrimSpace(s), "Ll")
+		if end := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+			digits = digits[:end]
+		}
+		line, _ := strconv.Atoi(digits)
+		return line
+	}
+	return 0
+}
+
+// normalizeResult 结论以问题的严重程度为准，模型给出的结论仅在没有问题时使用
+func normalizeResult(result string, issues []Issue) string {
+	hasWarning := false
+	for _, issue := range issues {
+		switch issue.Severity {
+		case models.IssueSeverityError:
+			return ReviewResultProblem
+		case models.IssueSeverityWarning:
+			hasWarning = true
+		}
+	}
+	if hasWarning || len(issues) > 0 {
+		return ReviewResultSuggest
+	}
+	switch strings.TrimSpace(result) {
+	case ReviewResultProblem, ReviewResultSuggest:
+		return strings.TrimSpace(result)
+	}
+	return ReviewResultPass
+}
+
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "error", "critical", "blocker", "high", "严重", "错误":
+		return models.IssueSeverityError
+	case "warning", "warn", "major", "medium", "警告":
+		return models.IssueSeverityWarning
+	}
+	return models.IssueSeverityInfo
+}
+
+func normalizeCategory(category string) string {
+	switch c := strings.ToLower(strings.TrimSpace(category)); c {
+	case models.IssueCategoryBug, models.IssueCategorySecurity, models.IssueCategoryPerformance,
+		models.IssueCategoryLogic, models.IssueCategoryStyle, models.IssueCategoryMaintainability:
+		return c
+	case "安全":
+		return models.IssueCategorySecurity
+	case "性能":
+		return models.IssueCategoryPerformance
+	case "逻辑":
+		return models.IssueCategoryLogic
+	case "规范", "风格", "code style":
+		return models.IssueCategoryStyle
+	case "可维护性", "duplication", "重复代码":
+		return models.IssueCategoryMaintainability
+	}
+	return models.IssueCategoryOther
+}
+
+// guessSeverity 按关键词推断自由文本问题的严重程度
+func guessSeverity(message string) string {
+	lower := strings.ToLower(message)
+	for _, kw := range []string{"严重", "错误", "漏洞", "崩溃", "panic", "error", "critical", "bug"} {
+		if strings.Contains(lower, kw) {
+			return models.IssueSeverityError
+		}
+	}
+	for _, kw := range []string{"警告", "风险", "可能", "潜在", "warning", "potential"} {
+		if strings.Contains(lower, kw) {
+			return models.IssueSeverityWarning
+		}
+	}
+	return models.IssueSeverityInfo
+}
+
+// guessCategory 按关键词推断自由文本问题的类别
+func guessCategory(message string) string {
+	lower := strings.ToLower(message)
+	rules := []struct {
+		category string
+		keywords []string
+	}{
+		{models.IssueCategorySecurity, []string{"安全", "漏洞", "注入", "xss", "security", "injection"}},
+		{models.IssueCategoryPerformance, []string{"性能", "performance", "内存", "memory"}},
+		{models.IssueCategoryBug, []string{"bug", "空指针", "nil", "panic", "崩溃"}},
+		{models.IssueCategoryLogic, []string{"逻辑", "logic"}},
+		{models.IssueCategoryStyle, []string{"规范", "命名", "格式", "style", "naming"}},
+		{models.IssueCategoryMaintainability, []string{"重复", "可读性", "可维护", "duplicate", "readability"}},
+	}
+	for _, rule := range rules {
+		for _, kw := range rule.keywords {
+			if strings.Contains(lower, kw) {
+				return rule.category
+			}
+		}
+	}
+	return models.IssueCategoryOther
+}
+
+// severityLabels 严重程度在通知内容中的显示名称
+var severityLabels = map[string]string{
+	models.IssueSeverityError:   "错误",
+	models.IssueSeverityWarning: "警告",
+	models.IssueSeverityInfo:    "提示",
+}
+
+// formatReviewSummary 生成审查结果的 Markdown 摘要，逐条列出问题
+func formatReviewSummary(summary string, issues []Issue) string {
+	var b strings.Builder
+	if summary != "" {
+		b.WriteString(summary)
+	} else if len(issues) == 0 {
+		b.WriteString("未发现问题")
+	}
+	for _, issue := range issues {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		location := ""
+		if issue.Line > 0 {
+			location = fmt.Sprintf("（第 %d 行）", issue.Line)
+		}
+		fmt.Fprintf(&b, "- [%s]%s %s", severityLabels[issue.Severity], location, issue.Message)
+		if issue.Suggestion != "" {
+			fmt.Fprintf(&b, "\n  建议：%s", issue.Suggestion)
+		}
+	}
+	return b.String()
+}
+
+// countIssues 按严重程度统计问题数量
+func countIssues(issues []models.ReviewIssue) (errorCount, warningCount, infoCount int) {
+	for _, issue := range issues {
+		switch issue.Severity {
+		case models.IssueSeverityError:
+			errorCount++
+		case models.IssueSeverityWarning:
+			warningCount++
+		default:
+			infoCount++
+		}
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"backend/internal/models"
+)
+
+func TestParseReviewOutputJSON(t *testing.T) {
+	raw := `{"result":"通过","summary":"发现一个空指针问题","issues":[
+		{"file":"","line":12,"severity":"critical","category":"bug","message":"user 可能为 nil","suggestion":"先判空"},
+		{"file":"util.go","line":"L30-32","severity":"warning","category":"风格","message":"命名不规范","suggestion":""},
+		{"file":"a.go","line":1,"severity":"info","category":"other","message":"  ","suggestion":"忽略空问题"}]}`
+
+	res := parseReviewOutput(raw, "main.go")
+	if res.Source != models.IssueSourceJSON {
+		t.Fatalf("source = %q, want json", res.Source)
+	}
+	if len(res.Issues) != 2 {
+		t.Fatalf("got %d issues, want 2: %+v", len(res.Issues), res.Issues)
+	}
+
+	first := res.Issues[0]
+	if first.File != "main.go" || first.Line != 12 || first.Severity != models.IssueSeverityError || first.Category != models.IssueCategoryBug {
+		t.Errorf("first issue = %+v", first)
+	}
+	second := res.Issues[1]
+	if second.File != "util.go" || second.Line != 30 || second.Severity != models.IssueSeverityWarning || second.Category != models.IssueCategoryStyle {
+		t.Errorf("second issue = %+v", second)
+	}
+	// 结论以问题的严重程度为准
+	if res.Result != ReviewResultProblem {
+		t.Errorf("result = %q, want %q", res.Result, ReviewResultProblem)
+	}
+	if !strings.HasPrefix(res.Summary, "发现一个空指针问题") || !strings.Contains(res.Summary, "第 12 行") {
+		t.Errorf("summary = %q", res.Summary)
+	}
+}
+
+func TestParseReviewOutputWrapped(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"code block", "审查结果如下：\n```json\n{\"result\":\"有建议\",\"summary\":\"s\",\"issues\":[{\"line\":3,\"severity\":\"warning\",\"message\":\"m\"}]}\n```\n以上。"},
+		{"surrounding text", "结果：{\"result\":\"有建议\",\"summary\":\"s\",\"issues\":[{\"line\":3,\"severity\":\"warning\",\"message\":\"m\"}]} 完毕"},
+		{"issues array", "[{\"line\":3,\"severity\":\"warning\",\"message\":\"m\"}]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := parseReviewOutput(tt.raw, "main.go")
+			if res.Source != models.IssueSourceJSON || len(res.Issues) != 1 {
+				t.Fatalf("source = %q, issues = %+v", res.Source, res.Issues)
+			}
+			if issue := res.Issues[0]; issue.File != "main.go" || issue.Line != 3 || issue.Severity != models.IssueSeverityWarning {
+				t.Errorf("issue = %+v", issue)
+			}
+			if res.Result != ReviewResultSuggest {
+				t.Errorf("result = %q, want %q", res.Result, ReviewResultSuggest)
+			}
+		})
+	}
+}
+
+func TestParseReviewOutputPass(t *testing.T) {
+	res := parseReviewOutput(`{"result":"通过","summary":"","issues":[]}`, "main.go")
+	if res.Result != ReviewResultPass || len(res.Issues) != 0 || res.Summary != "未发现问题" {
+		t.Errorf("result = %+v", res)
+	}
+}
+
+func TestParseReviewOutputText(t *testing.T) {
+	raw := "发现以下问题：\n" +
+		"1. 第 8 行存在 SQL 注入漏洞\n" +
+		"- 变量命名不规范\n" +
+		"普通说明文字\n" +
+		"* 可能的性能问题 line 20"
+
+	res := parseReviewOutput(raw, "db.go")
+	if res.Source != models.IssueSourceText {
+		t.Fatalf("source = %q, want text", res.Source)
+	}
+	if len(res.Issues) != 3 {
+		t.Fatalf("got %d issues, want 3: %+v", len(res.Issues), res.Issues)
+	}
+	want := []struct {
+		line     int
+		severity string
+		category string
+	}{
+		{8, models.IssueSeverityError, models.IssueCategorySecurity},
+		{0, models.IssueSeverityInfo, models.IssueCategoryStyle},
+		{20, models.IssueSeverityWarning, models.IssueCategoryPerformance},
+	}
+	for i, w := range want {
+		issue := res.Issues[i]
+		if issue.File != "db.go" || issue.Line != w.line || issue.Severity != w.severity || issue.Category != w.category {
+			t.Errorf("issue %d = %+v, want line %d %s/%s", i, issue, w.line, w.severity, w.category)
+		}
+	}
+	if res.Result != ReviewResultProblem {
+		t.Errorf("result = %q, want %q", res.Result, ReviewResultProblem)
+	}
+}
+
+func TestParseReviewOutputTextWithoutIssues(t *testing.T) {
+	if res := parseReviewOutput("代码审查通过，没有发现问题。", "a.go"); res.Result != ReviewResultPass {
+		t.Errorf("result = %q, want %q", res.Result, ReviewResultPass)
+	}
+}
@@ -1,16 +1,13 @@
 package services
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
-	"net/http"
-	"strings"
 	"time"
 
 	"backend/internal/models"
 	"backend/internal/repository"
-	"backend/pkg/dingtalk"
+	"backend/pkg/notifier"
 	"backend/utils/logger"
 
 	"gorm.io/gorm"
@@ -20,6 +17,8 @@ var (
 	ErrTargetNotFound       = errors.New("推送目标不存在")
 	ErrTargetAlreadyExists  = errors.New("推送目标名称已存在")
 	ErrInvalidDingTalkToken = errors.New("无效的钉钉AccessToken")
+	ErrInvalidDeliveryMode  = errors.New("无效的投递方式")
+	ErrInvalidDigestAt      = errors.New("摘要发送时间格式应为 HH:MM")
 )
 
 type TargetService struct {
@@ -45,22 +44,16 @@ func (s *TargetService) Create(data map[string]interface{}) (*models.Target, err
 		return nil, err
 	}
 
-	// 验证配置有效性
-	if targetType == models.TargetTypeDingTalk {
-		if err := validateDingTalkConfig(string(configStr)); err != nil {
-			return nil, err
-		}
-	} else if targetType == models.TargetTypeWebhook {
-		if err := validateWebhookConfig(string(configStr)); err != nil {
-			return nil, err
-		}
-	}
-
 	cfg := &models.Config{}
 	if err := json.Unmarshal(configStr, cfg); err != nil {
 		return nil, err
 	}
 
+	// 验证配置有效性
+	if err := validateTargetConfig(targetType, cfg); err != nil {
+		return nil, err
+	}
+
 	target := &models.Target{
 		Name:   name,
 		Type:   targetType,
@@ -68,6 +61,16 @@ func (s *TargetService) Create(data map[string]interface{}) (*models.Target, err
 		Scope:  getStringWithDefault(data, "scope", models.TargetScopeGlobal),
 		Status: models.StatusActive,
 	}
+	if rateLimit, ok := data["rate_limit"].(float64); ok && rateLimit > 0 {
+		target.RateLimit = int(rateLimit)
+	}
+	if err := applyDeliveryOptions(target, data); err != nil {
+		return nil, err
+	}
+	if err := applyQuietHours(target, data); err != nil {
+		return nil, err
+	}
+	applyCircuitBreaker(target, data)
 
 	if err := s.targetRepo.Create(target); err != nil {
 		return nil, err
@@ -120,16 +123,38 @@ func (s *TargetService) Update(id uint, data map[string]interface{}) error {
 		if err := json.Unmarshal(configStr, cfg); err != nil {
 			return err
 		}
+		if err := validateTargetConfig(target.Type, cfg); err != nil {
+			return err
+		}
 		target.Config = cfg
 	}
 	if scope, ok := data["scope"].(string); ok && scope != "" {
 		target.Scope = scope
 	}
-	if status, ok := data["status"].(string); ok && status != "" {
+	// 编辑表单通常原样带回读取时的状态，只有状态发生变化时才写入
+	status, _ := data["status"].(string)
+	setStatus := status != "" && status != target.Status
+	if setStatus {
+		// 手动恢复已暂停的目标时清除暂停状态
+		if target.Status == models.TargetStatusPaused && status == models.StatusActive {
+			target.PausedAt = nil
+			s.targetRepo.RecordSuccess(target.ID)
+		}
 		target.Status = status
 	}
+	if rateLimit, ok := data["rate_limit"].(float64); ok && rateLimit >= 0 {
+		target.RateLimit = int(rateLimit)
+	}
+	if err := applyDeliveryOptions(target, data); err != nil {
+		return err
+	}
+	if err := applyQuietHours(target, data); err != nil {
+		return err
+	}
+	applyCircuitBreaker(target, data)
 
-	return s.targetRepo.Update(target)
+	// 未修改状态时不写入状态，保留编辑期间可能发生的自动暂停
+	return s.targetRepo.Update(target, setStatus)
 }
 
 // Delete 删除推送目标
@@ -144,18 +169,15 @@ func (s *TargetService) Test(id uint) (map[string]interface{}, error) {
 		return nil, err
 	}
 
-	var result map[string]interface{}
-	var sendErr error
-
-	switch target.Type {
-	case models.TargetTypeDingTalk:
-		result, sendErr = s.testDingTalk(target)
-	case models.TargetTypeWebhook:
-		result, sendErr = s.testWebhook(target)
-	default:
-		return nil, errors.New("不支持的推送类型: " + target.Type)
+	n, err := notifier.Get(target.Type)
+	if err != nil {
+		return nil, err
+	}
+	if target.Config == nil {
+		return nil, errors.New(n.Name() + "配置无效")
 	}
 
+	result, sendErr := n.Test(notifier.Config(target.Config.ToMap()), target.Name)
 	if sendErr != nil {
 		logger.Error("Target test failed", map[string]interface{}{
 			"target_id": id,
@@ -172,106 +194,16 @@ func (s *TargetService) Test(id uint) (map[string]interface{}, error) {
 		"type":      target.Type,
 	})
 
-	return result, nil
-}
-
-// testDingTalk 测试钉钉通知
-func (s *TargetService) testDingTalk(target *models.Target) (map[string]interface{}, error) {
-	if target.Config == nil {
-		return nil, errors.New("钉钉配置无效")
-	}
-
-	accessToken := target.Config.AccessToken
-	secret := target.Config.Secret
-
-	if accessToken == "" {
-		return nil, errors.New("access_token不能为空")
-	}
-
-	client := dingtalk.NewClient(accessToken, secret)
-
-	// 构建测试消息
-	content := `## 代码提交通知
-
-**这是一条测试消息**
-
-- 推送目标: ` + target.Name + `
-- 测试时间: ` + time.Now().Format("2006-01-02 15:04:05") + `
-- 状态: 正常
-
-如果收到此消息，说明钉钉配置正确。`
-
-	title := "推送通知测试"
-	if err := client.SendMarkdown(target.Config.WebhookURL, title, content); err != nil {
-		return nil, errors.New("发送失败: " + err.Error())
-	}
-
-	return map[string]interface{}{
-		"status":  "success",
-		"message": "测试消息已发送",
-		"type":    "dingtalk",
-	}, nil
-}
-
-// testWebhook 测试Webhook通知
-func (s *TargetService) testWebhook(target *models.Target) (map[string]interface{}, error) {
-	if target.Config == nil {
-		return nil, errors.New("Webhook配置无效")
-	}
-
-	webhookURL := target.Config.WebhookURL
-	if webhookURL == "" {
-		return nil, errors.New("webhook_url不能为空")
-	}
-
-	// 构建测试消息
-	testPayload := map[string]interface{}{
-		"event":       "test",
-		"message":     "这是一条测试消息",
-		"target_name": target.Name,
-		"timestamp":   time.Now().Format(time.RFC3339),
-	}
-
-	body, _ := json.Marshal(testPayload)
-
-	method := "POST"
-	if target.Config.Method != "" {
-		method = target.Config.Method
-	}
-
-	req, err := http.NewRequest(method, webhookURL, bytes.NewReader(body))
-	if err != nil {
-		return nil, errors.New("创建请求失败: " + err.Error())
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	for key, value := range target.Config.Headers {
-		req.Header.Set(key, value)
-	}
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, errors.New("请求失败: " + err.Error())
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return nil, errors.New("返回状态码: " + resp.Status)
+	// 测试发送成功说明配置已修复，自动恢复被暂停的目标
+	if resumed, err := s.targetRepo.Resume(id); err == nil && resumed {
+		result["resumed"] = true
+		logger.Info("Target resumed", map[string]interface{}{
+			"target_id": id,
+			"name":      target.Name,
+		})
 	}
 
-	// 读取响应内容（限制1000字符）
-	respBody := make([]byte, 1000)
-	n, _ := resp.Body.Read(respBody)
-	respStr := strings.TrimSpace(string(respBody[:n]))
-
-	return map[string]interface{}{
-		"status":      "success",
-		"message":     "测试消息已发送",
-		"type":        "webhook",
-		"status_code": resp.StatusCode,
-		"response":    respStr,
-	}, nil
+	return result, nil
 }
 
 // AddRepo 关联仓库
@@ -294,24 +226,81 @@ func (s *TargetService) GetByScope(repoID uint) ([]models.Target, error) {
 	return s.targetRepo.GetByScopeAndRepo(repoID)
 }
 
-func validateDingTalkConfig(configStr string) error {
-	var config map[string]interface{}
-	if err := json.Unmarshal([]byte(configStr), &config); err != nil {
-		return ErrInvalidDingTalkToken
+// Types 获取支持的推送渠道及其配置项
+func (s *TargetService) Types() []notifier.Info {
+	return notifier.List()
+}
+
+// applyDeliveryOptions 解析投递方式（立即/摘要）及摘要周期
+func applyDeliveryOptions(target *models.Target, data map[string]interface{}) error {
+	if mode, ok := data["delivery_mode"].(string); ok && mode != "" {
+		if mode != models.DeliveryModeImmediate && mode != models.DeliveryModeDigest {
+			return ErrInvalidDeliveryMode
+		}
+		target.DeliveryMode = mode
+	}
+	if interval, ok := data["digest_interval"].(float64); ok && interval >= 0 {
+		target.DigestInterval = int(interval)
+	}
+	if digestAt, ok := data["digest_at"].(string); ok {
+		if digestAt != "" {
+			if _, err := time.Parse("15:04", digestAt); err != nil {
+				return ErrInvalidDigestAt
+			}
+		}
+		target.DigestAt = digestAt
 	}
-	if _, ok := config["access_token"]; !ok {
-		return ErrInvalidDingTalkToken
+	if maxAttempts, ok := data["max_attempts"].(float64); ok && maxAttempts >= 0 {
+		target.MaxAttempts = int(maxAttempts)
 	}
 	return nil
 }
 
-func validateWebhookConfig(configStr string) error {
-	var config map[string]interface{}
-	if err := json.Unmarshal([]byte(configStr), &config); err != nil {
-		return errors.New("无效的Webhook配置")
+// applyQuietHours 解析免打扰配置，传 null 表示清除
+func applyQuietHours(target *models.Target, data map[string]interface{}) error {
+	raw, ok := data["quiet_hours"]
+	if !ok {
+		return nil
+	}
+	if raw == nil {
+		target.QuietHours = nil
+		return nil
+	}
+
+	qhStr, err := json.Marshal(raw)
+	if err != nil {
+		return err
 	}
-	if _, ok := config["webhook_url"]; !ok || config["webhook_url"] == "" {
-		return errors.New("Webhook URL不能为空")
+	qh := &models.QuietHours{}
+	if err := json.Unmarshal(qhStr, qh); err != nil {
+		return err
 	}
+	if err := qh.Validate(); err != nil {
+		return err
+	}
+	target.QuietHours = qh
 	return nil
 }
+
+// applyCircuitBreaker 解析熔断阈值和告警目标，alert_target_id 传 null 或 0 表示使用全局配置
+func applyCircuitBreaker(target *models.Target, data map[string]interface{}) {
+	if threshold, ok := data["failure_threshold"].(float64); ok && threshold >= 0 {
+		target.FailureThreshold = int(threshold)
+	}
+	if raw, ok := data["alert_target_id"]; ok {
+		target.AlertTargetID = nil
+		if id, ok := raw.(float64); ok && id > 0 {
+			alertID := uint(id)
+			target.AlertTargetID = &alertID
+		}
+	}
+}
+
+// validateTargetConfig 使用推送渠道校验配置
+func validateTargetConfig(targetType string, cfg *models.Config) error {
+	n, err := notifier.Get(targetType)
+	if err != nil {
+		return err
+	}
+	return n.Validate(notifier.Config(cfg.ToMap()))
+}
@@ -22,6 +22,8 @@ type UnifiedPushPayload struct {
 	FileList     []string        // Simple list of changed files
 	Commits      []UnifiedCommit // For listing in message
 	TotalCommits int
+	Forced       bool   // 强制推送，GitLab 的 Push Hook 不提供该字段
+	DeliveryID   string // Git 平台的投递ID，平台重新投递同一事件时不变
 }
 
 // UnifiedCommit 统一的提交信息
@@ -33,18 +35,40 @@ type UnifiedCommit struct {
 
 // WebhookProvider Webhook提供者接口
 type WebhookProvider interface {
+	Name() string
 	GetEventType(header http.Header) string
+	GetDeliveryID(header http.Header) string
 	ParsePushPayload(body []byte) (*UnifiedPushPayload, error)
 	BuildMessage(payload *UnifiedPushPayload, template *models.Template) string
 }
 
+// providerByName 根据名称获取提供者，用于从持久化任务中恢复
+func providerByName(name string) WebhookProvider {
+	switch name {
+	case "github":
+		return &GitHubProvider{}
+	case "gitlab":
+		return &GitLabProvider{}
+	default:
+		return nil
+	}
+}
+
 // GitHubProvider GitHub实现
 type GitHubProvider struct{}
 
+func (p *GitHubProvider) Name() string {
+	return "github"
+}
+
 func (p *GitHubProvider) GetEventType(header http.Header) string {
 	return header.Get("X-GitHub-Event")
 }
 
+func (p *GitHubProvider) GetDeliveryID(header http.Header) string {
+	return header.Get("X-GitHub-Delivery")
+}
+
 func (p *GitHubProvider) ParsePushPayload(body []byte) (*UnifiedPushPayload, error) {
 	var payload WebhookPayload
 	if err := json.Unmarshal(body, &payload); err != nil {
@@ -76,6 +100,7 @@ func (p *GitHubProvider) ParsePushPayload(body []byte) (*UnifiedPushPayload, err
 		FileList:     allFiles,
 		Commits:      commits,
 		TotalCommits: len(payload.Commits), // GitHub payload usually contains new commits
+		Forced:       payload.Forced,
 	}, nil
 }
 
@@ -112,10 +137,18 @@ func (p *GitHubProvider) BuildMessage(payload *UnifiedPushPayload, template *mod
 // GitLabProvider GitLab实现
 type GitLabProvider struct{}
 
+func (p *GitLabProvider) Name() string {
+	return "gitlab"
+}
+
 func (p *GitLabProvider) GetEventType(header http.Header) string {
 	return header.Get("X-Gitlab-Event")
 }
 
+func (p *GitLabProvider) GetDeliveryID(header http.Header) string {
+	return header.Get("X-Gitlab-Event-UUID")
+}
+
 func (p *GitLabProvider) ParsePushPayload(body []byte) (*UnifiedPushPayload, error) {
 	var payload GitLabPayload
 	if err := json.Unmarshal(body, &payload); err != nil {
@@ -262,6 +295,7 @@ type WebhookPayload struct {
 	Sender     Sender     `json:"sender"`
 	Commits    []Commit   `json:"commits"`
 	HeadCommit Commit     `json:"head_commit"`
+	Forced     bool       `json:"forced"`
 }
 
 // GitLabPayload GitLab Webhook负载
@@ -1,20 +1,21 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"backend/internal/models"
 	"backend/internal/repository"
-	"backend/pkg/dingtalk"
 	"backend/pkg/git"
 	"backend/utils/logger"
 
 	"net/http"
-	"net/url"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -25,34 +26,118 @@ type WebhookService struct {
 	repoRepo     *repository.RepoRepo
 	targetRepo   *repository.TargetRepo
 	pushRepo     *repository.PushRepo
+	issueRepo    *repository.ReviewIssueRepo
+	fileRepo     *repository.ReviewFileRepo
 	templateRepo *repository.TemplateRepo
 	promptRepo   *repository.PromptRepo
 	modelRepo    *repository.AIModelRepo
 	codeviewServ *CodeViewService
+	commentServ  *ReviewCommentService
+	statusServ   *CommitStatusService
+	deliveryServ *DeliveryService
+	pushServ     *PushService
+	quietServ    *QuietHoursService
 	codeReviewQ  *CodeReviewQueue
 	pushNotifyQ  *PushNotifyQueue
 	baseURL      string
+	reviewOpts   ReviewOptions
 }
 
-func NewWebhookService(db *gorm.DB, baseURL string) *WebhookService {
+// ReviewOptions 代码审查的选项：获取提交差异的方式和审查结果缓存
+type ReviewOptions struct {
+	ContextLines int              // 统一差异中变更前后保留的上下文行数
+	Mirror       *git.MirrorCache // 仓库镜像缓存，为空时每次克隆到内存
+	// KnownHostsFile 全局 known_hosts 文件，与仓库配置的主机公钥一起校验 SSH 仓库，为空时使用 ~/.ssh/known_hosts
+	KnownHostsFile string
+	// CacheTTL 审查结果缓存的有效期，为 0 时不缓存
+	CacheTTL time.Duration
+}
+
+func NewWebhookService(db *gorm.DB, baseURL string, deliveryServ *DeliveryService, pushServ *PushService, quietServ *QuietHoursService, reviewOpts ReviewOptions, queueOpts QueueOptions) *WebhookService {
 	s := &WebhookService{
 		db:           db,
 		repoRepo:     repository.NewRepoRepo(db),
 		targetRepo:   repository.NewTargetRepo(db),
 		pushRepo:     repository.NewPushRepo(db),
+		issueRepo:    repository.NewReviewIssueRepo(db),
+		fileRepo:     repository.NewReviewFileRepo(db),
 		templateRepo: repository.NewTemplateRepo(db),
 		promptRepo:   repository.NewPromptRepo(db),
 		modelRepo:    repository.NewAIModelRepo(db),
-		codeviewServ: NewCodeViewService(db),
+		codeviewServ: NewCodeViewService(db).WithCache(reviewOpts.CacheTTL),
+		commentServ:  NewReviewCommentService(db, baseURL),
+		statusServ:   NewCommitStatusService(baseURL),
+		deliveryServ: deliveryServ,
+		pushServ:     pushServ,
+		quietServ:    quietServ,
 		baseURL:      baseURL,
+		reviewOpts:   reviewOpts,
 	}
-	s.codeReviewQ = NewCodeReviewQueue(200, 2, s.processCodeReviewJob)
-	s.pushNotifyQ = NewPushNotifyQueue(500, 5, s.processPushNotifyJob)
+	s.codeReviewQ = NewCodeReviewQueue(db, queueOpts, s.processCodeReviewJob)
+	s.codeReviewQ.OnCancel(s.onCodeReviewCanceled)
+	s.pushNotifyQ = NewPushNotifyQueue(db, queueOpts, s.processPushNotifyJob)
 	return s
 }
 
-func (s *WebhookService) processPushNotifyJob(job PushNotifyJob) {
-	s.sendUnifiedPushNotification(job.Repo, job.Target, job.Payload, job.Template, job.Provider)
+// Start 启动推送和审查队列
+func (s *WebhookService) Start() {
+	s.pushNotifyQ.Start()
+	s.codeReviewQ.Start()
+}
+
+// Queues Webhook服务使用的任务队列
+func (s *WebhookService) Queues() []*JobQueue {
+	return []*JobQueue{s.pushNotifyQ.JobQueue, s.codeReviewQ.JobQueue}
+}
+
+// onCodeReviewCanceled 审查任务被取消时，待审查的推送记录标记为已跳过
+func (s *WebhookService) onCodeReviewCanceled(job *models.Job) {
+	var payload CodeReviewJob
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return
+	}
+	resultText := "审查任务已取消"
+	s.pushRepo.UpdateCodeview(payload.RepoID, payload.CommitID, models.CodeviewStatusSkipped, &resultText)
+	if repo, err := s.repoRepo.GetByID(payload.RepoID); err == nil {
+		s.statusServ.Error(repo, payload.PushID, payload.CommitID, resultText)
+	}
+}
+
+// Shutdown 停止推送和审查队列，ctx 结束前未完成的任务重新排队
+func (s *WebhookService) Shutdown(ctx context.Context) error {
+	errCh := make(chan error, 2)
+	go func() { errCh <- s.pushNotifyQ.Shutdown(ctx) }()
+	go func() { errCh <- s.codeReviewQ.Shutdown(ctx) }()
+
+	var err error
+	for i := 0; i < 2; i++ {
+		if e := <-errCh; e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// processPushNotifyJob 执行推送任务，重新加载任务引用的仓库、推送目标和模板
+func (s *WebhookService) processPushNotifyJob(job PushNotifyJob) error {
+	repo, err := s.repoRepo.GetByID(job.RepoID)
+	if err != nil {
+		return fmt.Errorf("获取仓库失败: %w", err)
+	}
+	target, err := s.targetRepo.GetByID(job.TargetID)
+	if err != nil {
+		return fmt.Errorf("获取推送目标失败: %w", err)
+	}
+	var template *models.Template
+	if job.TemplateID != nil {
+		template, _ = s.templateRepo.GetByID(*job.TemplateID)
+	}
+	provider := providerByName(job.Provider)
+	if provider == nil {
+		return fmt.Errorf("未知的Webhook提供者: %s", job.Provider)
+	}
+
+	return s.sendUnifiedPushNotification(repo, target, job.Payload, template, provider, job.JobID)
 }
 
 // HandleGitHubWebhook 处理GitHub Webhook
@@ -103,7 +188,15 @@ func (s *WebhookService) handleWebhook(c *gin.Context, provider WebhookProvider)
 			})
 			return
 		}
-		s.dispatchPushNotification(repo, payload, provider)
+		payload.DeliveryID = provider.GetDeliveryID(c.Request.Header)
+		if err := s.dispatchPushNotification(repo, payload, provider); err != nil {
+			// 任务未能落库，返回错误让 Git 平台重新投递
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "推送任务入队失败",
+			})
+			return
+		}
 	} else if eventType == "ping" || eventType == "Event Hook" {
 		c.JSON(http.StatusOK, gin.H{"message": "pong"})
 		return
@@ -124,22 +217,22 @@ func (s *WebhookService) handleWebhook(c *gin.Context, provider WebhookProvider)
 	})
 }
 
-// dispatchPushNotification 分发推送通知
-func (s *WebhookService) dispatchPushNotification(repo *models.Repo, payload *UnifiedPushPayload, provider WebhookProvider) {
+// dispatchPushNotification 分发推送通知，任务落库失败时返回错误
+func (s *WebhookService) dispatchPushNotification(repo *models.Repo, payload *UnifiedPushPayload, provider WebhookProvider) error {
 	// 获取推送目标
 	targets, err := s.targetRepo.GetByScopeAndRepo(repo.ID)
 	if err != nil {
 		logger.Error("Failed to get targets", map[string]interface{}{
 			"error": err.Error(),
 		})
-		return
+		return err
 	}
 
 	if len(targets) == 0 {
 		logger.Info("No targets configured", map[string]interface{}{
 			"repo_id": repo.ID,
 		})
-		return
+		return nil
 	}
 
 	// 获取默认模板
@@ -150,29 +243,62 @@ func (s *WebhookService) dispatchPushNotification(repo *models.Repo, payload *Un
 		template, _ = s.templateRepo.GetByTypeAndScene(models.TemplateTypeDingTalk, models.TemplateSceneCommitNotify)
 	}
 
-	// 为每个推送目标发送通知
+	// 为每个推送目标创建推送任务
+	priority := jobPriority(repo, payload)
 	for _, target := range targets {
-		t := target // 局部变量，防止闭包问题
-		s.pushNotifyQ.Enqueue(PushNotifyJob{
-			Repo:     repo,
-			Target:   &t,
+		job := PushNotifyJob{
+			RepoID:   repo.ID,
+			TargetID: target.ID,
+			Provider: provider.Name(),
 			Payload:  payload,
-			Template: template,
-			Provider: provider,
-		})
+			DedupKey: pushDedupKey(repo, payload),
+			Priority: priority,
+		}
+		if template != nil {
+			job.TemplateID = &template.ID
+		}
+		if _, err := s.pushNotifyQ.Enqueue(job); err != nil {
+			logger.Error("Failed to enqueue push job", map[string]interface{}{
+				"repo_id":   repo.ID,
+				"target_id": target.ID,
+				"error":     err.Error(),
+			})
+			return err
+		}
 	}
+	return nil
 }
 
-// sendUnifiedPushNotification 发送统一推送通知
-func (s *WebhookService) sendUnifiedPushNotification(repo *models.Repo, target *models.Target, payload *UnifiedPushPayload, template *models.Template, provider WebhookProvider) {
-	// 去重检查：同一提交同一目标不重复推送
-	if s.pushRepo.ExistsByCommitAndTarget(payload.After, target.ID) {
-		logger.Info("Duplicate push detected, skipping", map[string]interface{}{
-			"commit_id": payload.After[:7],
+// sendUnifiedPushNotification 发送统一推送通知，jobID 为执行的推送任务，用于任务重试时接续已创建的推送记录
+func (s *WebhookService) sendUnifiedPushNotification(repo *models.Repo, target *models.Target, payload *UnifiedPushPayload, template *models.Template, provider WebhookProvider, jobID uint) error {
+	// 去重检查：任务重试（如上次审查任务入队失败）时接续本任务创建的记录，不论仓库是否去重都不重复推送；
+	// 否则按仓库的去重策略，同一去重键同一目标不重复推送
+	// 仍为 pending 的记录说明上次执行在发送前中断（如进程重启），继续发送
+	dedupKey := pushDedupKey(repo, payload)
+	existing := s.findJobPush(target, jobID)
+	if existing == nil {
+		existing = s.findDuplicatePush(repo, target, payload, dedupKey)
+	}
+	if existing != nil {
+		if existing.Status != models.PushStatusPending {
+			logger.Info("Duplicate push detected, skipping", map[string]interface{}{
+				"commit_id":    shortCommit(payload.After),
+				"target_id":    target.ID,
+				"repo_name":    repo.Name,
+				"dedup_policy": repo.DedupPolicy,
+				"duplicate_of": existing.ID,
+			})
+			// 上次执行可能在审查任务入队前中断，补充入队（审查队列自带去重）
+			if existing.Status != models.PushStatusFailed && existing.CodeviewStatus == models.CodeviewStatusPending {
+				return s.enqueueCodeReview(existing, repo, payload)
+			}
+			return nil
+		}
+		logger.Info("Resuming interrupted push", map[string]interface{}{
+			"push_id":   existing.ID,
 			"target_id": target.ID,
-			"repo_name": repo.Name,
 		})
-		return
+		return s.finishPush(existing, repo, target, payload, existing.Content)
 	}
 
 	// 构建消息内容
@@ -180,74 +306,174 @@ func (s *WebhookService) sendUnifiedPushNotification(repo *models.Repo, target *
 
 	// 创建推送记录
 	push := &models.Push{
-		RepoID:    repo.ID,
-		TargetID:  target.ID,
-		CommitID:  payload.After,
-		CommitMsg: payload.CommitMsg,
-		Status:    models.PushStatusPending,
-		Content:   content,
+		RepoID:     repo.ID,
+		TargetID:   target.ID,
+		CommitID:   payload.After,
+		CommitMsg:  payload.CommitMsg,
+		Branch:     payload.Branch,
+		DeliveryID: payload.DeliveryID,
+		DedupKey:   dedupKey,
+		Status:     models.PushStatusPending,
+		Content:    content,
 	}
 
 	if template != nil {
 		push.TemplateID = &template.ID
 	}
+	if jobID != 0 {
+		push.JobID = &jobID
+	}
 
 	if err := s.pushRepo.Create(push); err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint failed") || strings.Contains(err.Error(), "Duplicate entry") {
-			logger.Info("Duplicate push detected (DB constraint), skipping", map[string]interface{}{
-				"commit_id": payload.After[:7],
-				"target_id": target.ID,
-				"repo_name": repo.Name,
-			})
-			return
-		}
 		logger.Error("Failed to create push record", map[string]interface{}{
 			"error": err.Error(),
 		})
-		return
+		return err
 	}
 
-	// 发送通知
-	var err error
-	err = s.sendDingTalk(target, content)
+	return s.finishPush(push, repo, target, payload, content)
+}
 
-	// 更新推送状态
+// findJobPush 查找推送任务上次执行时创建的推送记录，没有时返回 nil
+func (s *WebhookService) findJobPush(target *models.Target, jobID uint) *models.Push {
+	if jobID == 0 {
+		return nil
+	}
+	existing, err := s.pushRepo.GetByJobID(target.ID, jobID)
 	if err != nil {
-		push.Status = models.PushStatusFailed
-		push.ErrorMsg = err.Error()
-		logger.Error("Push failed", map[string]interface{}{
+		return nil
+	}
+	return existing
+}
+
+// findDuplicatePush 按仓库的去重策略查找已有的推送记录，没有时返回 nil
+// 不去重时只接续同一次投递中被中断的 pending 记录；设置了时间窗口时只与窗口内的记录去重。
+func (s *WebhookService) findDuplicatePush(repo *models.Repo, target *models.Target, payload *UnifiedPushPayload, dedupKey string) *models.Push {
+	if dedupKey == "" {
+		if payload.DeliveryID == "" {
+			return nil
+		}
+		existing, err := s.pushRepo.GetPendingByDeliveryID(target.ID, payload.DeliveryID)
+		if err != nil {
+			return nil
+		}
+		return existing
+	}
+
+	var since *time.Time
+	if repo.DedupWindow > 0 {
+		t := time.Now().Add(-time.Duration(repo.DedupWindow) * time.Minute)
+		since = &t
+	}
+	existing, err := s.pushRepo.GetByDedupKey(target.ID, dedupKey, since)
+	if err != nil {
+		return nil
+	}
+	return existing
+}
+
+// pushDedupKey 按仓库的去重策略生成推送去重键，不去重时返回空
+// 按投递去重但平台未提供投递ID时，退化为按提交+分支去重。
+func pushDedupKey(repo *models.Repo, payload *UnifiedPushPayload) string {
+	switch repo.DedupPolicy {
+	case models.DedupPolicyNone:
+		return ""
+	case models.DedupPolicyCommitBranch:
+		return payload.After + "@" + payload.Branch
+	case models.DedupPolicyDelivery:
+		if payload.DeliveryID != "" {
+			return "delivery:" + payload.DeliveryID
+		}
+		return payload.After + "@" + payload.Branch
+	default:
+		return payload.After
+	}
+}
+
+// shortCommit 提交ID的短格式
+func shortCommit(commitID string) string {
+	if len(commitID) > 7 {
+		return commitID[:7]
+	}
+	return commitID
+}
+
+// finishPush 按推送目标的投递方式发送或暂存推送，并触发代码审查
+func (s *WebhookService) finishPush(push *models.Push, repo *models.Repo, target *models.Target, payload *UnifiedPushPayload, content string) error {
+	// 摘要模式：只记录，等待摘要统一发送
+	if target.DeliveryMode == models.DeliveryModeDigest {
+		push.Status = models.PushStatusDigest
+		push.DeliveredVia = models.DeliveredViaDigest
+		s.pushRepo.Update(push)
+		logger.Info("Push queued for digest", map[string]interface{}{
 			"push_id":   push.ID,
 			"target_id": target.ID,
-			"error":     err.Error(),
 		})
+	} else if s.quietServ.IsQuiet(target, time.Now()) && !s.quietServ.IsUrgentPush(target, payload) {
+		s.holdPush(push, target, content)
 	} else {
-		push.Status = models.PushStatusSuccess
-		logger.Info("Push succeeded", map[string]interface{}{
+		s.deliverPush(push, target)
+	}
+
+	// 执行代码审查 (异步)
+	if push.Status != models.PushStatusFailed {
+		return s.enqueueCodeReview(push, repo, payload)
+	}
+	return nil
+}
+
+// enqueueCodeReview 为配置了模型的仓库创建代码审查任务
+// 提交状态在入队前同步设为 pending：审查任务（包括已在队列中的同一提交的任务）在此之后才会设置最终状态，
+// 不会被 pending 覆盖。
+func (s *WebhookService) enqueueCodeReview(push *models.Push, repo *models.Repo, payload *UnifiedPushPayload) error {
+	if repo.ModelID == nil {
+		return nil
+	}
+	s.statusServ.Pending(repo, push.ID, payload.After)
+	_, err := s.codeReviewQ.Enqueue(CodeReviewJob{
+		RepoID:   repo.ID,
+		PushID:   push.ID,
+		CommitID: payload.After,
+		Branch:   payload.Branch,
+		Priority: jobPriority(repo, payload),
+	})
+	if err != nil {
+		logger.Error("Failed to enqueue code review job", map[string]interface{}{
+			"push_id": push.ID,
+			"error":   err.Error(),
+		})
+		return err
+	}
+	return nil
+}
+
+// holdPush 免打扰期间暂存推送，暂存失败时直接发送
+func (s *WebhookService) holdPush(push *models.Push, target *models.Target, content string) {
+	if err := s.quietServ.Hold(target, models.HeldKindPush, &push.ID, "代码提交通知", content); err != nil {
+		logger.Error("Failed to hold push", map[string]interface{}{
 			"push_id":   push.ID,
 			"target_id": target.ID,
+			"error":     err.Error(),
 		})
+		s.deliverPush(push, target)
+		return
 	}
-
+	push.Status = models.PushStatusHeld
 	s.pushRepo.Update(push)
+}
 
-	// 执行代码审查 (异步)
-	if push.Status == models.PushStatusSuccess && repo.ModelID != nil {
-		s.codeReviewQ.Enqueue(CodeReviewJob{
-			RepoID:   repo.ID,
-			PushID:   push.ID,
-			CommitID: payload.After,
-			Branch:   payload.Branch,
-		})
-	}
+// deliverPush 立即发送推送，临时错误由推送服务安排自动重试
+func (s *WebhookService) deliverPush(push *models.Push, target *models.Target) {
+	s.pushServ.Deliver(push, target)
 }
 
-func (s *WebhookService) processCodeReviewJob(job CodeReviewJob) {
+func (s *WebhookService) processCodeReviewJob(job CodeReviewJob) error {
 	repo, err := s.repoRepo.GetByID(job.RepoID)
 	if err != nil {
 		logger.Error("Repo not found for codeview", map[string]interface{}{
 			"repo_id": job.RepoID,
 		})
-		return
+		return err
 	}
 
 	push, err := s.pushRepo.GetByID(job.PushID)
@@ -255,7 +481,7 @@ func (s *WebhookService) processCodeReviewJob(job CodeReviewJob) {
 		logger.Error("Push not found for codeview", map[string]interface{}{
 			"push_id": job.PushID,
 		})
-		return
+		return err
 	}
 
 	logger.Info("Starting code review", map[string]interface{}{
@@ -265,20 +491,8 @@ func (s *WebhookService) processCodeReviewJob(job CodeReviewJob) {
 
 	s.pushRepo.UpdateCodeview(repo.ID, job.CommitID, models.CodeviewStatusPending, nil)
 
-	// 默认使用 go-git
-	gitClient := git.NewGoGitClient(repo.URL, repo.AccessToken)
-	if gitClient == nil {
-		logger.Warn("Unsupported repo type for codeview", map[string]interface{}{
-			"repo_id": repo.ID,
-			"type":    repo.Type,
-		})
-		resultText := "不支持的仓库类型，已跳过"
-		s.pushRepo.UpdateCodeview(repo.ID, job.CommitID, models.CodeviewStatusSkipped, &resultText)
-		return
-	}
-
 	// 获取差异文件
-	files, err := gitClient.GetSingleCommitDiff(job.CommitID)
+	diff, err := s.getCommitDiff(repo, job.Branch, job.CommitID)
 	if err != nil {
 		logger.Error("Failed to get diff", map[string]interface{}{
 			"repo_id":   repo.ID,
@@ -287,7 +501,21 @@ func (s *WebhookService) processCodeReviewJob(job CodeReviewJob) {
 		})
 		resultText := "获取差异失败: " + err.Error()
 		s.pushRepo.UpdateCodeview(repo.ID, job.CommitID, models.CodeviewStatusFailed, &resultText)
-		return
+		s.statusServ.Error(repo, push.ID, job.CommitID, "获取差异失败")
+		return nil
+	}
+	logger.Info("Got commit diff", map[string]interface{}{
+		"repo_id":   repo.ID,
+		"commit_id": job.CommitID,
+		"source":    diff.Source,
+		"files":     len(diff.Files),
+	})
+	files := diff.Files
+
+	// 按仓库的上下文预算为审查附带完整文件
+	contentClient, _ := diff.Client.(git.ContentClient)
+	if repo.ContextBudget <= 0 {
+		contentClient = nil
 	}
 
 	// 过滤需要审查的文件 (代码文件)
@@ -299,18 +527,24 @@ func (s *WebhookService) processCodeReviewJob(job CodeReviewJob) {
 			"files":     len(files),
 		})
 		resultText := "无代码文件，已跳过"
+		s.saveReviewIssues(repo, push, nil)
+		s.saveReviewFiles(repo, push, nil)
 		s.pushRepo.UpdateCodeview(repo.ID, job.CommitID, models.CodeviewStatusSkipped, &resultText)
-		s.sendReviewNotification(repo, push, codeFiles, resultText)
-		return
+		s.statusServ.Skip(repo, push.ID, job.CommitID, resultText)
+		s.sendReviewNotification(repo, push, codeFiles, resultText, false)
+		return nil
 	}
 
 	type fileTask struct {
 		file git.DiffFile
 	}
 	type fileResult struct {
-		fileName string
-		summary  string
-		err      error
+		fileName  string
+		summary   string
+		issues    []Issue
+		hasErrors bool
+		record    models.ReviewFile
+		err       error
 	}
 
 	fileCh := make(chan fileTask, len(codeFiles))
@@ -332,20 +566,38 @@ func (s *WebhookService) processCodeReviewJob(job CodeReviewJob) {
 					DiffContent: task.file.Patch,
 					RepoName:    repo.Name,
 					Branch:      job.Branch,
+					CommitID:    job.CommitID,
 					CommitMsg:   push.CommitMsg,
 					Language:    detectLanguage(task.file.Filename),
 				}
+				if contentClient != nil {
+					loadReviewContext(contentClient, repo, job.CommitID, task.file, &input)
+				}
 
+				record := models.ReviewFile{FileName: task.file.Filename, Language: input.Language}
 				res, err := s.codeviewServ.Review(repo.ID, input)
 				if err != nil {
-					resultCh <- fileResult{fileName: task.file.Filename, err: err}
+					record.Error = err.Error()
+					resultCh <- fileResult{fileName: task.file.Filename, record: record, err: err}
 					continue
 				}
 
 				if res != nil {
-					resultCh <- fileResult{fileName: task.file.Filename, summary: strings.TrimSpace(res.Summary)}
+					record.PromptID = res.PromptID
+					record.PromptName = res.PromptName
+					record.PromptVersion = res.PromptVersion
+					record.PromptMatch = res.PromptMatch
+					record.ModelID = res.ModelID
+					record.ModelName = res.ModelName
+					record.Result = res.Result
+					record.CachedFrom = res.CachedFrom
+					summary := strings.TrimSpace(res.Summary)
+					if res.CachedFrom != "" {
+						summary = fmt.Sprintf("> 与提交 %s 的变更相同，复用其审查结果\n\n%s", shortCommit(res.CachedFrom), summary)
+					}
+					resultCh <- fileResult{fileName: task.file.Filename, summary: summary, issues: res.Issues, hasErrors: res.Result == ReviewResultProblem, record: record}
 				} else {
-					resultCh <- fileResult{fileName: task.file.Filename}
+					resultCh <- fileResult{fileName: task.file.Filename, record: record}
 				}
 			}
 		}()
@@ -360,8 +612,14 @@ func (s *WebhookService) processCodeReviewJob(job CodeReviewJob) {
 	close(resultCh)
 
 	var allIssues strings.Builder
+	var reviewIssues []models.ReviewIssue
+	var reviewFiles []models.ReviewFile
+	hasErrors := false
+	failedFiles := 0
 	for r := range resultCh {
+		reviewFiles = append(reviewFiles, r.record)
 		if r.err != nil {
+			failedFiles++
 			logger.Error("Failed to review file", map[string]interface{}{
 				"file_name": r.fileName,
 				"error":     r.err.Error(),
@@ -369,6 +627,19 @@ func (s *WebhookService) processCodeReviewJob(job CodeReviewJob) {
 			allIssues.WriteString(fmt.Sprintf("### %s\n审查失败: %s\n\n", r.fileName, r.err.Error()))
 			continue
 		}
+		if r.hasErrors {
+			hasErrors = true
+		}
+		for _, issue := range r.issues {
+			reviewIssues = append(reviewIssues, models.ReviewIssue{
+				File:       issue.File,
+				Line:       issue.Line,
+				Severity:   issue.Severity,
+				Category:   issue.Category,
+				Message:    issue.Message,
+				Suggestion: issue.Suggestion,
+			})
+		}
 		if r.summary != "" {
 			allIssues.WriteString(fmt.Sprintf("### %s\n%s\n\n", r.fileName, r.summary))
 		}
@@ -378,16 +649,75 @@ func (s *WebhookService) processCodeReviewJob(job CodeReviewJob) {
 	if strings.TrimSpace(resultText) == "" {
 		resultText = "未发现明显问题"
 	}
+	s.saveReviewIssues(repo, push, reviewIssues)
+	s.saveReviewFiles(repo, push, reviewFiles)
 	s.pushRepo.UpdateCodeview(repo.ID, job.CommitID, models.CodeviewStatusSuccess, &resultText)
 
+	// 将审查结论发布为提交状态，所有文件都审查失败时为 error
+	if failedFiles == len(codeFiles) {
+		s.statusServ.Error(repo, push.ID, job.CommitID, "AI 审查失败")
+	} else {
+		s.statusServ.Report(repo, push, reviewIssues)
+	}
+
+	// 将审查问题发布到 Git 平台
+	if repo.PublishComments {
+		if err := s.commentServ.Publish(repo, push, reviewIssues, codeFiles); err != nil {
+			logger.Error("Failed to publish review comments", map[string]interface{}{
+				"repo_id":   repo.ID,
+				"commit_id": job.CommitID,
+				"error":     err.Error(),
+			})
+		}
+	}
+
 	// 发送审查结果通知
-	s.sendReviewNotification(repo, push, codeFiles, resultText)
+	s.sendReviewNotification(repo, push, codeFiles, resultText, hasErrors)
 
 	logger.Info("Code review completed", map[string]interface{}{
 		"repo_id":   repo.ID,
 		"commit_id": job.CommitID,
 		"files":     len(codeFiles),
+		"issues":    len(reviewIssues),
 	})
+
+	return nil
+}
+
+// saveReviewIssues 保存提交的审查问题，覆盖上次审查的结果，并同步推送记录上的问题统计
+func (s *WebhookService) saveReviewIssues(repo *models.Repo, push *models.Push, issues []models.ReviewIssue) {
+	for i := range issues {
+		issues[i].PushID = push.ID
+		issues[i].RepoID = repo.ID
+		issues[i].CommitID = push.CommitID
+	}
+	if err := s.issueRepo.ReplaceForCommit(repo.ID, push.CommitID, issues); err != nil {
+		logger.Error("Failed to save review issues", map[string]interface{}{
+			"repo_id":   repo.ID,
+			"commit_id": push.CommitID,
+			"error":     err.Error(),
+		})
+		return
+	}
+	errorCount, warningCount, _ := countIssues(issues)
+	push.IssueCount = len(issues)
+	push.ErrorCount = errorCount
+	push.WarningCount = warningCount
+}
+
+// saveReviewFiles 保存提交中每个文件使用的提示词和模型，覆盖上次审查的记录
+func (s *WebhookService) saveReviewFiles(repo *models.Repo, push *models.Push, files []models.ReviewFile) {
+	for i := range files {
+		files[i].RepoID = repo.ID
+		files[i].CommitID = push.CommitID
+	}
+	if err := s.fileRepo.ReplaceForCommit(repo.ID, push.CommitID, files); err != nil {
+		logger.Error("Failed to save review files", map[string]interface{}{
+			"repo_id":   repo.ID,
+			"commit_id": push.CommitID,
+			"error":     err.Error(),
+		})
+	}
 }
 
 // extractOwner 从URL提取owner
@@ -408,13 +738,13 @@ func extractRepoName(urlStr string) string {
 	return ""
 }
 
-// extractGitLabBaseURL 从URL提取GitLab BaseURL
+// extractGitLabBaseURL 从URL提取GitLab BaseURL，支持 SSH 地址
 func extractGitLabBaseURL(repoURL string) string {
-	u, err := url.Parse(repoURL)
+	baseURL, _, err := forgeProject(repoURL)
 	if err != nil {
 		return "https://gitlab.com" // Default fallback
 	}
-	return u.Scheme + "://" + u.Host
+	return baseURL
 }
 
 // filterCodeFiles 过滤需要审查的代码文件
@@ -429,7 +759,7 @@ func filterCodeFiles(files []git.DiffFile) []git.DiffFile {
 	var result []git.DiffFile
 	for _, f := range files {
 		ext := getFileExtension(f.Filename)
-		if _, ok := codeExtensions[ext]; ok && f.Status != "deleted" {
+		if _, ok := codeExtensions[ext]; ok && f.Status != "deleted" && !f.Binary {
 			result = append(result, f)
 		}
 	}
@@ -468,69 +798,8 @@ func detectLanguage(filename string) string {
 	return "Unknown"
 }
 
-// sendDingTalk 发送钉钉通知
-func (s *WebhookService) sendDingTalk(target *models.Target, content string) error {
-	return s.sendDingTalkMarkdown(target, "代码提交通知", content)
-}
-
-func (s *WebhookService) sendDingTalkMarkdown(target *models.Target, title string, content string) error {
-	if target.Config == nil {
-		return fmt.Errorf("config is required for DingTalk target")
-	}
-
-	accessToken := target.Config.AccessToken
-	secret := target.Config.Secret
-
-	if accessToken == "" {
-		return fmt.Errorf("access_token is required for DingTalk target")
-	}
-
-	client := dingtalk.NewClient(accessToken, secret)
-
-	return client.SendMarkdown(target.Config.WebhookURL, title, content)
-}
-
-// sendWebhook 发送Webhook通知
-func (s *WebhookService) sendWebhook(target *models.Target, content string) error {
-	if target.Config == nil {
-		return fmt.Errorf("config is required for webhook target")
-	}
-
-	if target.Config.WebhookURL == "" {
-		return fmt.Errorf("webhook_url is required for webhook target")
-	}
-
-	method := target.Config.Method
-
-	// 准备请求体
-	var body io.Reader
-	if method == "POST" {
-		body = strings.NewReader(content)
-	}
-
-	req, err := http.NewRequest(method, target.Config.WebhookURL, body)
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("webhook returned error status: %d", resp.StatusCode)
-	}
-
-	return nil
-}
-
 // sendReviewNotification 发送审查结果通知
-func (s *WebhookService) sendReviewNotification(repo *models.Repo, push *models.Push, codeFiles []git.DiffFile, issues string) {
+func (s *WebhookService) sendReviewNotification(repo *models.Repo, push *models.Push, codeFiles []git.DiffFile, issues string, hasErrors bool) {
 	// 获取推送目标
 	targets, err := s.targetRepo.GetByScopeAndRepo(repo.ID)
 	if err != nil || len(targets) == 0 {
@@ -586,8 +855,22 @@ func (s *WebhookService) sendReviewNotification(repo *models.Repo, push *models.
 	for _, tpl := range templatesToSend {
 		content := s.buildReviewMessageContent(repo, push, issues, tpl)
 		for _, target := range targets {
-			if target.Type == models.TargetTypeDingTalk {
-				s.sendDingTalkMarkdown(&target, "代码审查报告", content)
+			t := target
+			// 摘要模式的目标在摘要中汇总审查结论，不单独发送；已暂停的目标不发送
+			if t.DeliveryMode == models.DeliveryModeDigest || t.Status == models.TargetStatusPaused {
+				continue
+			}
+			if s.quietServ.IsQuiet(&t, time.Now()) && !s.quietServ.IsUrgentReview(&t, hasErrors) {
+				if err := s.quietServ.Hold(&t, models.HeldKindReview, &push.ID, "代码审查报告", content); err == nil {
+					continue
+				}
+			}
+			if _, err := s.deliveryServ.Send(&t, "代码审查报告", content, AttemptRef{Kind: models.AttemptKindReview, PushID: &push.ID}); err != nil {
+				logger.Error("Review notification failed", map[string]interface{}{
+					"push_id":   push.ID,
+					"target_id": t.ID,
+					"error":     err.Error(),
+				})
 			}
 		}
 	}
@@ -610,8 +893,11 @@ func (s *WebhookService) buildReviewMessageContent(repo *models.Repo, push *mode
 		content.WriteString("### 🔍 代码审查结果\n\n")
 		content.WriteString("**仓库名称：** " + repo.Name + "\n")
 		content.WriteString("**提交ID：** `" + push.CommitID + "`\n")
-		content.WriteString("**提交信息：** " + push.CommitMsg + "\n\n")
-		content.WriteString("---\n")
+		content.WriteString("**提交信息：** " + push.CommitMsg + "\n")
+		if push.IssueCount > 0 {
+			content.WriteString(fmt.Sprintf("**问题统计：** 共 %d 个（错误 %d，警告 %d）\n", push.IssueCount, push.ErrorCount, push.WarningCount))
+		}
+		content.WriteString("\n---\n")
 		if reviewURL != "" {
 			content.WriteString("[查看审查详情](" + reviewURL + ")")
 		} else {
@@ -626,6 +912,10 @@ func (s *WebhookService) buildReviewMessageContent(repo *models.Repo, push *mode
 	content = strings.ReplaceAll(content, "{{.CommitID}}", push.CommitID)
 	content = strings.ReplaceAll(content, "{{.CommitMsg}}", push.CommitMsg)
 	content = strings.ReplaceAll(content, "{{.Issues}}", issues)
+	content = strings.ReplaceAll(content, "{{.IssueCount}}", strconv.Itoa(push.IssueCount))
+	content = strings.ReplaceAll(content, "{{.ErrorCount}}", strconv.Itoa(push.ErrorCount))
+	content = strings.ReplaceAll(content, "{{.WarningCount}}", strconv.Itoa(push.WarningCount))
+	content = strings.ReplaceAll(content, "{{.InfoCount}}", strconv.Itoa(push.IssueCount-push.ErrorCount-push.WarningCount))
 
 	// 生成审查链接
 	reviewURL := ""
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"backend/database"
+	"backend/internal/models"
+	"backend/pkg/retry"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func newTestWebhookService(t *testing.T, db *gorm.DB) *WebhookService {
+	t.Helper()
+	deliveryServ := NewDeliveryService(db, nil, CircuitBreaker{})
+	pushServ := NewPushService(db, deliveryServ, retry.Policy{MaxAttempts: 1}, QueueOptions{})
+	s := NewWebhookService(db, "", deliveryServ, pushServ, NewQuietHoursService(db, deliveryServ, pushServ), ReviewOptions{}, QueueOptions{})
+	t.Cleanup(func() {
+		s.Shutdown(context.Background())
+		pushServ.Shutdown(context.Background())
+	})
+	return s
+}
+
+// 推送任务重试时（如审查任务入队失败）接续本任务创建的推送记录，仓库不去重时也不重复创建和发送
+func TestSendUnifiedPushNotificationJobRetry(t *testing.T) {
+	var sent int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&sent, 1)
+	}))
+	defer srv.Close()
+
+	db := newTestDB(t)
+	s := newTestWebhookService(t, db)
+
+	repo := &models.Repo{Name: "demo", URL: "https://github.com/acme/demo", Type: "github", WebhookID: "w1", WebhookURL: "/webhook/w1", DedupPolicy: models.DedupPolicyNone}
+	target := &models.Target{Name: "hook", Type: models.TargetTypeWebhook, Config: &models.Config{WebhookURL: srv.URL}}
+	if err := db.Create(repo).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(target).Error; err != nil {
+		t.Fatal(err)
+	}
+	payload := &UnifiedPushPayload{After: "abc1234567", Branch: "main", CommitMsg: "fix"}
+	provider := providerByName("github")
+
+	for i := 0; i < 2; i++ {
+		if err := s.sendUnifiedPushNotification(repo, target, payload, nil, provider, 7); err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+	var count int64
+	db.Model(&models.Push{}).Count(&count)
+	if count != 1 || atomic.LoadInt32(&sent) != 1 {
+		t.Fatalf("retried job: %d pushes, %d sends, want 1 and 1", count, sent)
+	}
+
+	// 另一个推送任务（新的 Webhook 请求）在仓库不去重时照常推送
+	if err := s.sendUnifiedPushNotification(repo, target, payload, nil, provider, 8); err != nil {
+		t.Fatal(err)
+	}
+	db.Model(&models.Push{}).Count(&count)
+	if count != 2 || atomic.LoadInt32(&sent) != 2 {
+		t.Fatalf("new job: %d pushes, %d sends, want 2 and 2", count, sent)
+	}
+}
+
+// 提交状态在审查任务入队前同步设为 pending，之后审查任务设置的最终状态不会被覆盖
+func TestEnqueueCodeReviewPostsPendingBeforeEnqueue(t *testing.T) {
+	db := newTestDB(t)
+	s := newTestWebhookService(t, db)
+
+	var states []string
+	var queuedAtPending int64 = -1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		states = append(states, body["state"])
+		db.Model(&models.Job{}).Where("queue = ?", models.JobQueueCodeReview).Count(&queuedAtPending)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	modelID := uint(1)
+	repo := &models.Repo{ID: 1, Name: "demo", URL: srv.URL + "/acme/demo", Type: models.RepoTypeGitHub, APIURL: srv.URL, ModelID: &modelID, PublishStatus: true}
+	push := &models.Push{ID: 3}
+	if err := s.enqueueCodeReview(push, repo, &UnifiedPushPayload{After: "abc1234567", Branch: "main"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(states) != 1 || states[0] != "pending" {
+		t.Fatalf("states = %v, want [pending] posted before enqueueCodeReview returns", states)
+	}
+	if queuedAtPending != 0 {
+		t.Errorf("%d review jobs queued before pending status, want 0", queuedAtPending)
+	}
+	var queued int64
+	db.Model(&models.Job{}).Where("queue = ?", models.JobQueueCodeReview).Count(&queued)
+	if queued != 1 {
+		t.Errorf("%d review jobs queued, want 1", queued)
+	}
+}
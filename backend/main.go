@@ -5,8 +5,14 @@ import (
 	"backend/internal/config"
 	"backend/router"
 	"backend/utils/logger"
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -64,7 +70,7 @@ func main() {
 	}
 
 	// 创建路由
-	r := router.Setup(cfg)
+	r, shutdown := router.Setup(cfg)
 
 	// 启动服务器
 	addr := fmt.Sprintf("%s:%d", cfg.App.Host, cfg.App.Port)
@@ -72,9 +78,38 @@ func main() {
 		"addr": addr,
 	})
 
-	if err := r.Run(addr); err != nil {
-		logger.Fatal("Server failed to start", map[string]interface{}{
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: r,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("Server failed to start", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}()
+
+	// 等待退出信号
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+
+	timeout := time.Duration(cfg.App.ShutdownTimeout) * time.Second
+	logger.Info("Shutting down", map[string]interface{}{
+		"signal":  sig.String(),
+		"timeout": timeout.String(),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// 先停止接收请求（含 Webhook），再等待队列中执行的任务完成
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("Server shutdown error", map[string]interface{}{
 			"error": err.Error(),
 		})
 	}
+	shutdown(ctx)
+
+	logger.Info("Server exited", nil)
 }
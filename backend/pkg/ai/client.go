@@ -18,6 +18,23 @@ type Config struct {
 	Model   string // 模型名称
 	Timeout int    // 秒
 	Params  map[string]interface{}
+	// ContextSize 上下文大小（token），0 表示按模型名称推断
+	ContextSize int
+	// JSONSchema 要求模型按该结构返回 JSON，按模型支持的方式发送 response_format（见 ResponseFormatOf）
+	JSONSchema *JSONSchema
+}
+
+// 结构化输出方式（response_format 的 type）
+const (
+	FormatJSONSchema = "json_schema" // 按 JSON Schema 约束输出
+	FormatJSONObject = "json_object" // 只保证输出合法 JSON，结构由提示词说明
+)
+
+// JSONSchema response_format 为 json_schema 时的结构定义
+type JSONSchema struct {
+	Name   string                 `json:"name"`
+	Strict bool                   `json:"strict,omitempty"`
+	Schema map[string]interface{} `json:"schema"`
 }
 
 // Message 消息结构
@@ -33,6 +50,8 @@ type Request struct {
 	Temperature float64   `json:"temperature,omitempty"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	TopP        float64   `json:"top_p,omitempty"`
+	// ResponseFormat 结构化输出，如 {"type":"json_object"}
+	ResponseFormat interface{} `json:"response_format,omitempty"`
 }
 
 // Response 响应结构
@@ -129,16 +148,46 @@ func (c *Client) Chat(messages []Message, systemPrompt string) (string, error) {
 	if topP, ok := c.config.Params["top_p"].(float64); ok {
 		req.TopP = topP
 	}
+	// 模型参数中配置的 response_format 优先（配置为 null 表示不发送），否则按模型支持的方式要求结构化输出
+	format, configured := c.config.Params["response_format"]
+	if !configured {
+		format = c.defaultResponseFormat()
+	}
+	req.ResponseFormat = format
+
+	content, status, err := c.send(req)
+	if status == http.StatusBadRequest && !configured && req.ResponseFormat != nil {
+		// 推断有误或服务端不支持时去掉 response_format 重试，输出格式仍由提示词约束
+		req.ResponseFormat = nil
+		content, _, err = c.send(req)
+	}
+	return content, err
+}
+
+// defaultResponseFormat 按模型支持的结构化输出方式生成 response_format，未设置 JSONSchema 或模型不支持时返回 nil
+func (c *Client) defaultResponseFormat() interface{} {
+	if c.config.JSONSchema == nil {
+		return nil
+	}
+	switch ResponseFormatOf(c.config.Model) {
+	case FormatJSONSchema:
+		return map[string]interface{}{"type": FormatJSONSchema, "json_schema": c.config.JSONSchema}
+	case FormatJSONObject:
+		return map[string]interface{}{"type": FormatJSONObject}
+	}
+	return nil
+}
 
-	// 发送请求
+// send 发送请求并返回第一个回复的内容和 HTTP 状态码（未收到响应时为 0）
+func (c *Client) send(req Request) (string, int, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", 0, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	httpReq, err := http.NewRequest("POST", c.config.APIURL+"/chat/completions", bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// 设置请求头
@@ -147,26 +196,26 @@ func (c *Client) Chat(messages []Message, systemPrompt string) (string, error) {
 
 	resp, err := c.client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", 0, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("api error: status=%d body=%s", resp.StatusCode, string(respBody))
+		return "", resp.StatusCode, fmt.Errorf("api error: status=%d body=%s", resp.StatusCode, string(respBody))
 	}
 
 	var response Response
 	if err := json.Unmarshal(respBody, &response); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return "", resp.StatusCode, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
+		return "", resp.StatusCode, fmt.Errorf("no choices in response")
 	}
 
-	return response.Choices[0].Message.Content, nil
+	return response.Choices[0].Message.Content, resp.StatusCode, nil
 }
 
 // CodeReview 进行代码审查
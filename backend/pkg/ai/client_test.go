@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var testSchema = &JSONSchema{Name: "review", Strict: true, Schema: map[string]interface{}{"type": "object"}}
+
+// newTestServer 记录每次请求的 response_format，rejectFormat 为 true 时带 response_format 的请求返回 400
+func newTestServer(t *testing.T, rejectFormat bool) (*httptest.Server, *[]interface{}) {
+	t.Helper()
+	var formats []interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]interface{}
+		json.Unmarshal(body, &req)
+		formats = append(formats, req["response_format"])
+		if rejectFormat && req["response_format"] != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"error":{"message":"response_format is not supported"}}`)
+			return
+		}
+		io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"{}"}}]}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &formats
+}
+
+func formatType(format interface{}) string {
+	m, _ := format.(map[string]interface{})
+	s, _ := m["type"].(string)
+	return s
+}
+
+func TestChatResponseFormatByModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"gpt-4o-mini", FormatJSONSchema},
+		{"openai/gpt-4.1", FormatJSONSchema},
+		{"deepseek-chat", FormatJSONObject},
+		{"qwen-plus", FormatJSONObject},
+		{"claude-3-5-sonnet", ""},
+		{"unknown-model", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			srv, formats := newTestServer(t, false)
+			c := NewClientWithConfig(Config{APIURL: srv.URL, Model: tt.model, JSONSchema: testSchema})
+			if _, err := c.Chat([]Message{{Role: "user", Content: "review"}}, ""); err != nil {
+				t.Fatal(err)
+			}
+			if len(*formats) != 1 {
+				t.Fatalf("sent %d requests, want 1", len(*formats))
+			}
+			got := (*formats)[0]
+			if formatType(got) != tt.want {
+				t.Fatalf("response_format = %v, want type %q", got, tt.want)
+			}
+			if tt.want == FormatJSONSchema {
+				schema, _ := got.(map[string]interface{})["json_schema"].(map[string]interface{})
+				if schema["name"] != "review" || schema["strict"] != true {
+					t.Errorf("json_schema = %v", schema)
+				}
+			}
+		})
+	}
+}
+
+func TestChatWithoutSchema(t *testing.T) {
+	srv, formats := newTestServer(t, false)
+	c := NewClientWithConfig(Config{APIURL: srv.URL, Model: "gpt-4o"})
+	if _, err := c.Chat([]Message{{Role: "user", Content: "hi"}}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if (*formats)[0] != nil {
+		t.Errorf("response_format = %v, want none", (*formats)[0])
+	}
+}
+
+func TestChatResponseFormatFromParams(t *testing.T) {
+	srv, formats := newTestServer(t, false)
+	params := map[string]interface{}{"response_format": map[string]interface{}{"type": "text"}}
+	c := NewClientWithConfig(Config{APIURL: srv.URL, Model: "gpt-4o", Params: params, JSONSchema: testSchema})
+	if _, err := c.Chat([]Message{{Role: "user", Content: "hi"}}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if formatType((*formats)[0]) != "text" {
+		t.Errorf("response_format = %v, want params value", (*formats)[0])
+	}
+
+	// 配置为 null 时不发送
+	srv, formats = newTestServer(t, false)
+	c = NewClientWithConfig(Config{APIURL: srv.URL, Model: "gpt-4o", Params: map[string]interface{}{"response_format": nil}, JSONSchema: testSchema})
+	if _, err := c.Chat([]Message{{Role: "user", Content: "hi"}}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if (*formats)[0] != nil {
+		t.Errorf("response_format = %v, want none", (*formats)[0])
+	}
+}
+
+func TestChatFallsBackWithoutResponseFormat(t *testing.T) {
+	srv, formats := newTestServer(t, true)
+	c := NewClientWithConfig(Config{APIURL: srv.URL, Model: "deepseek-chat", JSONSchema: testSchema})
+	content, err := c.Chat([]Message{{Role: "user", Content: "review"}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "{}" {
+		t.Errorf("content = %q", content)
+	}
+	if len(*formats) != 2 || (*formats)[0] == nil || (*formats)[1] != nil {
+		t.Errorf("formats = %v, want one with and one without response_format", *formats)
+	}
+
+	// 模型参数中明确配置的 response_format 被拒绝时不重试
+	srv, formats = newTestServer(t, true)
+	params := map[string]interface{}{"response_format": map[string]interface{}{"type": "json_object"}}
+	c = NewClientWithConfig(Config{APIURL: srv.URL, Model: "deepseek-chat", Params: params, JSONSchema: testSchema})
+	if _, err := c.Chat([]Message{{Role: "user", Content: "review"}}, ""); err == nil {
+		t.Error("expected error for rejected configured response_format")
+	}
+	if len(*formats) != 1 {
+		t.Errorf("sent %d requests, want 1", len(*formats))
+	}
+}
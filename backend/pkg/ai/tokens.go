@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultContextSize 无法识别模型时使用的上下文大小（token）
+const DefaultContextSize = 8192
+
+// DefaultOutputTokens 模型参数未配置 max_tokens 时为输出预留的 token 数
+const DefaultOutputTokens = 2048
+
+// modelProfile 模型族的上下文大小、分词粒度和支持的结构化输出方式
+type modelProfile struct {
+	prefix         string
+	contextSize    int
+	charsPerToken  float64 // 平均每个 token 对应的 ASCII 字符数
+	responseFormat string  // 支持的 response_format 类型，为空表示不支持或未知
+}
+
+// modelProfiles 按模型名称前缀匹配，更具体的前缀在前
+var modelProfiles = []modelProfile{
+	{"gpt-4o", 128000, 4.0, FormatJSONSchema},
+	{"gpt-4.1", 1000000, 4.0, FormatJSONSchema},
+	{"gpt-4-turbo", 128000, 3.5, FormatJSONObject},
+	{"gpt-4-32k", 32768, 3.5, ""},
+	{"gpt-4", 8192, 3.5, ""},
+	{"gpt-3.5-turbo", 16385, 3.5, FormatJSONObject},
+	{"o1", 128000, 4.0, FormatJSONSchema},
+	{"o3", 200000, 4.0, FormatJSONSchema},
+	{"o4", 200000, 4.0, FormatJSONSchema},
+	{"claude", 200000, 3.2, ""},
+	{"deepseek", 64000, 3.2, FormatJSONObject},
+	{"qwen", 32768, 3.2, FormatJSONObject},
+	{"glm", 128000, 3.2, FormatJSONObject},
+	{"moonshot-v1-128k", 131072, 3.2, FormatJSONObject},
+	{"moonshot-v1-32k", 32768, 3.2, FormatJSONObject},
+	{"moonshot-v1-8k", 8192, 3.2, FormatJSONObject},
+	{"gemini", 1000000, 4.0, FormatJSONObject},
+}
+
+// lookupProfile 按模型名称查找模型族，名称可带提供商前缀（如 openai/gpt-4o）
+func lookupProfile(model string) (modelProfile, bool) {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, p := range modelProfiles {
+		if strings.HasPrefix(name, p.prefix) {
+			return p, true
+		}
+	}
+	return modelProfile{}, false
+}
+
+// ContextSizeOf 按模型名称推断上下文大小，无法识别时返回 DefaultContextSize
+func ContextSizeOf(model string) int {
+	if p, ok := lookupProfile(model); ok {
+		return p.contextSize
+	}
+	return DefaultContextSize
+}
+
+// ResponseFormatOf 按模型名称推断支持的结构化输出方式，不支持或无法识别时返回空
+func ResponseFormatOf(model string) string {
+	if p, ok := lookupProfile(model); ok {
+		return p.responseFormat
+	}
+	return ""
+}
+
+// EstimateTokens 估算文本在该模型下的 token 数
+// ASCII 字符按模型族的平均粒度折算，中文等非 ASCII 字符按每个字符一个 token 保守估计。
+func EstimateTokens(model, text string) int {
+	charsPerToken := 3.5
+	if p, ok := lookupProfile(model); ok {
+		charsPerToken = p.charsPerToken
+	}
+
+	ascii, other := 0, 0
+	for i := 0; i < len(text); {
+		if text[i] < utf8.RuneSelf {
+			ascii++
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		other++
+		i += size
+	}
+	return int(math.Ceil(float64(ascii)/charsPerToken)) + other
+}
+
+// ContextSize 模型的上下文大小，未配置时按模型名称推断
+func (c *Client) ContextSize() int {
+	if c.config.ContextSize > 0 {
+		return c.config.ContextSize
+	}
+	return ContextSizeOf(c.config.Model)
+}
+
+// OutputTokens 为模型输出预留的 token 数，取模型参数中的 max_tokens
+func (c *Client) OutputTokens() int {
+	if maxTokens, ok := c.config.Params["max_tokens"].(float64); ok && maxTokens > 0 {
+		return int(maxTokens)
+	}
+	return DefaultOutputTokens
+}
+
+// EstimateTokens 估算文本在当前模型下的 token 数
+func (c *Client) EstimateTokens(text string) int {
+	return EstimateTokens(c.config.Model, text)
+}
@@ -0,0 +1,150 @@
+package calendar
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNoEvents ICS 文件中没有事件
+var ErrNoEvents = errors.New("ICS 文件中没有可用的事件")
+
+// Event ICS 中的一个事件（VEVENT）
+type Event struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time // 不含；全天事件为结束日期的 0 点
+	AllDay  bool
+}
+
+// Dates 事件覆盖的日期（YYYY-MM-DD），跨多天的事件逐日展开
+func (e Event) Dates() []string {
+	end := e.End
+	if !end.After(e.Start) {
+		end = e.Start.AddDate(0, 0, 1)
+	}
+
+	var dates []string
+	day := time.Date(e.Start.Year(), e.Start.Month(), e.Start.Day(), 0, 0, 0, 0, e.Start.Location())
+	for day.Before(end) {
+		dates = append(dates, day.Format("2006-01-02"))
+		day = day.AddDate(0, 0, 1)
+	}
+	return dates
+}
+
+// Parse 解析 ICS（RFC 5545）内容中的事件，仅读取 UID、SUMMARY、DTSTART、DTEND
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current *Event
+	for _, line := range lines {
+		name, params, value := splitProperty(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current = &Event{}
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current != nil && !current.Start.IsZero() {
+				if current.End.IsZero() && current.AllDay {
+					current.End = current.Start.AddDate(0, 0, 1)
+				}
+				events = append(events, *current)
+			}
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.UID = value
+		case name == "SUMMARY":
+			current.Summary = unescape(value)
+		case name == "DTSTART":
+			t, allDay, err := parseTime(value, params)
+			if err != nil {
+				return nil, err
+			}
+			current.Start, current.AllDay = t, allDay
+		case name == "DTEND":
+			t, _, err := parseTime(value, params)
+			if err != nil {
+				return nil, err
+			}
+			current.End = t
+		}
+	}
+
+	if len(events) == 0 {
+		return nil, ErrNoEvents
+	}
+	return events, nil
+}
+
+// unfold 读取所有行并合并折行（以空格或制表符开头的行属于上一行）
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitProperty 拆分 NAME;PARAM=VALUE:value 形式的属性行
+func splitProperty(line string) (string, map[string]string, string) {
+	idx := strings.Index(line, ":")
+	if idx < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+	head, value := line[:idx], line[idx+1:]
+
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseTime 解析 DATE 或 DATE-TIME 值，支持 TZID 参数和 UTC 的 Z 后缀
+func parseTime(value string, params map[string]string) (time.Time, bool, error) {
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// unescape 还原 TEXT 值中的转义字符
+func unescape(s string) string {
+	r := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(s)
+}
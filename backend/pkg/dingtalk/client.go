@@ -36,6 +36,30 @@ type Config struct {
 	Secret      string
 }
 
+// APIError 钉钉接口返回的错误
+type APIError struct {
+	StatusCode int
+	ErrCode    int
+	ErrMsg     string
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode != 200 {
+		return fmt.Sprintf("dingtalk api error: %s", e.ErrMsg)
+	}
+	return fmt.Sprintf("dingtalk error: %v", e.ErrMsg)
+}
+
+// Result 一次请求的详细结果
+type Result struct {
+	URL          string
+	RequestBody  string
+	StatusCode   int
+	ResponseBody string
+	ErrCode      int
+	Latency      time.Duration
+}
+
 // Client 钉钉客户端
 type Client struct {
 	config Config
@@ -57,41 +81,51 @@ func NewClient(accessToken, secret string) *Client {
 
 // Send 发送消息
 func (c *Client) Send(hookUrl string, msg Message) error {
+	_, err := c.Do(hookUrl, msg)
+	return err
+}
+
+// Do 发送消息并返回请求详情，请求未发出时返回 nil
+func (c *Client) Do(hookUrl string, msg Message) (*Result, error) {
 	apiURL := hookUrl
 
 	data, err := json.Marshal(msg)
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return nil, fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	fmt.Println("data ->", string(data))
-
 	req, err := http.NewRequest("POST", apiURL, strings.NewReader(string(data)))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
+	res := &Result{URL: apiURL, RequestBody: string(data)}
+	start := time.Now()
 	resp, err := c.client.Do(req)
+	res.Latency = time.Since(start)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return res, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
+	res.StatusCode = resp.StatusCode
+	res.ResponseBody = string(body)
 
 	var result map[string]interface{}
 	json.Unmarshal(body, &result)
 
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("dingtalk api error: %s", string(body))
+		return res, &APIError{StatusCode: resp.StatusCode, ErrMsg: string(body)}
 	}
 
 	if errCode, ok := result["errcode"].(float64); ok && errCode != 0 {
-		return fmt.Errorf("dingtalk error: %v", result["errmsg"])
+		res.ErrCode = int(errCode)
+		return res, &APIError{StatusCode: resp.StatusCode, ErrCode: int(errCode), ErrMsg: fmt.Sprintf("%v", result["errmsg"])}
 	}
 
-	return nil
+	return res, nil
 }
 
 // SendText 发送文本消息
@@ -1,10 +1,13 @@
 package git
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -14,6 +17,25 @@ type GitClient interface {
 	GetSingleCommitDiff(commitSHA string) ([]DiffFile, error)
 }
 
+// ContentClient 读取仓库指定版本中的文件，为代码审查提供完整的文件上下文
+type ContentClient interface {
+	GetFileContent(filePath, ref string) (string, error)
+	// ListFiles 列出目录下的文件路径（不含子目录），dir 为空表示根目录
+	ListFiles(dir, ref string) ([]string, error)
+}
+
+// ErrDiffTruncated 平台 API 返回的差异不完整（文件数超出上限、比较超时或文件差异过大被折叠）
+var ErrDiffTruncated = errors.New("diff truncated by api")
+
+// githubCompareMaxFiles GitHub 比较接口最多返回的文件数，达到该数量时差异可能不完整
+const githubCompareMaxFiles = 300
+
+// diffPageSize 分页获取差异文件时每页的数量
+const diffPageSize = 100
+
+// maxDiffPages 分页获取差异文件的最大页数，GitHub 提交接口最多返回 3000 个文件
+const maxDiffPages = 30
+
 // Client Git客户端
 type Client struct {
 	baseURL   string
@@ -37,13 +59,19 @@ func NewClient(baseURL, token, repoOwner, repoName string) *Client {
 // DiffFile 差异文件
 type DiffFile struct {
 	Filename    string `json:"filename"`
-	Status      string `json:"status"` // added, modified, deleted
+	Status      string `json:"status"` // added, modified, deleted, renamed
 	Patch       string `json:"patch"`
 	Additions   int    `json:"additions"`
 	Deletions   int    `json:"deletions"`
 	Changes     int    `json:"changes"`
 	SHA         string `json:"sha"`
 	PreviousSHA string `json:"previous_sha"`
+	// PreviousFilename 重命名前的文件名，Status 为 renamed 时有效
+	PreviousFilename string `json:"previous_filename,omitempty"`
+	// Binary 二进制文件没有 Patch
+	Binary bool `json:"binary,omitempty"`
+	// Hunks 各块在变更前后文件中的行号范围
+	Hunks []DiffHunk `json:"hunks,omitempty"`
 }
 
 // CompareResult 比较结果
@@ -84,17 +112,49 @@ func (c *Client) GetDiff(base, head string) ([]DiffFile, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	// 比较接口的文件列表不分页，达到上限时需改用其他方式获取完整差异
+	if len(result.Files) >= githubCompareMaxFiles {
+		return nil, ErrDiffTruncated
+	}
 
+	for i := range result.Files {
+		result.Files[i].Hunks = ParseHunks(result.Files[i].Patch)
+	}
 	return result.Files, nil
 }
 
 // GetSingleCommitDiff 获取单次提交的差异
+// 提交接口每页最多返回 300 个文件，按 Link 头分页获取其余文件，超出 maxDiffPages 时返回 ErrDiffTruncated。
 func (c *Client) GetSingleCommitDiff(commitSHA string) ([]DiffFile, error) {
-	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s", c.baseURL, c.repoOwner, c.repoName, commitSHA)
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s?per_page=%d", c.baseURL, c.repoOwner, c.repoName, commitSHA, diffPageSize)
+
+	files := []DiffFile{}
+	for page := 1; url != ""; page++ {
+		if page > maxDiffPages {
+			return nil, ErrDiffTruncated
+		}
+		var commitData struct {
+			Files []DiffFile `json:"files"`
+		}
+		next, err := c.getPage(url, &commitData)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, commitData.Files...)
+		url = next
+	}
 
+	for i := range files {
+		files[i].Hunks = ParseHunks(files[i].Patch)
+	}
+	return files, nil
+}
+
+// getPage 获取一页结果，返回 Link 头中下一页的地址，没有下一页时为空
+func (c *Client) getPage(url string, out interface{}) (string, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	if c.token != "" {
@@ -104,34 +164,31 @@ func (c *Client) GetSingleCommitDiff(commitSHA string) ([]DiffFile, error) {
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return "", fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("api error: %s", string(body))
-	}
-
-	// 解析响应获取parent
-	var commitData struct {
-		SHA     string `json:"sha"`
-		Parents []struct {
-			SHA string `json:"sha"`
-		} `json:"parents"`
+		return "", fmt.Errorf("api error: %s", string(body))
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&commitData); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
+	return nextPageURL(resp.Header.Get("Link")), nil
+}
 
-	// 如果有parent，获取与parent的差异
-	if len(commitData.Parents) > 0 {
-		return c.GetDiff(commitData.Parents[0].SHA, commitSHA)
+// nextPageURL 解析 Link 头中 rel="next" 的地址
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(part, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(target), "<>")
 	}
-
-	// 首次提交，返回空差异
-	return []DiffFile{}, nil
+	return ""
 }
 
 // GetFileContent 获取文件内容
@@ -162,3 +219,154 @@ func (c *Client) GetFileContent(filePath, ref string) (string, error) {
 	content, _ := io.ReadAll(resp.Body)
 	return string(content), nil
 }
+
+// ListFiles 列出目录下的文件
+func (c *Client) ListFiles(dir, ref string) ([]string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", c.baseURL, c.repoOwner, c.repoName, strings.Trim(dir, "/"), ref)
+	var entries []struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+	}
+	if err := c.doJSON(http.MethodGet, url, nil, &entries); err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.Type == "file" {
+			files = append(files, e.Path)
+		}
+	}
+	return files, nil
+}
+
+// doJSON 发送 JSON 请求并解析响应，out 为 nil 时忽略响应内容
+func (c *Client) doJSON(method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodPatch {
+		return ErrCommentNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("api error: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// FindPullRequest 查找包含该提交的打开状态的 PR
+func (c *Client) FindPullRequest(commitSHA string) (*PullRequest, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s/pulls", c.baseURL, c.repoOwner, c.repoName, commitSHA)
+	var pulls []struct {
+		Number  int    `json:"number"`
+		State   string `json:"state"`
+		HTMLURL string `json:"html_url"`
+		Head    struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if err := c.doJSON(http.MethodGet, url, nil, &pulls); err != nil {
+		return nil, err
+	}
+	for _, p := range pulls {
+		if p.State == "open" {
+			return &PullRequest{Number: p.Number, WebURL: p.HTMLURL, HeadSHA: p.Head.SHA}, nil
+		}
+	}
+	return nil, nil
+}
+
+// CreateCommitComment 发布提交评论，行评论按差异中的 position 定位
+func (c *Client) CreateCommitComment(commitSHA string, comment Comment) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s/comments", c.baseURL, c.repoOwner, c.repoName, commitSHA)
+	body := map[string]interface{}{"body": comment.Body}
+	if comment.Path != "" {
+		body["path"] = comment.Path
+		body["position"] = comment.Line.Position
+	}
+	var result struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.doJSON(http.MethodPost, url, body, &result); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(result.ID, 10), nil
+}
+
+// UpdateCommitComment 更新提交评论
+func (c *Client) UpdateCommitComment(commitSHA, commentID, body string) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/comments/%s", c.baseURL, c.repoOwner, c.repoName, commentID)
+	return c.doJSON(http.MethodPatch, url, map[string]string{"body": body}, nil)
+}
+
+// CreatePullRequestComment 发布 PR 评论，行评论作为 review comment 定位到变更后的行
+func (c *Client) CreatePullRequestComment(pr *PullRequest, commitSHA string, comment Comment) (string, error) {
+	var url string
+	body := map[string]interface{}{"body": comment.Body}
+	if comment.Path != "" {
+		url = fmt.Sprintf("%s/repos/%s/%s/pulls/%d/comments", c.baseURL, c.repoOwner, c.repoName, pr.Number)
+		body["commit_id"] = commitSHA
+		body["path"] = comment.Path
+		body["line"] = comment.Line.NewLine
+		body["side"] = "RIGHT"
+	} else {
+		url = fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", c.baseURL, c.repoOwner, c.repoName, pr.Number)
+	}
+	var result struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.doJSON(http.MethodPost, url, body, &result); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(result.ID, 10), nil
+}
+
+// UpdatePullRequestComment 更新 PR 评论
+func (c *Client) UpdatePullRequestComment(pr *PullRequest, commentID, body string) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/comments/%s", c.baseURL, c.repoOwner, c.repoName, commentID)
+	return c.doJSON(http.MethodPatch, url, map[string]string{"body": body}, nil)
+}
+
+// SetCommitStatus 通过 Status API 设置提交状态，可在分支保护中设为必需检查
+func (c *Client) SetCommitStatus(commitSHA string, status CommitStatus) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/statuses/%s", c.baseURL, c.repoOwner, c.repoName, commitSHA)
+	body := map[string]string{
+		"state":       status.State,
+		"context":     status.Context,
+		"description": truncateDescription(status.Description),
+	}
+	if status.TargetURL != "" {
+		body["target_url"] = status.TargetURL
+	}
+	return c.doJSON(http.MethodPost, url, body, nil)
+}
@@ -1,11 +1,13 @@
 package git
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -25,11 +27,15 @@ type GitLabCommit struct {
 type GitLabDiff struct {
 	OldPath     string `json:"old_path"`
 	NewPath     string `json:"new_path"`
-	NewFile     string `json:"new_file"`
-	RenamedFile string `json:"renamed_file"`
-	DeletedFile string `json:"deleted_file"`
-	ModeChange  string `json:"mode_change"`
+	AMode       string `json:"a_mode"`
+	BMode       string `json:"b_mode"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
 	Diff        string `json:"diff"`
+	// TooLarge、Collapsed 文件差异超出平台限制，Diff 为空
+	TooLarge  bool `json:"too_large"`
+	Collapsed bool `json:"collapsed"`
 }
 
 // GitLabCompare 比较结果
@@ -91,73 +97,320 @@ func (c *GitLabClient) GetDiff(from, to string) ([]DiffFile, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	if result.CompareTimeout {
+		return nil, ErrDiffTruncated
+	}
+
+	return convertGitLabDiffs(result.Diffs)
+}
+
+// GetSingleCommitDiff 获取单次提交的差异，按 X-Next-Page 头分页获取所有文件
+func (c *GitLabClient) GetSingleCommitDiff(commitSHA string) ([]DiffFile, error) {
+	var diffs []GitLabDiff
+	for page := 1; page > 0; {
+		if page > maxDiffPages {
+			return nil, ErrDiffTruncated
+		}
+		url := fmt.Sprintf("%s/projects/%s/repository/commits/%s/diff?per_page=%d&page=%d", c.baseURL, c.projectID, commitSHA, diffPageSize, page)
+
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		if c.token != "" {
+			req.Header.Set("PRIVATE-TOKEN", c.token)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
 
-	// 转换GitLab差异为通用格式
-	files := make([]DiffFile, 0, len(result.Diffs))
-	for _, d := range result.Diffs {
-		status := "modified"
-		if d.NewFile == "true" {
-			status = "added"
-		} else if d.DeletedFile == "true" {
-			status = "deleted"
+		if resp.StatusCode != 200 {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("api error: %s", string(body))
 		}
 
-		files = append(files, DiffFile{
+		var pageDiffs []GitLabDiff
+		err = json.NewDecoder(resp.Body).Decode(&pageDiffs)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		diffs = append(diffs, pageDiffs...)
+
+		// 最后一页的 X-Next-Page 为空
+		page, _ = strconv.Atoi(resp.Header.Get("X-Next-Page"))
+	}
+
+	return convertGitLabDiffs(diffs)
+}
+
+// convertGitLabDiffs 转换GitLab差异为通用格式，有文件差异被平台折叠时返回 ErrDiffTruncated
+func convertGitLabDiffs(diffs []GitLabDiff) ([]DiffFile, error) {
+	files := make([]DiffFile, 0, len(diffs))
+	for _, d := range diffs {
+		if d.TooLarge || d.Collapsed {
+			return nil, ErrDiffTruncated
+		}
+
+		file := DiffFile{
 			Filename: d.NewPath,
-			Status:   status,
+			Status:   "modified",
 			Patch:    d.Diff,
-		})
+			Hunks:    ParseHunks(d.Diff),
+		}
+		switch {
+		case d.NewFile:
+			file.Status = "added"
+		case d.DeletedFile:
+			file.Status = "deleted"
+		case d.RenamedFile:
+			file.Status = "renamed"
+			file.PreviousFilename = d.OldPath
+		}
+		// 二进制文件的差异为 "Binary files ... differ"，不带块头
+		file.Binary = d.Diff != "" && len(file.Hunks) == 0 && strings.HasPrefix(d.Diff, "Binary files")
+		file.Additions, file.Deletions = countPatchLines(d.Diff)
+		file.Changes = file.Additions + file.Deletions
+		files = append(files, file)
 	}
-
 	return files, nil
 }
 
-// GetSingleCommitDiff 获取单次提交的差异
-func (c *GitLabClient) GetSingleCommitDiff(commitSHA string) ([]DiffFile, error) {
-	url := fmt.Sprintf("%s/projects/%s/repository/commits/%s/diff", c.baseURL, c.projectID, commitSHA)
+// GetFileContent 获取文件内容
+func (c *GitLabClient) GetFileContent(filePath, ref string) (string, error) {
+	fileURL := fmt.Sprintf("%s/projects/%s/repository/files/%s/raw?ref=%s", c.baseURL, c.projectID, url.PathEscape(filePath), url.QueryEscape(ref))
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequest("GET", fileURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	if c.token != "" {
 		req.Header.Set("PRIVATE-TOKEN", c.token)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return "", fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("api error: %s", string(body))
+		return "", fmt.Errorf("api error: %s", string(body))
 	}
 
-	var diffs []GitLabDiff
-	if err := json.NewDecoder(resp.Body).Decode(&diffs); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	content, _ := io.ReadAll(resp.Body)
+	return string(content), nil
+}
+
+// ListFiles 列出目录下的文件
+func (c *GitLabClient) ListFiles(dir, ref string) ([]string, error) {
+	treeURL := fmt.Sprintf("%s/projects/%s/repository/tree?path=%s&ref=%s&per_page=%d", c.baseURL, c.projectID, url.QueryEscape(strings.Trim(dir, "/")), url.QueryEscape(ref), diffPageSize)
+	var entries []struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+	}
+	if err := c.doJSON(http.MethodGet, treeURL, nil, &entries); err != nil {
+		return nil, err
 	}
+	var files []string
+	for _, e := range entries {
+		if e.Type == "blob" {
+			files = append(files, e.Path)
+		}
+	}
+	return files, nil
+}
 
-	// 转换GitLab差异为通用格式
-	files := make([]DiffFile, 0, len(diffs))
-	for _, d := range diffs {
-		status := "modified"
-		if d.NewFile == "true" {
-			status = "added"
-		} else if d.DeletedFile == "true" {
-			status = "deleted"
+// doJSON 发送 JSON 请求并解析响应，out 为 nil 时忽略响应内容
+func (c *GitLabClient) doJSON(method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
 		}
+		body = bytes.NewReader(data)
+	}
 
-		files = append(files, DiffFile{
-			Filename: d.NewPath,
-			Status:   status,
-			Patch:    d.Diff,
-		})
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
 	}
+	req.Header.Set("Content-Type", "application/json")
 
-	return files, nil
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodPut {
+		return ErrCommentNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("api error: status=%d body=%s", resp.StatusCode, string(data))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// FindPullRequest 查找包含该提交的打开状态的 MR，并获取行评论定位所需的 diff_refs
+func (c *GitLabClient) FindPullRequest(commitSHA string) (*PullRequest, error) {
+	listURL := fmt.Sprintf("%s/projects/%s/repository/commits/%s/merge_requests", c.baseURL, c.projectID, commitSHA)
+	var mrs []struct {
+		IID    int    `json:"iid"`
+		State  string `json:"state"`
+		WebURL string `json:"web_url"`
+	}
+	if err := c.doJSON(http.MethodGet, listURL, nil, &mrs); err != nil {
+		return nil, err
+	}
+	for _, mr := range mrs {
+		if mr.State != "opened" {
+			continue
+		}
+		detailURL := fmt.Sprintf("%s/projects/%s/merge_requests/%d", c.baseURL, c.projectID, mr.IID)
+		var detail struct {
+			DiffRefs struct {
+				BaseSHA  string `json:"base_sha"`
+				HeadSHA  string `json:"head_sha"`
+				StartSHA string `json:"start_sha"`
+			} `json:"diff_refs"`
+		}
+		if err := c.doJSON(http.MethodGet, detailURL, nil, &detail); err != nil {
+			return nil, err
+		}
+		return &PullRequest{
+			Number:   mr.IID,
+			WebURL:   mr.WebURL,
+			BaseSHA:  detail.DiffRefs.BaseSHA,
+			StartSHA: detail.DiffRefs.StartSHA,
+			HeadSHA:  detail.DiffRefs.HeadSHA,
+		}, nil
+	}
+	return nil, nil
+}
+
+// CreateCommitComment 发布提交评论
+// 普通评论作为提交讨论发布以便之后更新，返回 "讨论ID/评论ID"；行评论不返回 ID。
+func (c *GitLabClient) CreateCommitComment(commitSHA string, comment Comment) (string, error) {
+	if comment.Path != "" {
+		commentURL := fmt.Sprintf("%s/projects/%s/repository/commits/%s/comments", c.baseURL, c.projectID, commitSHA)
+		body := map[string]interface{}{
+			"note":      comment.Body,
+			"path":      comment.Path,
+			"line":      comment.Line.NewLine,
+			"line_type": "new",
+		}
+		return "", c.doJSON(http.MethodPost, commentURL, body, nil)
+	}
+
+	discussionURL := fmt.Sprintf("%s/projects/%s/repository/commits/%s/discussions", c.baseURL, c.projectID, commitSHA)
+	var discussion struct {
+		ID    string `json:"id"`
+		Notes []struct {
+			ID int64 `json:"id"`
+		} `json:"notes"`
+	}
+	if err := c.doJSON(http.MethodPost, discussionURL, map[string]string{"body": comment.Body}, &discussion); err != nil {
+		return "", err
+	}
+	if len(discussion.Notes) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("%s/%d", discussion.ID, discussion.Notes[0].ID), nil
+}
+
+// UpdateCommitComment 更新提交讨论中的评论，commentID 为 "讨论ID/评论ID"
+func (c *GitLabClient) UpdateCommitComment(commitSHA, commentID, body string) error {
+	discussionID, noteID, ok := strings.Cut(commentID, "/")
+	if !ok {
+		return ErrCommentNotFound
+	}
+	noteURL := fmt.Sprintf("%s/projects/%s/repository/commits/%s/discussions/%s/notes/%s", c.baseURL, c.projectID, commitSHA, discussionID, noteID)
+	return c.doJSON(http.MethodPut, noteURL, map[string]string{"body": body}, nil)
+}
+
+// CreatePullRequestComment 发布 MR 评论，行评论作为定位到变更行的讨论
+func (c *GitLabClient) CreatePullRequestComment(pr *PullRequest, commitSHA string, comment Comment) (string, error) {
+	if comment.Path == "" {
+		noteURL := fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes", c.baseURL, c.projectID, pr.Number)
+		var note struct {
+			ID int64 `json:"id"`
+		}
+		if err := c.doJSON(http.MethodPost, noteURL, map[string]string{"body": comment.Body}, &note); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d", note.ID), nil
+	}
+
+	position := map[string]interface{}{
+		"position_type": "text",
+		"base_sha":      pr.BaseSHA,
+		"start_sha":     pr.StartSHA,
+		"head_sha":      pr.HeadSHA,
+		"old_path":      comment.Path,
+		"new_path":      comment.Path,
+		"new_line":      comment.Line.NewLine,
+	}
+	if !comment.Line.Added {
+		position["old_line"] = comment.Line.OldLine
+	}
+	discussionURL := fmt.Sprintf("%s/projects/%s/merge_requests/%d/discussions", c.baseURL, c.projectID, pr.Number)
+	var discussion struct {
+		ID string `json:"id"`
+	}
+	body := map[string]interface{}{"body": comment.Body, "position": position}
+	if err := c.doJSON(http.MethodPost, discussionURL, body, &discussion); err != nil {
+		return "", err
+	}
+	return discussion.ID, nil
+}
+
+// UpdatePullRequestComment 更新 MR 评论
+func (c *GitLabClient) UpdatePullRequestComment(pr *PullRequest, commentID, body string) error {
+	noteURL := fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes/%s", c.baseURL, c.projectID, pr.Number, commentID)
+	return c.doJSON(http.MethodPut, noteURL, map[string]string{"body": body}, nil)
+}
+
+// gitlabStatusStates GitLab 提交状态没有 failure/error，均对应 failed
+var gitlabStatusStates = map[string]string{
+	StatusPending: "pending",
+	StatusSuccess: "success",
+	StatusFailure: "failed",
+	StatusError:   "failed",
+}
+
+// SetCommitStatus 设置提交状态，可配合“流水线必须成功”等合并检查使用
+func (c *GitLabClient) SetCommitStatus(commitSHA string, status CommitStatus) error {
+	statusURL := fmt.Sprintf("%s/projects/%s/statuses/%s", c.baseURL, c.projectID, commitSHA)
+	state, ok := gitlabStatusStates[status.State]
+	if !ok {
+		state = status.State
+	}
+	body := map[string]string{
+		"state":       state,
+		"name":        status.Context,
+		"description": truncateDescription(status.Description),
+	}
+	if status.TargetURL != "" {
+		body["target_url"] = status.TargetURL
+	}
+	return c.doJSON(http.MethodPost, statusURL, body, nil)
 }
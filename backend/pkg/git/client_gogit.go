@@ -1,13 +1,16 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/format/diff"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/storage/memory"
 )
@@ -17,6 +20,14 @@ type GoGitClient struct {
 	repoURL  string
 	auth     *git.CloneOptions
 	memStore *memory.Storage
+	// memRepo 内存中克隆的仓库，同一客户端的多次读取共用
+	memRepo *git.Repository
+	memMu   sync.Mutex
+	// contextLines 统一差异中变更前后保留的上下文行数
+	contextLines int
+	// mirror 磁盘镜像缓存，为空时每次克隆到内存
+	mirror     *MirrorCache
+	mirrorRefs []string
 }
 
 // NewGoGitClient 创建 go-git 客户端
@@ -36,23 +47,64 @@ func NewGoGitClient(repoURL, token string) *GoGitClient {
 	}
 
 	return &GoGitClient{
-		repoURL:  repoURL,
-		auth:     opts,
-		memStore: memory.NewStorage(),
+		repoURL:      repoURL,
+		auth:         opts,
+		memStore:     memory.NewStorage(),
+		contextLines: DefaultContextLines,
 	}
 }
 
+// SetContextLines 设置统一差异的上下文行数
+// go-git 在 0 行上下文时生成的块头行号不正确，至少保留 1 行。
+func (c *GoGitClient) SetContextLines(n int) {
+	if n < 1 {
+		n = 1
+	}
+	c.contextLines = n
+}
+
+// SetAuth 替换默认的令牌认证，如 SSH 地址的仓库使用部署密钥
+func (c *GoGitClient) SetAuth(auth transport.AuthMethod) {
+	c.auth.Auth = auth
+}
+
+// UseMirror 使用磁盘镜像缓存读取提交，refs 为优先拉取的引用（如推送的分支）
+func (c *GoGitClient) UseMirror(mirror *MirrorCache, refs ...string) {
+	c.mirror = mirror
+	c.mirrorRefs = refs
+}
+
+// open 打开包含指定提交的仓库，返回的 release 在读取完成后调用
+// 配置了镜像缓存时增量拉取到磁盘镜像，否则完整克隆到内存（对于大仓库会慢）。
+func (c *GoGitClient) open(commits ...string) (*git.Repository, func(), error) {
+	if c.mirror != nil {
+		return c.mirror.Open(MirrorRequest{
+			URL:     c.repoURL,
+			Auth:    c.auth.Auth,
+			Refs:    c.mirrorRefs,
+			Commits: commits,
+		})
+	}
+
+	c.memMu.Lock()
+	defer c.memMu.Unlock()
+	if c.memRepo == nil {
+		r, err := git.Clone(c.memStore, nil, c.auth)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to clone repo: %w", err)
+		}
+		c.memRepo = r
+	}
+	return c.memRepo, func() {}, nil
+}
+
 // GetDiff 获取两次提交之间的差异
-// 注意：go-git 获取两次提交的差异需要完整的历史或至少包含这两个提交
-// 这里为了简单，我们可能需要 Clone 整个仓库或使用 Shallow Clone
 func (c *GoGitClient) GetDiff(base, head string) ([]DiffFile, error) {
-	// Clone 仓库到内存
-	// 使用 Shallow Clone 可能会有问题，如果 base/head 不在 depth 范围内
-	// 这里尝试完整 Clone (对于大仓库会慢)
-	r, err := git.Clone(c.memStore, nil, c.auth)
+	r, release, err := c.open(base, head)
 	if err != nil {
-		return nil, fmt.Errorf("failed to clone repo: %w", err)
+		return nil, err
 	}
+	defer release()
 
 	// 获取 Commit 对象
 	headHash := plumbing.NewHash(head)
@@ -80,7 +132,7 @@ func (c *GoGitClient) GetDiff(base, head string) ([]DiffFile, error) {
 	}
 
 	// 比较
-	patch, err := baseTree.Patch(headTree)
+	patch, err := diffTrees(baseTree, headTree)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get patch: %w", err)
 	}
@@ -90,11 +142,11 @@ func (c *GoGitClient) GetDiff(base, head string) ([]DiffFile, error) {
 
 // GetSingleCommitDiff 获取单次提交的差异
 func (c *GoGitClient) GetSingleCommitDiff(commitSHA string) ([]DiffFile, error) {
-	// Clone 仓库
-	r, err := git.Clone(c.memStore, nil, c.auth)
+	r, release, err := c.open(commitSHA)
 	if err != nil {
-		return nil, fmt.Errorf("failed to clone repo: %w", err)
+		return nil, err
 	}
+	defer release()
 
 	commitHash := plumbing.NewHash(commitSHA)
 	commit, err := r.CommitObject(commitHash)
@@ -123,7 +175,7 @@ func (c *GoGitClient) GetSingleCommitDiff(commitSHA string) ([]DiffFile, error)
 		return nil, fmt.Errorf("failed to get current tree: %w", err)
 	}
 
-	patch, err := parentTree.Patch(currentTree)
+	patch, err := diffTrees(parentTree, currentTree)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get patch: %w", err)
 	}
@@ -131,75 +183,150 @@ func (c *GoGitClient) GetSingleCommitDiff(commitSHA string) ([]DiffFile, error)
 	return c.convertPatchToDiffFiles(patch), nil
 }
 
+// GetFileContent 获取指定提交中的文件内容
+func (c *GoGitClient) GetFileContent(filePath, ref string) (string, error) {
+	r, release, err := c.open(ref)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	commit, err := r.CommitObject(plumbing.NewHash(ref))
+	if err != nil {
+		return "", fmt.Errorf("failed to get commit: %w", err)
+	}
+	file, err := commit.File(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get file: %w", err)
+	}
+	return file.Contents()
+}
+
+// ListFiles 列出指定提交中目录下的文件
+func (c *GoGitClient) ListFiles(dir, ref string) ([]string, error) {
+	r, release, err := c.open(ref)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	commit, err := r.CommitObject(plumbing.NewHash(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree: %w", err)
+	}
+	dir = strings.Trim(dir, "/")
+	if dir != "" {
+		if tree, err = tree.Tree(dir); err != nil {
+			return nil, fmt.Errorf("failed to get dir: %w", err)
+		}
+	}
+
+	var files []string
+	for _, entry := range tree.Entries {
+		if !entry.Mode.IsFile() {
+			continue
+		}
+		if dir == "" {
+			files = append(files, entry.Name)
+		} else {
+			files = append(files, dir+"/"+entry.Name)
+		}
+	}
+	return files, nil
+}
+
+// diffTrees 比较两个 Tree，识别重命名的文件
+func diffTrees(from, to *object.Tree) (*object.Patch, error) {
+	changes, err := object.DiffTreeWithOptions(context.Background(), from, to, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+	return changes.Patch()
+}
+
 // convertPatchToDiffFiles 转换 patch 为通用 DiffFile 格式
+// 每个文件编码为带 @@ 块头的统一差异，只保留块内容（与 GitHub API 的 patch 一致），文件头信息记录在字段中。
 func (c *GoGitClient) convertPatchToDiffFiles(patch *object.Patch) []DiffFile {
 	var files []DiffFile
 	for _, fp := range patch.FilePatches() {
 		from, to := fp.Files()
-		
-		var filename string
-		var status string
-		
-		if from == nil && to != nil {
-			filename = to.Path()
-			status = "added"
-		} else if from != nil && to == nil {
-			filename = from.Path()
-			status = "deleted"
-		} else if from != nil && to != nil {
-			filename = to.Path() // Usually same as from
+
+		file := DiffFile{Binary: fp.IsBinary()}
+		switch {
+		case from == nil && to != nil:
+			file.Filename = to.Path()
+			file.Status = "added"
+		case from != nil && to == nil:
+			file.Filename = from.Path()
+			file.Status = "deleted"
+		case from != nil && to != nil:
+			file.Filename = to.Path()
+			file.Status = "modified"
 			if from.Path() != to.Path() {
-				status = "renamed"
-			} else {
-				status = "modified"
+				file.Status = "renamed"
+				file.PreviousFilename = from.Path()
 			}
+		default:
+			continue
+		}
+		if from != nil {
+			file.PreviousSHA = from.Hash().String()
+		}
+		if to != nil {
+			file.SHA = to.Hash().String()
 		}
 
-		chunks := fp.Chunks()
-		var diffBuilder strings.Builder
-		additions := 0
-		deletions := 0
-
-		for _, chunk := range chunks {
-			content := chunk.Content()
-			op := chunk.Type()
-
-			// Count lines
-			lines := strings.Count(content, "\n")
-			if len(content) > 0 && content[len(content)-1] != '\n' {
+		for _, chunk := range fp.Chunks() {
+			lines := strings.Count(chunk.Content(), "\n")
+			if content := chunk.Content(); content != "" && !strings.HasSuffix(content, "\n") {
 				lines++
 			}
+			switch chunk.Type() {
+			case diff.Add:
+				file.Additions += lines
+			case diff.Delete:
+				file.Deletions += lines
+			}
+		}
+		file.Changes = file.Additions + file.Deletions
 
-			// Build diff string
-			linesArr := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
-
-			for _, line := range linesArr {
-				if op == diff.Add {
-					additions++ // Count again per line to be safe, or just use the block count
-					diffBuilder.WriteString("+" + line + "\n")
-				} else if op == diff.Delete {
-					deletions++
-					diffBuilder.WriteString("-" + line + "\n")
-				} else {
-					diffBuilder.WriteString(" " + line + "\n")
-				}
+		if !file.Binary {
+			var buf strings.Builder
+			if err := diff.NewUnifiedEncoder(&buf, c.contextLines).Encode(singleFilePatch{fp}); err == nil {
+				file.Patch = stripFileHeader(buf.String())
+				file.Hunks = ParseHunks(file.Patch)
 			}
 		}
 
-		// Re-calculate stats from chunks logic if needed, but the loop above does it too.
-		// However, iterating lines is safer for stats if chunk content has mixed newlines.
-		// Let's rely on the loop counters.
-		// Reset counters to be accurate based on lines we processed
-		// Actually, let's just use the chunk stats logic I had before but per line.
-
-		files = append(files, DiffFile{
-			Filename:  filename,
-			Status:    status,
-			Patch:     diffBuilder.String(),
-			Additions: additions,
-			Deletions: deletions,
-			Changes:   additions + deletions,
-		})
+		files = append(files, file)
 	}
 	return files
 }
+
+// singleFilePatch 单个文件的差异，用于按文件编码统一差异
+type singleFilePatch struct {
+	filePatch diff.FilePatch
+}
+
+func (p singleFilePatch) FilePatches() []diff.FilePatch {
+	return []diff.FilePatch{p.filePatch}
+}
+
+func (p singleFilePatch) Message() string {
+	return ""
+}
+
+// stripFileHeader 去掉统一差异中第一个 @@ 块头之前的文件头（diff --git、index、---、+++ 等）
+func stripFileHeader(patch string) string {
+	if strings.HasPrefix(patch, "@@") {
+		return patch
+	}
+	if i := strings.Index(patch, "\n@@"); i >= 0 {
+		return patch[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrCommentNotFound 要更新的评论已被删除
+var ErrCommentNotFound = errors.New("comment not found")
+
+// Comment 审查评论，Path 为空时为提交或合并请求上的普通评论
+type Comment struct {
+	Path string
+	Body string
+	Line DiffLine // 行评论定位，Path 不为空时有效
+}
+
+// PullRequest 提交所属的打开状态的合并请求（GitHub PR / GitLab MR）
+type PullRequest struct {
+	Number   int    // GitHub PR 编号 / GitLab MR iid
+	WebURL   string // 页面地址
+	BaseSHA  string // GitLab 行评论定位所需的 diff_refs
+	StartSHA string
+	HeadSHA  string
+}
+
+// CommentClient 在 Git 平台发布审查评论
+type CommentClient interface {
+	// FindPullRequest 查找包含该提交的打开状态的合并请求，没有时返回 nil
+	FindPullRequest(commitSHA string) (*PullRequest, error)
+	// CreateCommitComment 在提交上发布评论，返回评论 ID
+	CreateCommitComment(commitSHA string, comment Comment) (string, error)
+	// UpdateCommitComment 更新提交上的普通评论
+	UpdateCommitComment(commitSHA, commentID, body string) error
+	// CreatePullRequestComment 在合并请求上发布评论或行评论，返回评论 ID
+	CreatePullRequestComment(pr *PullRequest, commitSHA string, comment Comment) (string, error)
+	// UpdatePullRequestComment 更新合并请求上的普通评论
+	UpdatePullRequestComment(pr *PullRequest, commentID, body string) error
+}
+
+// DiffLine 变更后文件中的一行在差异中的位置
+type DiffLine struct {
+	Position int  // 在差异中的行序号（GitHub 提交评论的 position），从 1 开始
+	OldLine  int  // 变更前的行号，新增行为 0
+	NewLine  int  // 变更后的行号
+	Added    bool // 是否为新增行
+}
+
+// LocateLine 在差异中查找变更后文件的第 line 行，行不在差异中时返回 false
+// 兼容带 @@ 块头的统一差异格式和不带块头、从第 1 行开始的完整文件差异。
+func LocateLine(patch string, line int) (DiffLine, bool) {
+	if line <= 0 || patch == "" {
+		return DiffLine{}, false
+	}
+
+	position, oldLine, newLine := 0, 1, 1
+	inHunk := !hasDiffHeader(patch)
+	for _, text := range strings.Split(strings.TrimSuffix(patch, "\n"), "\n") {
+		if strings.HasPrefix(text, "@@") {
+			// 第一个块头不计入 position，之后的块头计入
+			if inHunk && position > 0 {
+				position++
+			}
+			oldLine, newLine = parseHunkHeader(text)
+			inHunk = true
+			continue
+		}
+		if !inHunk {
+			continue
+		}
+
+		position++
+		switch {
+		case strings.HasPrefix(text, "+"):
+			if newLine == line {
+				return DiffLine{Position: position, NewLine: newLine, Added: true}, true
+			}
+			newLine++
+		case strings.HasPrefix(text, "-"):
+			oldLine++
+		case strings.HasPrefix(text, "\\"):
+			// \ No newline at end of file
+		default:
+			if newLine == line {
+				return DiffLine{Position: position, OldLine: oldLine, NewLine: newLine}, true
+			}
+			oldLine++
+			newLine++
+		}
+	}
+	return DiffLine{}, false
+}
+
+// hasDiffHeader 差异是否为带文件头或 @@ 块头的统一差异格式
+func hasDiffHeader(patch string) bool {
+	return strings.HasPrefix(patch, "diff ") || strings.HasPrefix(patch, "@@") ||
+		(strings.HasPrefix(patch, "--- ") && strings.Contains(patch, "\n+++ "))
+}
+
+// parseHunkHeader 解析块头 @@ -a,b +c,d @@ 中的起始行号
+func parseHunkHeader(header string) (oldStart, newStart int) {
+	oldStart, newStart = 1, 1
+	for _, field := range strings.Fields(header) {
+		if len(field) < 2 || (field[0] != '-' && field[0] != '+') {
+			continue
+		}
+		n, err := strconv.Atoi(strings.SplitN(field[1:], ",", 2)[0])
+		if err != nil {
+			continue
+		}
+		if field[0] == '-' {
+			oldStart = n
+		} else {
+			newStart = n
+		}
+	}
+	return
+}
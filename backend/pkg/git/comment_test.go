@@ -0,0 +1,199 @@
+package git
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocateLine(t *testing.T) {
+	patch := "@@ -1,3 +1,4 @@\n" +
+		" line1\n" +
+		"-old2\n" +
+		"+new2\n" +
+		"+new3\n" +
+		" line3\n" +
+		"@@ -10,2 +11,3 @@\n" +
+		" ctx10\n" +
+		"+add12\n" +
+		" ctx11\n"
+
+	tests := []struct {
+		line int
+		want DiffLine
+		ok   bool
+	}{
+		{1, DiffLine{Position: 1, OldLine: 1, NewLine: 1}, true},
+		{2, DiffLine{Position: 3, NewLine: 2, Added: true}, true},
+		{3, DiffLine{Position: 4, NewLine: 3, Added: true}, true},
+		{4, DiffLine{Position: 5, OldLine: 3, NewLine: 4}, true},
+		// 第二个块头计入 position
+		{11, DiffLine{Position: 7, OldLine: 10, NewLine: 11}, true},
+		{12, DiffLine{Position: 8, NewLine: 12, Added: true}, true},
+		{13, DiffLine{Position: 9, OldLine: 11, NewLine: 13}, true},
+		{5, DiffLine{}, false},
+		{14, DiffLine{}, false},
+		{0, DiffLine{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := LocateLine(patch, tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("LocateLine(%d) = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLocateLineWithFileHeader(t *testing.T) {
+	patch := "diff --git a/x.go b/x.go\nindex 1111111..2222222 100644\n--- a/x.go\n+++ b/x.go\n" +
+		"@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+b\n"
+	got, ok := LocateLine(patch, 1)
+	if want := (DiffLine{Position: 3, NewLine: 1, Added: true}); !ok || got != want {
+		t.Errorf("LocateLine() = %+v, %v; want %+v", got, ok, want)
+	}
+}
+
+func TestLocateLineWithoutHunkHeader(t *testing.T) {
+	// 新增文件等完整内容差异不带块头，从第 1 行开始
+	got, ok := LocateLine("package main\n\nfunc main() {}\n", 3)
+	if want := (DiffLine{Position: 3, OldLine: 3, NewLine: 3}); !ok || got != want {
+		t.Errorf("LocateLine() = %+v, %v; want %+v", got, ok, want)
+	}
+	if _, ok := LocateLine("", 1); ok {
+		t.Error("empty patch should not locate any line")
+	}
+}
+
+// recordedRequest 测试服务器收到的请求
+type recordedRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   map[string]interface{}
+}
+
+// newRecordingServer 记录请求并按 "方法 路径" 返回预设响应，未预设的返回 404
+func newRecordingServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var requests []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := recordedRequest{Method: r.Method, Path: r.URL.Path, Header: r.Header}
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			json.Unmarshal(data, &rec.Body)
+		}
+		requests = append(requests, rec)
+		resp, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestGitHubComments(t *testing.T) {
+	srv, requests := newRecordingServer(t, map[string]string{
+		"GET /repos/acme/demo/commits/abc/pulls":     `[{"number":3,"state":"closed"},{"number":7,"state":"open","html_url":"https://github.com/acme/demo/pull/7","head":{"sha":"abc"}}]`,
+		"POST /repos/acme/demo/commits/abc/comments": `{"id":41}`,
+		"POST /repos/acme/demo/pulls/7/comments":     `{"id":42}`,
+		"POST /repos/acme/demo/issues/7/comments":    `{"id":43}`,
+	})
+	c := NewClient(srv.URL, "tok", "acme", "demo")
+
+	pr, err := c.FindPullRequest("abc")
+	if err != nil || pr == nil || pr.Number != 7 || pr.HeadSHA != "abc" {
+		t.Fatalf("FindPullRequest() = %+v, %v", pr, err)
+	}
+	if got := (*requests)[0].Header.Get("Authorization"); got != "Bearer tok" {
+		t.Errorf("Authorization = %q", got)
+	}
+
+	line := DiffLine{Position: 5, OldLine: 3, NewLine: 4}
+	id, err := c.CreateCommitComment("abc", Comment{Path: "a.go", Body: "b", Line: line})
+	if err != nil || id != "41" {
+		t.Fatalf("CreateCommitComment() = %q, %v", id, err)
+	}
+	// 提交评论按差异中的 position 定位
+	if body := (*requests)[1].Body; body["path"] != "a.go" || body["position"] != float64(5) || body["line"] != nil {
+		t.Errorf("commit comment body = %v", body)
+	}
+
+	id, err = c.CreatePullRequestComment(pr, "abc", Comment{Path: "a.go", Body: "b", Line: line})
+	if err != nil || id != "42" {
+		t.Fatalf("CreatePullRequestComment() = %q, %v", id, err)
+	}
+	// PR 行评论按变更后的行号定位
+	if body := (*requests)[2].Body; body["commit_id"] != "abc" || body["line"] != float64(4) || body["side"] != "RIGHT" || body["position"] != nil {
+		t.Errorf("review comment body = %v", body)
+	}
+
+	id, err = c.CreatePullRequestComment(pr, "abc", Comment{Body: "summary"})
+	if err != nil || id != "43" {
+		t.Fatalf("CreatePullRequestComment(summary) = %q, %v", id, err)
+	}
+
+	err = c.UpdatePullRequestComment(pr, "43", "new")
+	if !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("UpdatePullRequestComment() error = %v, want ErrCommentNotFound", err)
+	}
+	if last := (*requests)[len(*requests)-1]; last.Method != http.MethodPatch || last.Path != "/repos/acme/demo/issues/comments/43" {
+		t.Errorf("update request = %s %s", last.Method, last.Path)
+	}
+}
+
+func TestGitLabComments(t *testing.T) {
+	srv, requests := newRecordingServer(t, map[string]string{
+		"GET /projects/9/repository/commits/abc/merge_requests":          `[{"iid":3,"state":"merged"},{"iid":5,"state":"opened","web_url":"https://gitlab.com/acme/demo/-/merge_requests/5"}]`,
+		"GET /projects/9/merge_requests/5":                               `{"diff_refs":{"base_sha":"b1","start_sha":"s1","head_sha":"abc"}}`,
+		"POST /projects/9/merge_requests/5/discussions":                  `{"id":"d5"}`,
+		"POST /projects/9/repository/commits/abc/discussions":            `{"id":"d1","notes":[{"id":11}]}`,
+		"PUT /projects/9/repository/commits/abc/discussions/d1/notes/11": `{}`,
+	})
+	c := NewGitLabClient(srv.URL, "tok", "9")
+
+	pr, err := c.FindPullRequest("abc")
+	if err != nil || pr == nil || pr.Number != 5 || pr.BaseSHA != "b1" || pr.StartSHA != "s1" || pr.HeadSHA != "abc" {
+		t.Fatalf("FindPullRequest() = %+v, %v", pr, err)
+	}
+	if got := (*requests)[0].Header.Get("PRIVATE-TOKEN"); got != "tok" {
+		t.Errorf("PRIVATE-TOKEN = %q", got)
+	}
+
+	// 上下文行同时带变更前后的行号，新增行只带变更后的行号
+	tests := []struct {
+		line    DiffLine
+		oldLine interface{}
+	}{
+		{DiffLine{Position: 5, OldLine: 3, NewLine: 4}, float64(3)},
+		{DiffLine{Position: 3, NewLine: 2, Added: true}, nil},
+	}
+	for _, tt := range tests {
+		id, err := c.CreatePullRequestComment(pr, "abc", Comment{Path: "a.go", Body: "b", Line: tt.line})
+		if err != nil || id != "d5" {
+			t.Fatalf("CreatePullRequestComment() = %q, %v", id, err)
+		}
+		position, _ := (*requests)[len(*requests)-1].Body["position"].(map[string]interface{})
+		if position["base_sha"] != "b1" || position["head_sha"] != "abc" || position["new_path"] != "a.go" ||
+			position["new_line"] != float64(tt.line.NewLine) || position["old_line"] != tt.oldLine {
+			t.Errorf("position = %v", position)
+		}
+	}
+
+	id, err := c.CreateCommitComment("abc", Comment{Body: "summary"})
+	if err != nil || id != "d1/11" {
+		t.Fatalf("CreateCommitComment() = %q, %v", id, err)
+	}
+	if err := c.UpdateCommitComment("abc", id, "new"); err != nil {
+		t.Errorf("UpdateCommitComment() error = %v", err)
+	}
+	if err := c.UpdateCommitComment("abc", "d1/12", "new"); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("UpdateCommitComment(deleted) error = %v, want ErrCommentNotFound", err)
+	}
+	if err := c.UpdateCommitComment("abc", "invalid", "new"); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("UpdateCommitComment(invalid id) error = %v, want ErrCommentNotFound", err)
+	}
+}
@@ -0,0 +1,394 @@
+package git
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// mirrorCommitRefPrefix 按提交 SHA 拉取时保存的引用，使提交在维护时保持可达，后续拉取可增量进行
+const mirrorCommitRefPrefix = "refs/commits/"
+
+// mirrorGCPacks 镜像的包文件数量达到该值时合并为一个包（每次增量拉取产生一个包）
+const mirrorGCPacks = 20
+
+// mirrorPruneAge 清理不可达的松散对象时保留的时长，避免删除正在写入的对象
+const mirrorPruneAge = time.Hour
+
+// ErrCommitNotFound 拉取后镜像中仍没有需要的提交
+var ErrCommitNotFound = errors.New("commit not found in repository")
+
+// MirrorCache 仓库的磁盘裸镜像缓存
+// 每个仓库地址对应一个裸仓库，需要的提交已存在时直接读取，否则增量拉取；
+// 同一镜像的读取可以并发，拉取和维护独占；总大小超出上限时按最近使用时间淘汰镜像。
+// 锁只在进程内有效，缓存目录只能由一个服务进程使用，多个实例需配置不同的目录。
+type MirrorCache struct {
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	locks map[string]*sync.RWMutex // 镜像目录到读写锁，镜像淘汰时删除
+}
+
+// MirrorRequest 打开镜像时需要的引用和提交
+type MirrorRequest struct {
+	URL     string
+	Auth    transport.AuthMethod
+	Refs    []string // 优先拉取的引用，如推送的分支，可为空
+	Commits []string // 必须存在的提交
+}
+
+// MaintainStats 一次维护的结果
+type MaintainStats struct {
+	Mirrors  int     // 维护前的镜像数量
+	Repacked int     // 合并了包文件的镜像数量
+	Pruned   int     // 清理的松散对象数量
+	Evicted  int     // 因超出容量淘汰的镜像数量
+	Size     int64   // 维护后的总大小（字节）
+	Errors   []error // 单个镜像维护失败的错误，不影响其他镜像
+}
+
+// NewMirrorCache 创建镜像缓存，maxBytes 为 0 表示不限制大小
+func NewMirrorCache(dir string, maxBytes int64) (*MirrorCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mirror dir: %w", err)
+	}
+	return &MirrorCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		locks:    make(map[string]*sync.RWMutex),
+	}, nil
+}
+
+// Open 打开仓库镜像并确保包含需要的提交，返回的 release 必须在读取完成后调用
+// 提交都已存在时不访问远端；否则依次尝试拉取指定引用、按提交 SHA 拉取（需服务端支持）、拉取所有分支和标签。
+func (m *MirrorCache) Open(req MirrorRequest) (*git.Repository, func(), error) {
+	path := m.mirrorPath(req.URL)
+
+	lock := m.acquire(path, true)
+	if r, err := git.PlainOpen(path); err == nil && hasCommits(r, req.Commits) {
+		touch(path)
+		return r, lock.RUnlock, nil
+	}
+	lock.RUnlock()
+
+	lock = m.acquire(path, false)
+	r, err := m.fetch(path, req)
+	if err != nil {
+		lock.Unlock()
+		return nil, nil, err
+	}
+	touch(path)
+	m.evict(path)
+	return r, lock.Unlock, nil
+}
+
+// fetch 初始化镜像并增量拉取缺少的提交，调用方持有镜像的写锁
+func (m *MirrorCache) fetch(path string, req MirrorRequest) (*git.Repository, error) {
+	r, err := git.PlainOpen(path)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		r, err = git.PlainInit(path, true)
+		if err == nil {
+			_, err = r.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{req.URL}})
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mirror: %w", err)
+	}
+	// 等待写锁期间其他任务可能已经拉取过
+	if hasCommits(r, req.Commits) {
+		return r, nil
+	}
+
+	var lastErr error
+	attempts := [][]config.RefSpec{refSpecs(req.Refs), commitRefSpecs(r, req.Commits), {
+		"+refs/heads/*:refs/heads/*",
+		"+refs/tags/*:refs/tags/*",
+	}}
+	for _, specs := range attempts {
+		if len(specs) == 0 {
+			continue
+		}
+		err := r.Fetch(&git.FetchOptions{RefSpecs: specs, Auth: req.Auth, Tags: git.NoTags, Force: true})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			lastErr = err
+			continue
+		}
+		lastErr = nil
+		if hasCommits(r, req.Commits) {
+			return r, nil
+		}
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("failed to fetch mirror: %w", lastErr)
+	}
+	return nil, ErrCommitNotFound
+}
+
+// refSpecs 引用对应的拉取规则，分支名补全为 refs/heads/
+func refSpecs(refs []string) []config.RefSpec {
+	var specs []config.RefSpec
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+		if !strings.HasPrefix(ref, "refs/") {
+			ref = "refs/heads/" + ref
+		}
+		specs = append(specs, config.RefSpec("+"+ref+":"+ref))
+	}
+	return specs
+}
+
+// commitRefSpecs 缺少的提交按 SHA 拉取的规则
+func commitRefSpecs(r *git.Repository, commits []string) []config.RefSpec {
+	var specs []config.RefSpec
+	for _, sha := range commits {
+		if plumbing.IsHash(sha) && !hasCommits(r, []string{sha}) {
+			specs = append(specs, config.RefSpec("+"+sha+":"+mirrorCommitRefPrefix+sha))
+		}
+	}
+	return specs
+}
+
+// hasCommits 镜像中是否包含所有提交
+func hasCommits(r *git.Repository, commits []string) bool {
+	for _, sha := range commits {
+		if _, err := r.CommitObject(plumbing.NewHash(sha)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Maintain 维护所有镜像：包文件过多时合并、清理不可达的松散对象，并按容量淘汰最久未使用的镜像
+// 正在被任务使用的镜像跳过，下次维护时处理。
+func (m *MirrorCache) Maintain() MaintainStats {
+	var stats MaintainStats
+	mirrors, err := m.mirrors()
+	if err != nil {
+		stats.Errors = append(stats.Errors, err)
+		return stats
+	}
+	stats.Mirrors = len(mirrors)
+
+	for _, info := range mirrors {
+		lock := m.tryAcquire(info.path)
+		if lock == nil {
+			continue
+		}
+		repacked, pruned, err := gcMirror(info.path)
+		lock.Unlock()
+		if err != nil {
+			stats.Errors = append(stats.Errors, fmt.Errorf("%s: %w", filepath.Base(info.path), err))
+			continue
+		}
+		if repacked {
+			stats.Repacked++
+		}
+		stats.Pruned += pruned
+	}
+
+	stats.Evicted = m.evict("")
+	if mirrors, err := m.mirrors(); err == nil {
+		for _, info := range mirrors {
+			stats.Size += info.size
+		}
+	}
+	return stats
+}
+
+// gcMirror 合并包文件并清理不可达的松散对象
+func gcMirror(path string) (repacked bool, pruned int, err error) {
+	r, err := git.PlainOpen(path)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if pos, ok := r.Storer.(storer.PackedObjectStorer); ok {
+		packs, err := pos.ObjectPacks()
+		if err != nil {
+			return false, 0, err
+		}
+		if len(packs) >= mirrorGCPacks {
+			if err := r.RepackObjects(&git.RepackConfig{}); err != nil {
+				return false, 0, err
+			}
+			repacked = true
+		}
+	}
+
+	err = r.Prune(git.PruneOptions{
+		OnlyObjectsOlderThan: time.Now().Add(-mirrorPruneAge),
+		Handler: func(hash plumbing.Hash) error {
+			pruned++
+			return r.DeleteObject(hash)
+		},
+	})
+	if errors.Is(err, git.ErrLooseObjectsNotSupported) {
+		err = nil
+	}
+	return repacked, pruned, err
+}
+
+// mirrorInfo 镜像目录的大小和最近使用时间
+type mirrorInfo struct {
+	path string
+	size int64
+	used time.Time
+}
+
+// mirrors 列出缓存目录中的镜像
+func (m *MirrorCache) mirrors() ([]mirrorInfo, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, err
+	}
+	var mirrors []mirrorInfo
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasSuffix(entry.Name(), ".git") {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(m.dir, entry.Name())
+		mirrors = append(mirrors, mirrorInfo{path: path, size: dirSize(path), used: fi.ModTime()})
+	}
+	return mirrors, nil
+}
+
+// evict 总大小超出上限时按最近使用时间淘汰镜像，keep 为当前任务使用的镜像，正在使用的镜像不淘汰
+func (m *MirrorCache) evict(keep string) int {
+	if m.maxBytes <= 0 {
+		return 0
+	}
+	mirrors, err := m.mirrors()
+	if err != nil {
+		return 0
+	}
+	var total int64
+	for _, info := range mirrors {
+		total += info.size
+	}
+	sort.Slice(mirrors, func(i, j int) bool { return mirrors[i].used.Before(mirrors[j].used) })
+
+	evicted := 0
+	for _, info := range mirrors {
+		if total <= m.maxBytes {
+			break
+		}
+		if info.path == keep {
+			continue
+		}
+		lock := m.tryAcquire(info.path)
+		if lock == nil {
+			continue
+		}
+		if err := os.RemoveAll(info.path); err == nil {
+			total -= info.size
+			evicted++
+			m.mu.Lock()
+			delete(m.locks, info.path)
+			m.mu.Unlock()
+		}
+		lock.Unlock()
+	}
+	return evicted
+}
+
+// mirrorPath 仓库地址对应的镜像目录
+func (m *MirrorCache) mirrorPath(repoURL string) string {
+	sum := sha1.Sum([]byte(strings.TrimSuffix(strings.TrimSpace(repoURL), "/")))
+	return filepath.Join(m.dir, hex.EncodeToString(sum[:10])+".git")
+}
+
+// lock 镜像当前的读写锁，不存在时创建
+func (m *MirrorCache) lock(path string) *sync.RWMutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.locks[path]
+	if !ok {
+		l = &sync.RWMutex{}
+		m.locks[path] = l
+	}
+	return l
+}
+
+// current 锁是否仍是镜像当前的锁，镜像被淘汰后旧锁从 locks 中删除
+func (m *MirrorCache) current(path string, l *sync.RWMutex) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.locks[path] == l
+}
+
+// acquire 获取镜像的读锁或写锁
+// 等待期间镜像可能被淘汰、锁被替换，此时释放旧锁并重新获取，保证同一镜像只有一把有效的锁。
+func (m *MirrorCache) acquire(path string, shared bool) *sync.RWMutex {
+	for {
+		l := m.lock(path)
+		if shared {
+			l.RLock()
+		} else {
+			l.Lock()
+		}
+		if m.current(path, l) {
+			return l
+		}
+		if shared {
+			l.RUnlock()
+		} else {
+			l.Unlock()
+		}
+	}
+}
+
+// tryAcquire 尝试获取镜像的写锁，镜像正在使用时返回 nil
+func (m *MirrorCache) tryAcquire(path string) *sync.RWMutex {
+	l := m.lock(path)
+	if !l.TryLock() {
+		return nil
+	}
+	if !m.current(path, l) {
+		l.Unlock()
+		return nil
+	}
+	return l
+}
+
+// touch 更新镜像目录的修改时间，作为最近使用时间
+func touch(path string) {
+	now := time.Now()
+	os.Chtimes(path, now, now)
+}
+
+// dirSize 目录中所有文件的大小
+func dirSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if fi, err := d.Info(); err == nil {
+			size += fi.Size()
+		}
+		return nil
+	})
+	return size
+}
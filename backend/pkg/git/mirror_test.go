@@ -0,0 +1,146 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// newSourceRepo 创建带一个提交的本地仓库，返回仓库路径和提交 SHA
+func newSourceRepo(t *testing.T, content string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	r, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("main.go"); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	hash, err := wt.Commit("init", &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, hash.String()
+}
+
+func TestMirrorCacheOpen(t *testing.T) {
+	src, sha := newSourceRepo(t, "package main\n")
+	m, err := NewMirrorCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, release, err := m.Open(MirrorRequest{URL: src, Refs: []string{"master"}, Commits: []string{sha}})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if !hasCommits(r, []string{sha}) {
+		t.Error("mirror does not contain the commit")
+	}
+	release()
+
+	// 提交已存在时不访问远端
+	if err := os.RemoveAll(src); err != nil {
+		t.Fatal(err)
+	}
+	if _, release, err := m.Open(MirrorRequest{URL: src, Commits: []string{sha}}); err != nil {
+		t.Fatalf("Open() from cache error = %v", err)
+	} else {
+		release()
+	}
+}
+
+func TestMirrorCacheCommitNotFound(t *testing.T) {
+	src, _ := newSourceRepo(t, "package main\n")
+	m, err := NewMirrorCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing := strings.Repeat("1", 40)
+	if _, _, err := m.Open(MirrorRequest{URL: src, Commits: []string{missing}}); !errors.Is(err, ErrCommitNotFound) {
+		t.Errorf("Open() error = %v, want ErrCommitNotFound", err)
+	}
+}
+
+func TestMirrorCacheEvict(t *testing.T) {
+	m, err := NewMirrorCache(t.TempDir(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcA, shaA := newSourceRepo(t, "package a\n")
+	srcB, shaB := newSourceRepo(t, "package b\n")
+
+	_, release, err := m.Open(MirrorRequest{URL: srcA, Commits: []string{shaA}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	// 打开第二个镜像时超出容量，淘汰最久未使用的镜像，当前使用的镜像保留
+	_, release, err = m.Open(MirrorRequest{URL: srcB, Commits: []string{shaB}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	if _, err := os.Stat(m.mirrorPath(srcA)); !os.IsNotExist(err) {
+		t.Errorf("mirror A should be evicted, stat error = %v", err)
+	}
+	if _, err := os.Stat(m.mirrorPath(srcB)); err != nil {
+		t.Errorf("mirror B should be kept: %v", err)
+	}
+	// 淘汰的镜像的锁一并删除
+	if _, ok := m.locks[m.mirrorPath(srcA)]; ok || len(m.locks) != 1 {
+		t.Errorf("locks = %v, want only mirror B", m.locks)
+	}
+
+	stats := m.Maintain()
+	if stats.Mirrors != 1 || stats.Evicted != 1 || len(stats.Errors) != 0 {
+		t.Errorf("Maintain() = %+v", stats)
+	}
+	if len(m.locks) != 0 {
+		t.Errorf("locks = %v, want empty after eviction", m.locks)
+	}
+}
+
+func TestMirrorCacheSkipsMirrorInUse(t *testing.T) {
+	src, sha := newSourceRepo(t, "package main\n")
+	m, err := NewMirrorCache(t.TempDir(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, release, err := m.Open(MirrorRequest{URL: src, Commits: []string{sha}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 正在读取的镜像不淘汰
+	if stats := m.Maintain(); stats.Evicted != 0 {
+		t.Errorf("Maintain() evicted %d mirrors in use", stats.Evicted)
+	}
+	release()
+	if stats := m.Maintain(); stats.Evicted != 1 {
+		t.Errorf("Maintain() evicted %d, want 1", stats.Evicted)
+	}
+
+	// 淘汰后再次打开重新拉取，并使用新的锁
+	_, release, err = m.Open(MirrorRequest{URL: src, Commits: []string{sha}})
+	if err != nil {
+		t.Fatalf("Open() after eviction error = %v", err)
+	}
+	release()
+}
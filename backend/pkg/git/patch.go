@@ -0,0 +1,220 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultContextLines 统一差异中变更前后默认保留的上下文行数
+const DefaultContextLines = 3
+
+// DiffHunk 差异块在变更前后文件中的行号范围
+type DiffHunk struct {
+	OldStart int `json:"old_start"`
+	OldLines int `json:"old_lines"`
+	NewStart int `json:"new_start"`
+	NewLines int `json:"new_lines"`
+}
+
+// ParseHunks 解析统一差异中各块的行号范围，不带块头的差异返回 nil
+func ParseHunks(patch string) []DiffHunk {
+	var hunks []DiffHunk
+	for _, line := range strings.Split(patch, "\n") {
+		if !strings.HasPrefix(line, "@@") {
+			continue
+		}
+		hunk := DiffHunk{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1}
+		for _, field := range strings.Fields(strings.TrimPrefix(line, "@@")) {
+			if field == "@@" {
+				break
+			}
+			if len(field) < 2 || (field[0] != '-' && field[0] != '+') {
+				continue
+			}
+			start, count, _ := strings.Cut(field[1:], ",")
+			n, err := strconv.Atoi(start)
+			if err != nil {
+				continue
+			}
+			lines := 1
+			if count != "" {
+				lines, _ = strconv.Atoi(count)
+			}
+			if field[0] == '-' {
+				hunk.OldStart, hunk.OldLines = n, lines
+			} else {
+				hunk.NewStart, hunk.NewLines = n, lines
+			}
+		}
+		hunks = append(hunks, hunk)
+	}
+	return hunks
+}
+
+// PatchChunk 按大小拆分后的一段差异
+type PatchChunk struct {
+	Patch     string
+	StartLine int // 该段在变更后文件中的起始行号
+	EndLine   int // 该段在变更后文件中的结束行号
+}
+
+// patchUnit 拆分的最小单位：一个完整的块，或超出上限的块按行拆开的一部分
+type patchUnit struct {
+	text  string
+	size  int
+	start int
+	end   int
+}
+
+// SplitPatch 在块边界（@@）处把差异拆分为多段，每段大小不超过 maxSize，size 计算文本的大小
+// 单个块超出上限或差异不带块头时按行拆分，拆开的部分补上块头以保留行号；文件头在每段中重复。
+func SplitPatch(patch string, maxSize int, size func(string) int) []PatchChunk {
+	lines := strings.Split(strings.TrimSuffix(patch, "\n"), "\n")
+
+	var fileHeader []string
+	var units []patchUnit
+	if hasDiffHeader(patch) {
+		i := 0
+		for i < len(lines) && !strings.HasPrefix(lines[i], "@@") {
+			fileHeader = append(fileHeader, lines[i])
+			i++
+		}
+		for i < len(lines) {
+			j := i + 1
+			for j < len(lines) && !strings.HasPrefix(lines[j], "@@") {
+				j++
+			}
+			oldStart, newStart := parseHunkHeader(lines[i])
+			units = append(units, splitHunk(lines[i], lines[i+1:j], oldStart, newStart, maxSize, size)...)
+			i = j
+		}
+	} else {
+		// 不带块头的完整文件差异从第 1 行开始
+		units = splitHunk("", lines, 1, 1, maxSize, size)
+	}
+
+	header := ""
+	if len(fileHeader) > 0 {
+		header = strings.Join(fileHeader, "\n") + "\n"
+	}
+	headerSize := size(header)
+
+	total := headerSize
+	for _, u := range units {
+		total += u.size
+	}
+	if total <= maxSize || len(units) <= 1 {
+		chunk := PatchChunk{Patch: patch, StartLine: 1, EndLine: 1}
+		if len(units) > 0 {
+			chunk.StartLine, chunk.EndLine = units[0].start, units[len(units)-1].end
+		}
+		return []PatchChunk{chunk}
+	}
+
+	var chunks []PatchChunk
+	var b strings.Builder
+	var current PatchChunk
+	currentSize := 0
+	for _, u := range units {
+		if b.Len() > 0 && currentSize+u.size > maxSize {
+			current.Patch = b.String()
+			chunks = append(chunks, current)
+			b.Reset()
+		}
+		if b.Len() == 0 {
+			b.WriteString(header)
+			current = PatchChunk{StartLine: u.start}
+			currentSize = headerSize
+		}
+		b.WriteString(u.text)
+		currentSize += u.size
+		current.EndLine = u.end
+	}
+	current.Patch = b.String()
+	return append(chunks, current)
+}
+
+// splitHunk 块不超过上限时原样保留，否则按行拆分并为每部分生成块头
+func splitHunk(header string, body []string, oldStart, newStart, maxSize int, size func(string) int) []patchUnit {
+	whole := strings.Join(body, "\n") + "\n"
+	if header != "" {
+		whole = header + "\n" + whole
+	}
+	if s := size(whole); s <= maxSize {
+		_, newCount := countHunkLines(body)
+		return []patchUnit{{text: whole, size: s, start: newStart, end: hunkEnd(newStart, newCount)}}
+	}
+
+	// 为块头预留的大小
+	headerSize := size(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart+len(body), len(body), newStart+len(body), len(body)))
+
+	var units []patchUnit
+	var piece []string
+	pieceSize := headerSize
+	flush := func() {
+		if len(piece) == 0 {
+			return
+		}
+		oldCount, newCount := countHunkLines(piece)
+		text := fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount) + strings.Join(piece, "\n") + "\n"
+		units = append(units, patchUnit{text: text, size: pieceSize, start: newStart, end: hunkEnd(newStart, newCount)})
+		oldStart += oldCount
+		newStart += newCount
+		piece = nil
+		pieceSize = headerSize
+	}
+	for _, line := range body {
+		lineSize := size(line + "\n")
+		if len(piece) > 0 && pieceSize+lineSize > maxSize {
+			flush()
+		}
+		piece = append(piece, line)
+		pieceSize += lineSize
+	}
+	flush()
+	return units
+}
+
+// countHunkLines 统计块内容在变更前后各占的行数
+func countHunkLines(body []string) (oldCount, newCount int) {
+	for _, line := range body {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			newCount++
+		case strings.HasPrefix(line, "-"):
+			oldCount++
+		case strings.HasPrefix(line, "\\"):
+			// \ No newline at end of file
+		default:
+			oldCount++
+			newCount++
+		}
+	}
+	return
+}
+
+// countPatchLines 统计差异中新增和删除的行数，不计文件头
+func countPatchLines(patch string) (additions, deletions int) {
+	inHunk := false
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk:
+		case strings.HasPrefix(line, "+"):
+			additions++
+		case strings.HasPrefix(line, "-"):
+			deletions++
+		}
+	}
+	return
+}
+
+// hunkEnd 块在变更后文件中的结束行号，只有删除行的块取起始行号
+func hunkEnd(newStart, newCount int) int {
+	if newCount == 0 {
+		return newStart
+	}
+	return newStart + newCount - 1
+}
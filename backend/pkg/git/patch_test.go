@@ -0,0 +1,154 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func byteSize(s string) int { return len(s) }
+
+// newLines 变更后文件的行号到行内容的映射，用于检查拆分后的块头行号
+func newLines(patch string) map[int]string {
+	lines := map[int]string{}
+	newLine := 1
+	inHunk := !hasDiffHeader(patch)
+	for _, text := range strings.Split(strings.TrimSuffix(patch, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(text, "@@"):
+			_, newLine = parseHunkHeader(text)
+			inHunk = true
+		case !inHunk, strings.HasPrefix(text, "-"), strings.HasPrefix(text, "\\"):
+		case strings.HasPrefix(text, "+"):
+			lines[newLine] = text[1:]
+			newLine++
+		default:
+			lines[newLine] = strings.TrimPrefix(text, " ")
+			newLine++
+		}
+	}
+	return lines
+}
+
+// checkChunks 每段不超过上限，且每段中的行号与原差异一致
+func checkChunks(t *testing.T, patch string, chunks []PatchChunk, maxSize int) {
+	t.Helper()
+	want := newLines(patch)
+	seen := 0
+	for i, chunk := range chunks {
+		if len(chunk.Patch) > maxSize {
+			t.Errorf("chunk %d size %d exceeds %d", i, len(chunk.Patch), maxSize)
+		}
+		got := newLines(chunk.Patch)
+		for line, text := range got {
+			if want[line] != text {
+				t.Errorf("chunk %d line %d = %q, want %q", i, line, text, want[line])
+			}
+			if line < chunk.StartLine || line > chunk.EndLine {
+				t.Errorf("chunk %d line %d outside [%d, %d]", i, line, chunk.StartLine, chunk.EndLine)
+			}
+		}
+		seen += len(got)
+	}
+	if seen != len(want) {
+		t.Errorf("chunks cover %d lines, want %d", seen, len(want))
+	}
+}
+
+func TestSplitPatchFits(t *testing.T) {
+	patch := "@@ -1,2 +1,3 @@\n a\n+b\n c\n"
+	chunks := SplitPatch(patch, 1000, byteSize)
+	if len(chunks) != 1 || chunks[0].Patch != patch || chunks[0].StartLine != 1 || chunks[0].EndLine != 3 {
+		t.Fatalf("chunks = %+v", chunks)
+	}
+}
+
+func TestSplitPatchAtHunks(t *testing.T) {
+	header := "diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n"
+	var b strings.Builder
+	b.WriteString(header)
+	for i := 0; i < 4; i++ {
+		start := 1 + i*20
+		fmt.Fprintf(&b, "@@ -%d,3 +%d,4 @@\n ctx%d\n-old%d\n+new%d\n+add%d\n ctx%d\n", start, start, i, i, i, i, i)
+	}
+	patch := b.String()
+
+	maxSize := len(header) + 90
+	chunks := SplitPatch(patch, maxSize, byteSize)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want at least 2", len(chunks))
+	}
+	for i, chunk := range chunks {
+		// 文件头在每段中重复，拆分点在块边界
+		if !strings.HasPrefix(chunk.Patch, header+"@@ ") {
+			t.Errorf("chunk %d does not start with file header and hunk header: %q", i, chunk.Patch)
+		}
+	}
+	if chunks[0].StartLine != 1 || chunks[len(chunks)-1].EndLine != 64 {
+		t.Errorf("range = %d..%d, want 1..64", chunks[0].StartLine, chunks[len(chunks)-1].EndLine)
+	}
+	checkChunks(t, patch, chunks, maxSize)
+}
+
+func TestSplitPatchLargeHunk(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("@@ -10,30 +10,40 @@ func main() {\n")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&b, " context line %02d\n", i)
+		if i%3 == 0 {
+			fmt.Fprintf(&b, "+added line %02d\n", i)
+		}
+		if i%10 == 0 {
+			fmt.Fprintf(&b, "-removed line %02d\n", i)
+		}
+	}
+	patch := b.String()
+
+	chunks := SplitPatch(patch, 200, byteSize)
+	if len(chunks) < 3 {
+		t.Fatalf("got %d chunks, want at least 3", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if !strings.HasPrefix(chunk.Patch, "@@ -") {
+			t.Errorf("chunk %d missing hunk header: %q", i, chunk.Patch)
+		}
+		if i > 0 && chunk.StartLine != chunks[i-1].EndLine+1 {
+			t.Errorf("chunk %d starts at %d, previous ended at %d", i, chunk.StartLine, chunks[i-1].EndLine)
+		}
+	}
+	checkChunks(t, patch, chunks, 200)
+}
+
+func TestSplitPatchWithoutHeader(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 50; i++ {
+		fmt.Fprintf(&b, "line %02d of a new file\n", i)
+	}
+	patch := b.String()
+
+	chunks := SplitPatch(patch, 300, byteSize)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want at least 2", len(chunks))
+	}
+	if !strings.HasPrefix(chunks[0].Patch, "@@ -1,") || chunks[0].StartLine != 1 || chunks[len(chunks)-1].EndLine != 50 {
+		t.Errorf("first chunk = %+v, last = %+v", chunks[0], chunks[len(chunks)-1])
+	}
+	checkChunks(t, patch, chunks, 300)
+}
+
+func TestParseHunks(t *testing.T) {
+	patch := "--- a/x\n+++ b/x\n@@ -1,3 +1,4 @@ func a() {\n a\n+b\n@@ -20 +21,0 @@\n-c\n"
+	got := ParseHunks(patch)
+	want := []DiffHunk{{1, 3, 1, 4}, {20, 1, 21, 0}}
+	if len(got) != len(want) {
+		t.Fatalf("ParseHunks() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("hunk %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if hunks := ParseHunks("no header\n"); hunks != nil {
+		t.Errorf("ParseHunks(no header) = %+v, want nil", hunks)
+	}
+}
@@ -0,0 +1,188 @@
+package git
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ErrNoKnownHosts 没有可用于校验平台主机公钥的 known_hosts
+var ErrNoKnownHosts = errors.New("未配置 SSH 主机公钥（known_hosts）")
+
+// HostKey 扫描到的平台主机公钥
+type HostKey struct {
+	Line        string `json:"line"`        // known_hosts 格式的一行
+	Type        string `json:"type"`        // 密钥类型，如 ssh-ed25519
+	Fingerprint string `json:"fingerprint"` // SHA256 指纹，应与平台公布的指纹核对
+}
+
+// IsSSHURL 仓库地址是否为 SSH 地址（git@host:path 或 ssh://）
+func IsSSHURL(repoURL string) bool {
+	return strings.HasPrefix(repoURL, "git@") || strings.HasPrefix(repoURL, "ssh://")
+}
+
+// GenerateDeployKey 生成 ed25519 部署密钥，返回 OpenSSH 格式的私钥和 authorized_keys 格式的公钥
+func GenerateDeployKey(comment string) (privateKey, publicKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	block, err := ssh.MarshalPrivateKey(priv, comment)
+	if err != nil {
+		return "", "", err
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return "", "", err
+	}
+	publicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub)))
+	if comment != "" {
+		publicKey += " " + comment
+	}
+	return string(pem.EncodeToMemory(block)), publicKey, nil
+}
+
+// ValidateKnownHosts 校验 known_hosts 内容的格式
+func ValidateKnownHosts(content string) error {
+	rest := []byte(content)
+	for len(strings.TrimSpace(string(rest))) > 0 {
+		var err error
+		_, _, _, _, rest, err = ssh.ParseKnownHosts(rest)
+		if err != nil {
+			return fmt.Errorf("invalid known_hosts: %w", err)
+		}
+	}
+	return nil
+}
+
+// SSHAuth 使用部署密钥的 SSH 认证，按仓库的 known_hosts 和全局 known_hosts 文件校验平台主机公钥
+// 两者都没有时返回 ErrNoKnownHosts，不会跳过主机校验。
+func SSHAuth(repoURL, privateKey, knownHosts, knownHostsFile string) (*gitssh.PublicKeys, error) {
+	auth, err := gitssh.NewPublicKeys(sshUser(repoURL), []byte(privateKey), "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh key: %w", err)
+	}
+	callback, err := hostKeyCallback(knownHosts, knownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+	auth.HostKeyCallback = callback
+	return auth, nil
+}
+
+// hostKeyCallback 合并仓库的 known_hosts 和全局 known_hosts 文件
+func hostKeyCallback(knownHosts, knownHostsFile string) (ssh.HostKeyCallback, error) {
+	var files []string
+	if strings.TrimSpace(knownHosts) != "" {
+		// knownhosts 只能从文件读取，创建时读入后即可删除
+		f, err := os.CreateTemp("", "known_hosts")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(f.Name())
+		_, err = f.WriteString(knownHosts + "\n")
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f.Name())
+	}
+	if knownHostsFile == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+		}
+	}
+	if knownHostsFile != "" {
+		if _, err := os.Stat(knownHostsFile); err == nil {
+			files = append(files, knownHostsFile)
+		}
+	}
+	if len(files) == 0 {
+		return nil, ErrNoKnownHosts
+	}
+	return knownhosts.New(files...)
+}
+
+// ScanHostKeys 连接平台的 SSH 服务获取主机公钥，用于确认后写入 known_hosts
+// 扫描结果未经校验，需与平台公布的指纹核对。
+func ScanHostKeys(repoURL string) ([]HostKey, error) {
+	addr, err := sshAddr(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []HostKey
+	seen := make(map[string]bool)
+	for _, algo := range []string{ssh.KeyAlgoED25519, ssh.KeyAlgoECDSA256, ssh.KeyAlgoRSASHA256} {
+		var key ssh.PublicKey
+		config := &ssh.ClientConfig{
+			User:              sshUser(repoURL),
+			HostKeyAlgorithms: []string{algo},
+			HostKeyCallback: func(_ string, _ net.Addr, k ssh.PublicKey) error {
+				key = k
+				// 只需要主机公钥，不继续认证
+				return errHostKeyScanned
+			},
+			Timeout: 10 * time.Second,
+		}
+		_, err := ssh.Dial("tcp", addr, config)
+		if key == nil {
+			if len(keys) == 0 && !strings.Contains(err.Error(), "no common algorithm") {
+				return nil, fmt.Errorf("failed to connect %s: %w", addr, err)
+			}
+			continue
+		}
+		line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, key)
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		keys = append(keys, HostKey{Line: line, Type: key.Type(), Fingerprint: ssh.FingerprintSHA256(key)})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no supported host key found on %s", addr)
+	}
+	return keys, nil
+}
+
+var errHostKeyScanned = errors.New("host key scanned")
+
+// sshAddr 仓库 SSH 地址的主机和端口
+func sshAddr(repoURL string) (string, error) {
+	if strings.HasPrefix(repoURL, "git@") {
+		host, _, ok := strings.Cut(strings.TrimPrefix(repoURL, "git@"), ":")
+		if !ok || host == "" {
+			return "", fmt.Errorf("invalid ssh url: %s", repoURL)
+		}
+		return net.JoinHostPort(host, "22"), nil
+	}
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Scheme != "ssh" || u.Hostname() == "" {
+		return "", fmt.Errorf("invalid ssh url: %s", repoURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "22"
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// sshUser SSH 地址中的用户名，默认 git
+func sshUser(repoURL string) string {
+	if u, err := url.Parse(repoURL); err == nil && u.Scheme == "ssh" && u.User != nil && u.User.Username() != "" {
+		return u.User.Username()
+	}
+	return "git"
+}
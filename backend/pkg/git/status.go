@@ -0,0 +1,32 @@
+package git
+
+// 提交状态
+const (
+	StatusPending = "pending"
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+	StatusError   = "error"
+)
+
+// CommitStatus 提交状态，平台上同一 Context 的状态会被后一次覆盖
+type CommitStatus struct {
+	State       string // pending, success, failure, error
+	Context     string // 状态名称，如 ai-code-review
+	Description string
+	TargetURL   string
+}
+
+// StatusClient 在 Git 平台设置提交状态
+type StatusClient interface {
+	SetCommitStatus(commitSHA string, status CommitStatus) error
+}
+
+// truncateDescription 平台限制状态描述长度（GitHub 140 字符）
+func truncateDescription(s string) string {
+	const max = 140
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,216 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Dialect 描述目标平台支持的 Markdown 子集
+type Dialect struct {
+	Name       string
+	Headings   bool // 支持 # 标题，否则降级为粗体
+	Tables     bool // 支持表格，否则降级为列表
+	CodeBlocks bool // 支持 ``` 代码块，否则降级为引用
+	Rules      bool // 支持分隔线
+	Slack      bool // 使用 Slack mrkdwn 行内语法
+	MaxBytes   int  // 单条消息字节上限，0 表示不限制
+}
+
+// 预置方言
+var (
+	// Standard 标准 Markdown，原样输出
+	Standard = Dialect{Name: "markdown", Headings: true, Tables: true, CodeBlocks: true, Rules: true}
+	// DingTalk 钉钉机器人：不支持表格和代码块，单条消息约 20000 字节
+	DingTalk = Dialect{Name: "dingtalk", Headings: true, Rules: true, MaxBytes: 20000}
+	// Slack Slack mrkdwn：无标题和表格，粗体为 *text*，链接为 <url|text>
+	Slack = Dialect{Name: "slack", CodeBlocks: true, Slack: true, MaxBytes: 40000}
+	// WeCom 企业微信机器人：不支持表格，单条消息 4096 字节
+	WeCom = Dialect{Name: "wecom", Headings: true, MaxBytes: 4096}
+)
+
+// Convert 将 Markdown 文本转换为目标方言
+func Convert(text string, d Dialect) string {
+	return Render(Parse(text), d)
+}
+
+// Render 按方言输出块序列
+func Render(blocks []Block, d Dialect) string {
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if s := renderBlock(b, d); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func renderBlock(b Block, d Dialect) string {
+	switch b.Kind {
+	case BlockHeading:
+		text := inline(b.Lines[0], d)
+		if d.Headings {
+			return strings.Repeat("#", b.Level) + " " + text
+		}
+		return bold(inline(stripEmphasis(b.Lines[0]), d), d)
+
+	case BlockTable:
+		if d.Tables {
+			return renderTable(b.Rows)
+		}
+		return tableToList(b.Rows, d)
+
+	case BlockCode:
+		if d.CodeBlocks {
+			return "```" + langTag(b.Lang, d) + "\n" + strings.Join(b.Lines, "\n") + "\n```"
+		}
+		quoted := make([]string, 0, len(b.Lines))
+		for _, l := range b.Lines {
+			quoted = append(quoted, "> "+l)
+		}
+		return strings.Join(quoted, "\n")
+
+	case BlockQuote:
+		lines := make([]string, 0, len(b.Lines))
+		for _, l := range b.Lines {
+			lines = append(lines, "> "+inline(l, d))
+		}
+		return strings.Join(lines, "\n")
+
+	case BlockRule:
+		if d.Rules {
+			return "---"
+		}
+		return "──────────"
+
+	case BlockList:
+		lines := make([]string, 0, len(b.Lines))
+		for _, l := range b.Lines {
+			if d.Slack {
+				// mrkdwn 没有列表语法，统一使用圆点
+				l = listItemRe.ReplaceAllStringFunc(l, func(m string) string {
+					if strings.ContainsAny(m, ".)") {
+						return m
+					}
+					return strings.Replace(m, strings.TrimSpace(m), "•", 1)
+				})
+			}
+			lines = append(lines, inline(l, d))
+		}
+		return strings.Join(lines, "\n")
+
+	default:
+		lines := make([]string, 0, len(b.Lines))
+		for _, l := range b.Lines {
+			lines = append(lines, inline(l, d))
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+func renderTable(rows [][]string) string {
+	var sb strings.Builder
+	for i, row := range rows {
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			seps := make([]string, len(row))
+			for j := range seps {
+				seps[j] = "---"
+			}
+			sb.WriteString("| " + strings.Join(seps, " | ") + " |\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// tableToList 表格降级为列表，每行一个列表项
+func tableToList(rows [][]string, d Dialect) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	header := rows[0]
+	bullet := "- "
+	if d.Slack {
+		bullet = "• "
+	}
+
+	if len(rows) == 1 {
+		return bullet + inline(strings.Join(header, " / "), d)
+	}
+
+	lines := make([]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		cells := make([]string, 0, len(row))
+		for j, cell := range row {
+			if j < len(header) && header[j] != "" {
+				cells = append(cells, bold(inline(stripEmphasis(header[j]), d), d)+": "+inline(cell, d))
+			} else {
+				cells = append(cells, inline(cell, d))
+			}
+		}
+		lines = append(lines, bullet+strings.Join(cells, "，"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+var (
+	boldRe   = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	italicRe = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*]*?)\*([^*\w]|$)`)
+	strikeRe = regexp.MustCompile(`~~(.+?)~~`)
+	linkRe   = regexp.MustCompile(`!?\[([^\]]*)\]\(([^)\s]+)\)`)
+)
+
+// inline 转换行内语法
+func inline(s string, d Dialect) string {
+	if !d.Slack {
+		return s
+	}
+	// 先用占位符保护粗体，避免被斜体规则误处理
+	const boldMark = "\x00"
+	s = linkRe.ReplaceAllString(s, "<$2|$1>")
+	s = boldRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := boldRe.FindStringSubmatch(m)
+		text := sub[1]
+		if text == "" {
+			text = sub[2]
+		}
+		return boldMark + text + boldMark
+	})
+	s = italicRe.ReplaceAllString(s, "${1}_${2}_${3}")
+	s = strikeRe.ReplaceAllString(s, "~$1~")
+	return strings.ReplaceAll(s, boldMark, "*")
+}
+
+func bold(s string, d Dialect) string {
+	if d.Slack {
+		return "*" + s + "*"
+	}
+	return "**" + s + "**"
+}
+
+// stripEmphasis 去除已有的粗体标记，避免重复加粗
+func stripEmphasis(s string) string {
+	return boldRe.ReplaceAllString(s, "$1$2")
+}
+
+func langTag(lang string, d Dialect) string {
+	if d.Slack {
+		return "" // mrkdwn 不支持语言标记
+	}
+	return lang
+}
+
+// Truncate 按字节上限截断文本，保证不截断 UTF-8 字符
+func Truncate(s string, maxBytes int, suffix string) string {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s
+	}
+	limit := maxBytes - len(suffix)
+	if limit < 0 {
+		limit = 0
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit] + suffix
+}
@@ -0,0 +1,74 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvertDegradesUnsupportedConstructs(t *testing.T) {
+	src := "## 审查结果\n\n| 文件 | 问题 |\n| --- | --- |\n| a.go | **空指针** |\n\n```go\nx := 1\n```"
+
+	tests := []struct {
+		name    string
+		dialect Dialect
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "standard keeps everything",
+			dialect: Standard,
+			want:    []string{"## 审查结果", "| 文件 | 问题 |", "```go"},
+		},
+		{
+			name:    "dingtalk turns tables into lists and code into quotes",
+			dialect: DingTalk,
+			want:    []string{"## 审查结果", "- **文件**: a.go，**问题**: **空指针**", "> x := 1"},
+			notWant: []string{"| 文件", "```"},
+		},
+		{
+			name:    "wecom turns tables into lists",
+			dialect: WeCom,
+			want:    []string{"## 审查结果", "- **文件**: a.go"},
+			notWant: []string{"| 文件", "---"},
+		},
+		{
+			name:    "slack uses mrkdwn",
+			dialect: Slack,
+			want:    []string{"*审查结果*", "• *文件*: a.go，*问题*: *空指针*", "```\nx := 1\n```"},
+			notWant: []string{"##", "**", "```go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Convert(src, tt.dialect)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("Convert() missing %q in:\n%s", w, got)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("Convert() unexpectedly contains %q in:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSlackInline(t *testing.T) {
+	got := inline("see [docs](https://example.com) and ~~old~~ *new* **bold**", Slack)
+	want := "see <https://example.com|docs> and ~old~ _new_ *bold*"
+	if got != want {
+		t.Errorf("inline() = %q, want %q", got, want)
+	}
+}
+
+func TestTruncateKeepsRunes(t *testing.T) {
+	got := Truncate("中文内容", 8, "…")
+	if got != "中…" {
+		t.Errorf("Truncate() = %q, want %q", got, "中…")
+	}
+	if got := Truncate("short", 10, "…"); got != "short" {
+		t.Errorf("Truncate() = %q, want unchanged", got)
+	}
+}
@@ -0,0 +1,140 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// BlockKind 块类型
+type BlockKind int
+
+const (
+	BlockParagraph BlockKind = iota
+	BlockHeading
+	BlockList
+	BlockTable
+	BlockCode
+	BlockQuote
+	BlockRule
+)
+
+// Block 解析后的 Markdown 块
+type Block struct {
+	Kind  BlockKind
+	Level int        // 标题级别
+	Lang  string     // 代码块语言
+	Lines []string   // 段落、列表、引用、代码块的原始行
+	Rows  [][]string // 表格行，首行为表头
+}
+
+var (
+	headingRe   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	listItemRe  = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+	ruleRe      = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+	tableSepRe  = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	fenceOpenRe = regexp.MustCompile("^\\s*(```|~~~)\\s*([\\w+-]*)")
+)
+
+// Parse 将 Markdown 文本解析为块序列
+func Parse(text string) []Block {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var blocks []Block
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case fenceOpenRe.MatchString(line):
+			m := fenceOpenRe.FindStringSubmatch(line)
+			fence := m[1]
+			block := Block{Kind: BlockCode, Lang: m[2]}
+			i++
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+				block.Lines = append(block.Lines, lines[i])
+				i++
+			}
+			i++ // 跳过结束标记
+			blocks = append(blocks, block)
+
+		case headingRe.MatchString(trimmed):
+			m := headingRe.FindStringSubmatch(trimmed)
+			blocks = append(blocks, Block{Kind: BlockHeading, Level: len(m[1]), Lines: []string{m[2]}})
+			i++
+
+		case ruleRe.MatchString(trimmed):
+			blocks = append(blocks, Block{Kind: BlockRule})
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			block := Block{Kind: BlockQuote}
+			for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">") {
+				content := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				block.Lines = append(block.Lines, strings.TrimPrefix(content, " "))
+				i++
+			}
+			blocks = append(blocks, block)
+
+		case strings.Contains(trimmed, "|") && i+1 < len(lines) && tableSepRe.MatchString(lines[i+1]):
+			block := Block{Kind: BlockTable, Rows: [][]string{splitTableRow(trimmed)}}
+			i += 2
+			for i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != "" {
+				block.Rows = append(block.Rows, splitTableRow(strings.TrimSpace(lines[i])))
+				i++
+			}
+			blocks = append(blocks, block)
+
+		case listItemRe.MatchString(line):
+			block := Block{Kind: BlockList}
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" && isListContinuation(lines[i]) {
+				block.Lines = append(block.Lines, lines[i])
+				i++
+			}
+			blocks = append(blocks, block)
+
+		default:
+			block := Block{Kind: BlockParagraph}
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" && !startsBlock(lines, i) {
+				block.Lines = append(block.Lines, lines[i])
+				i++
+			}
+			if len(block.Lines) == 0 {
+				// 无法识别的行，按段落处理避免死循环
+				block.Lines = append(block.Lines, lines[i])
+				i++
+			}
+			blocks = append(blocks, block)
+		}
+	}
+
+	return blocks
+}
+
+// isListContinuation 列表项或其缩进的续行
+func isListContinuation(line string) bool {
+	return listItemRe.MatchString(line) || strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")
+}
+
+// startsBlock 判断第 i 行是否开启新的块
+func startsBlock(lines []string, i int) bool {
+	line := lines[i]
+	trimmed := strings.TrimSpace(line)
+	if fenceOpenRe.MatchString(line) || headingRe.MatchString(trimmed) || ruleRe.MatchString(trimmed) ||
+		strings.HasPrefix(trimmed, ">") || listItemRe.MatchString(line) {
+		return true
+	}
+	return strings.Contains(trimmed, "|") && i+1 < len(lines) && tableSepRe.MatchString(lines[i+1])
+}
+
+// splitTableRow 拆分表格行的单元格
+func splitTableRow(row string) []string {
+	row = strings.TrimPrefix(strings.TrimSuffix(row, "|"), "|")
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
@@ -0,0 +1,155 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+)
+
+// partHeaderReserve 为分段编号预留的字节数
+const partHeaderReserve = 32
+
+// ConvertAndSplit 转换为目标方言，并在超出 MaxBytes 时按章节拆分为多段
+// 拆分优先在标题（如审查结果中每个文件的 ### 文件名）处进行，其次在块之间，
+// 单个块仍超长时按行拆分，代码块拆开后每段各自带上围栏。多段消息会在开头加上 (1/3) 形式的编号，
+// 编号只加在正文中（企业微信、Slack 等渠道不显示标题），发送时标题保持不变。
+func ConvertAndSplit(text string, d Dialect) []string {
+	blocks := Parse(text)
+	if d.MaxBytes <= 0 {
+		return []string{Render(blocks, d)}
+	}
+
+	whole := Render(blocks, d)
+	if len(whole) <= d.MaxBytes {
+		return []string{whole}
+	}
+
+	limit := d.MaxBytes - partHeaderReserve
+	if limit <= 0 {
+		limit = d.MaxBytes
+	}
+
+	// 按标题分节
+	var sections [][]renderedBlock
+	for _, b := range blocks {
+		rendered := renderBlock(b, d)
+		if rendered == "" {
+			continue
+		}
+		if b.Kind == BlockHeading || len(sections) == 0 {
+			sections = append(sections, nil)
+		}
+		last := len(sections) - 1
+		sections[last] = append(sections[last], renderedBlock{block: b, text: rendered})
+	}
+
+	var parts []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+	}
+	appendPiece := func(piece string) {
+		sep := 0
+		if current.Len() > 0 {
+			sep = 2
+		}
+		if current.Len()+sep+len(piece) > limit {
+			flush()
+			sep = 0
+		}
+		if sep > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(piece)
+	}
+
+	for _, section := range sections {
+		texts := make([]string, len(section))
+		for i, rb := range section {
+			texts[i] = rb.text
+		}
+		sectionText := strings.Join(texts, "\n\n")
+		if len(sectionText) <= limit {
+			// 整节放得下时不拆开，放不进当前段则另起一段
+			appendPiece(sectionText)
+			continue
+		}
+		for _, rb := range section {
+			pieces := splitLines(rb.text, limit)
+			if rb.block.Kind == BlockCode && d.CodeBlocks {
+				pieces = splitCode(rb.block, d, limit)
+			}
+			for _, piece := range pieces {
+				appendPiece(piece)
+			}
+		}
+	}
+	flush()
+
+	if len(parts) <= 1 {
+		return parts
+	}
+	for i := range parts {
+		parts[i] = bold(fmt.Sprintf("(%d/%d)", i+1, len(parts)), d) + "\n\n" + parts[i]
+	}
+	return parts
+}
+
+// renderedBlock 块及其渲染结果
+type renderedBlock struct {
+	block Block
+	text  string
+}
+
+// splitCode 将超长代码块按行拆分，每段在末尾关闭围栏、在下一段开头按原语言重新打开，
+// 避免某一段以未关闭的围栏结尾、下一段以裸代码开头
+func splitCode(b Block, d Dialect, limit int) []string {
+	open := "```" + langTag(b.Lang, d) + "\n"
+	const close = "\n```"
+	inner := limit - len(open) - len(close)
+	if inner <= 0 {
+		return splitLines(renderBlock(b, d), limit)
+	}
+	pieces := splitLines(strings.Join(b.Lines, "\n"), inner)
+	for i, piece := range pieces {
+		pieces[i] = open + piece + close
+	}
+	return pieces
+}
+
+// splitLines 将超长块按行拆分，单行超长时强制截断
+func splitLines(block string, limit int) []string {
+	if len(block) <= limit {
+		return []string{block}
+	}
+	var pieces []string
+	var current strings.Builder
+	for _, line := range strings.Split(block, "\n") {
+		for len(line) > limit {
+			if current.Len() > 0 {
+				pieces = append(pieces, current.String())
+				current.Reset()
+			}
+			head := Truncate(line, limit, "")
+			if head == "" {
+				head = line[:limit]
+			}
+			pieces = append(pieces, head)
+			line = line[len(head):]
+		}
+		if current.Len() > 0 && current.Len()+1+len(line) > limit {
+			pieces = append(pieces, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n")
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		pieces = append(pieces, current.String())
+	}
+	return pieces
+}
@@ -0,0 +1,123 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConvertAndSplitFitsInOnePart(t *testing.T) {
+	parts := ConvertAndSplit("### a.go\n没有问题", DingTalk)
+	if len(parts) != 1 {
+		t.Fatalf("got %d parts, want 1", len(parts))
+	}
+	if strings.Contains(parts[0], "(1/1)") {
+		t.Errorf("single part should not be numbered: %q", parts[0])
+	}
+}
+
+func TestConvertAndSplitAtSections(t *testing.T) {
+	d := Dialect{Name: "test", Headings: true, MaxBytes: 200}
+	var src strings.Builder
+	for i := 1; i <= 4; i++ {
+		fmt.Fprintf(&src, "### file%d.go\n%s\n\n", i, strings.Repeat("x", 80))
+	}
+
+	parts := ConvertAndSplit(src.String(), d)
+	if len(parts) < 2 {
+		t.Fatalf("got %d parts, want at least 2", len(parts))
+	}
+	seen := 0
+	for i, p := range parts {
+		if len(p) > d.MaxBytes {
+			t.Errorf("part %d is %d bytes, limit %d", i+1, len(p), d.MaxBytes)
+		}
+		prefix := fmt.Sprintf("**(%d/%d)**\n\n", i+1, len(parts))
+		if !strings.HasPrefix(p, prefix) {
+			t.Errorf("part %d missing number prefix: %q", i+1, p)
+		}
+		if strings.Count(p, fmt.Sprintf("(%d/%d)", i+1, len(parts))) != 1 {
+			t.Errorf("part %d numbered more than once: %q", i+1, p)
+		}
+		// 每段从文件标题开始，文件的内容不会跨段
+		body := strings.TrimPrefix(p, prefix)
+		if !strings.HasPrefix(body, "### file") {
+			t.Errorf("part %d does not start at a section: %q", i+1, body)
+		}
+		seen += strings.Count(body, "### file")
+	}
+	if seen != 4 {
+		t.Errorf("got %d sections across parts, want 4", seen)
+	}
+}
+
+func TestConvertAndSplitLongBlock(t *testing.T) {
+	d := Dialect{Name: "test", MaxBytes: 100}
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %02d %s", i, strings.Repeat("y", 10))
+	}
+
+	parts := ConvertAndSplit(strings.Join(lines, "\n"), d)
+	var joined []string
+	for i, p := range parts {
+		if len(p) > d.MaxBytes {
+			t.Errorf("part %d is %d bytes, limit %d", i+1, len(p), d.MaxBytes)
+		}
+		body := p[strings.Index(p, "\n\n")+2:]
+		joined = append(joined, strings.Split(body, "\n")...)
+	}
+	if strings.Join(joined, "\n") != strings.Join(lines, "\n") {
+		t.Errorf("lines lost or reordered across parts:\n%s", strings.Join(joined, "\n"))
+	}
+}
+
+func TestConvertAndSplitLongCodeBlock(t *testing.T) {
+	d := Dialect{Name: "test", MaxBytes: 120, CodeBlocks: true}
+	code := make([]string, 20)
+	for i := range code {
+		code[i] = fmt.Sprintf("x%02d := %s", i, strings.Repeat("z", 10))
+	}
+	text := "### main.go\n\n建议修改为：\n\n```go\n" + strings.Join(code, "\n") + "\n```\n\n以上。"
+
+	parts := ConvertAndSplit(text, d)
+	if len(parts) < 3 {
+		t.Fatalf("got %d parts, want at least 3", len(parts))
+	}
+	var joined []string
+	for i, p := range parts {
+		if len(p) > d.MaxBytes {
+			t.Errorf("part %d is %d bytes, limit %d", i+1, len(p), d.MaxBytes)
+		}
+		// 每段的围栏成对出现，代码段以带语言的围栏开头
+		fences := 0
+		for _, line := range strings.Split(p, "\n") {
+			if strings.HasPrefix(line, "```") {
+				if fences%2 == 0 && line != "```go" {
+					t.Errorf("part %d opens fence %q, want ```go", i+1, line)
+				}
+				fences++
+				continue
+			}
+			if strings.HasPrefix(line, "x") {
+				joined = append(joined, line)
+			}
+		}
+		if fences%2 != 0 {
+			t.Errorf("part %d has unbalanced code fences:\n%s", i+1, p)
+		}
+	}
+	if strings.Join(joined, "\n") != strings.Join(code, "\n") {
+		t.Errorf("code lines lost or reordered across parts:\n%s", strings.Join(joined, "\n"))
+	}
+}
+
+func TestSplitLinesHardWrapsLongLine(t *testing.T) {
+	pieces := splitLines(strings.Repeat("中", 10), 9)
+	if len(pieces) != 4 {
+		t.Fatalf("got %d pieces, want 4: %q", len(pieces), pieces)
+	}
+	if strings.Join(pieces, "") != strings.Repeat("中", 10) {
+		t.Errorf("pieces do not rejoin: %q", pieces)
+	}
+}
@@ -0,0 +1,117 @@
+package dingtalk
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"backend/pkg/dingtalk"
+	"backend/pkg/markdown"
+	"backend/pkg/notifier"
+)
+
+// Type 渠道标识
+const Type = "dingtalk"
+
+func init() {
+	notifier.Register(&Notifier{})
+}
+
+// Notifier 钉钉机器人推送渠道
+type Notifier struct{}
+
+func (n *Notifier) Type() string { return Type }
+
+func (n *Notifier) Name() string { return "钉钉" }
+
+func (n *Notifier) Schema() []notifier.Field {
+	return []notifier.Field{
+		{Key: "webhook_url", Label: "Webhook URL", Type: notifier.FieldTypeText, Required: true, Placeholder: "https://oapi.dingtalk.com/robot/send?access_token=xxx"},
+		{Key: "access_token", Label: "AccessToken", Type: notifier.FieldTypePassword, Required: true, Placeholder: "钉钉机器人AccessToken"},
+		{Key: "secret", Label: "Secret", Type: notifier.FieldTypePassword, Placeholder: "钉钉机器人Secret"},
+	}
+}
+
+func (n *Notifier) Dialect() markdown.Dialect { return markdown.DingTalk }
+
+func (n *Notifier) Validate(cfg notifier.Config) error {
+	if cfg.String("access_token") == "" {
+		return errors.New("无效的钉钉AccessToken")
+	}
+	return notifier.ValidateRequired(n, cfg)
+}
+
+func (n *Notifier) Send(cfg notifier.Config, msg notifier.Message) (*notifier.Trace, error) {
+	if cfg.String("access_token") == "" {
+		return nil, errors.New("access_token is required for DingTalk target")
+	}
+	client := dingtalk.NewClient(cfg.String("access_token"), cfg.String("secret"))
+	res, err := client.Do(cfg.String("webhook_url"), dingtalk.Message{
+		MsgType: "markdown",
+		Markdown: dingtalk.MarkdownContent{
+			Title: msg.Title,
+			Text:  msg.Content,
+		},
+	})
+
+	var trace *notifier.Trace
+	if res != nil {
+		trace = &notifier.Trace{
+			Method:       "POST",
+			URL:          notifier.RedactURL(res.URL),
+			RequestBody:  res.RequestBody,
+			StatusCode:   res.StatusCode,
+			ResponseBody: res.ResponseBody,
+			Latency:      res.Latency,
+		}
+		if res.ErrCode != 0 {
+			trace.ErrCode = strconv.Itoa(res.ErrCode)
+		}
+	}
+
+	var apiErr *dingtalk.APIError
+	if errors.As(err, &apiErr) {
+		sendErr := &notifier.SendError{
+			StatusCode: apiErr.StatusCode,
+			Message:    apiErr.Error(),
+			Retryable:  notifier.RetryableStatus(apiErr.StatusCode) || retryableErrCodes[apiErr.ErrCode],
+		}
+		if apiErr.ErrCode != 0 {
+			sendErr.Code = strconv.Itoa(apiErr.ErrCode)
+		}
+		return trace, sendErr
+	}
+	return trace, err
+}
+
+// retryableErrCodes 可重试的钉钉错误码：-1 系统繁忙，130101 发送太快被限流
+var retryableErrCodes = map[int]bool{
+	-1:     true,
+	130101: true,
+}
+
+func (n *Notifier) Test(cfg notifier.Config, targetName string) (map[string]interface{}, error) {
+	if cfg.String("access_token") == "" {
+		return nil, errors.New("access_token不能为空")
+	}
+
+	content := `## 代码提交通知
+
+**这是一条测试消息**
+
+- 推送目标: ` + targetName + `
+- 测试时间: ` + time.Now().Format("2006-01-02 15:04:05") + `
+- 状态: 正常
+
+如果收到此消息，说明钉钉配置正确。`
+
+	if _, err := n.Send(cfg, notifier.Message{Title: "推送通知测试", Content: content}); err != nil {
+		return nil, errors.New("发送失败: " + err.Error())
+	}
+
+	return map[string]interface{}{
+		"status":  "success",
+		"message": "测试消息已发送",
+		"type":    Type,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// SendError 渠道返回的发送错误
+type SendError struct {
+	StatusCode int    // HTTP 状态码，未收到响应时为 0
+	Code       string // 渠道错误码，如钉钉 errcode
+	Message    string
+	Retryable  bool // 是否为可重试的临时错误（超时、5xx、限流等）
+}
+
+func (e *SendError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s (errcode=%s)", e.Message, e.Code)
+	}
+	return e.Message
+}
+
+// RetryableStatus HTTP 状态码是否表示临时错误
+func RetryableStatus(statusCode int) bool {
+	return statusCode == 429 || statusCode == 408 || statusCode >= 500
+}
+
+// IsRetryable 判断发送错误是否值得重试
+// 渠道明确返回的 SendError 以其标记为准；网络超时、连接失败等传输层错误视为可重试。
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return sendErr.Retryable
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,159 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"backend/pkg/markdown"
+)
+
+// ErrUnsupportedType 未注册的推送渠道
+var ErrUnsupportedType = errors.New("不支持的推送类型")
+
+// Config 推送目标配置（JSON对象）
+type Config map[string]interface{}
+
+// String 读取字符串配置项
+func (c Config) String(key string) string {
+	if v, ok := c[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// StringWithDefault 读取字符串配置项，为空时返回默认值
+func (c Config) StringWithDefault(key, defaultVal string) string {
+	if v := c.String(key); v != "" {
+		return v
+	}
+	return defaultVal
+}
+
+// StringMap 读取键值对配置项（如 headers）
+func (c Config) StringMap(key string) map[string]string {
+	result := make(map[string]string)
+	switch m := c[key].(type) {
+	case map[string]string:
+		for k, v := range m {
+			result[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range m {
+			result[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	return result
+}
+
+// Message 待发送的消息
+type Message struct {
+	Title   string
+	Content string // Markdown 内容
+}
+
+// Field 配置项描述，供前端动态生成表单
+type Field struct {
+	Key         string   `json:"key"`
+	Label       string   `json:"label"`
+	Type        string   `json:"type"` // text, password, select, map
+	Required    bool     `json:"required"`
+	Placeholder string   `json:"placeholder,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// 配置项类型
+const (
+	FieldTypeText     = "text"
+	FieldTypePassword = "password"
+	FieldTypeSelect   = "select"
+	FieldTypeMap      = "map"
+)
+
+// Notifier 推送渠道接口
+type Notifier interface {
+	// Type 渠道标识，对应 Target.Type
+	Type() string
+	// Name 渠道显示名称
+	Name() string
+	// Schema 配置项描述
+	Schema() []Field
+	// Dialect 渠道支持的 Markdown 方言
+	Dialect() markdown.Dialect
+	// Validate 校验配置
+	Validate(cfg Config) error
+	// Send 发送消息，返回本次请求的记录（未发出请求时为 nil）
+	Send(cfg Config, msg Message) (*Trace, error)
+	// Test 发送测试消息
+	Test(cfg Config, targetName string) (map[string]interface{}, error)
+}
+
+// Info 渠道描述
+type Info struct {
+	Type   string  `json:"type"`
+	Name   string  `json:"name"`
+	Schema []Field `json:"schema"`
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Notifier)
+)
+
+// Register 注册推送渠道，通常在渠道包的 init 中调用
+func Register(n Notifier) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[n.Type()]; ok {
+		panic("notifier: duplicate registration for " + n.Type())
+	}
+	registry[n.Type()] = n
+}
+
+// Get 获取推送渠道
+func Get(notifierType string) (Notifier, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	n, ok := registry[notifierType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, notifierType)
+	}
+	return n, nil
+}
+
+// List 列出已注册的推送渠道
+func List() []Info {
+	mu.RLock()
+	defer mu.RUnlock()
+	infos := make([]Info, 0, len(registry))
+	for _, n := range registry {
+		infos = append(infos, Info{
+			Type:   n.Type(),
+			Name:   n.Name(),
+			Schema: n.Schema(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Type < infos[j].Type })
+	return infos
+}
+
+// ValidateRequired 按 Schema 校验必填项
+func ValidateRequired(n Notifier, cfg Config) error {
+	for _, f := range n.Schema() {
+		if !f.Required {
+			continue
+		}
+		if f.Type == FieldTypeMap {
+			if len(cfg.StringMap(f.Key)) == 0 {
+				return fmt.Errorf("%s不能为空", f.Label)
+			}
+			continue
+		}
+		if cfg.String(f.Key) == "" {
+			return fmt.Errorf("%s不能为空", f.Label)
+		}
+	}
+	return nil
+}
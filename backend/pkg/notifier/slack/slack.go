@@ -0,0 +1,117 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/pkg/markdown"
+	"backend/pkg/notifier"
+)
+
+// Type 渠道标识
+const Type = "slack"
+
+func init() {
+	notifier.Register(&Notifier{client: &http.Client{Timeout: 30 * time.Second}})
+}
+
+// Notifier Slack Incoming Webhook 推送渠道，消息使用 mrkdwn 格式
+type Notifier struct {
+	client *http.Client
+}
+
+func (n *Notifier) Type() string { return Type }
+
+func (n *Notifier) Name() string { return "Slack" }
+
+func (n *Notifier) Schema() []notifier.Field {
+	return []notifier.Field{
+		{Key: "webhook_url", Label: "Webhook URL", Type: notifier.FieldTypeText, Required: true, Placeholder: "https://hooks.slack.com/services/T000/B000/XXXX"},
+	}
+}
+
+func (n *Notifier) Dialect() markdown.Dialect { return markdown.Slack }
+
+func (n *Notifier) Validate(cfg notifier.Config) error {
+	if !strings.HasPrefix(cfg.String("webhook_url"), "https://") {
+		return errors.New("无效的 Slack Webhook URL")
+	}
+	return notifier.ValidateRequired(n, cfg)
+}
+
+// Send 发送消息，标题只用于通知预览（Slack 以 text 作为预览），正文中通常已包含标题
+func (n *Notifier) Send(cfg notifier.Config, msg notifier.Message) (*notifier.Trace, error) {
+	return n.do(cfg, msg.Content)
+}
+
+func (n *Notifier) Test(cfg notifier.Config, targetName string) (map[string]interface{}, error) {
+	content := "*推送通知测试*\n\n" +
+		"• 推送目标: " + targetName + "\n" +
+		"• 测试时间: " + time.Now().Format("2006-01-02 15:04:05") + "\n\n" +
+		"如果收到此消息，说明 Slack 配置正确。"
+	if _, err := n.do(cfg, content); err != nil {
+		return nil, errors.New("发送失败: " + err.Error())
+	}
+
+	return map[string]interface{}{
+		"status":  "success",
+		"message": "测试消息已发送",
+		"type":    Type,
+	}, nil
+}
+
+// do 发送 mrkdwn 文本，Slack 成功时返回 200 和 "ok"，失败时返回错误码文本（如 invalid_payload）
+func (n *Notifier) do(cfg notifier.Config, text string) (*notifier.Trace, error) {
+	webhookURL := cfg.String("webhook_url")
+	if webhookURL == "" {
+		return nil, errors.New("webhook_url不能为空")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"text": text, "mrkdwn": true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	trace := &notifier.Trace{
+		Method:      "POST",
+		URL:         redactWebhookURL(webhookURL),
+		RequestBody: string(body),
+	}
+	start := time.Now()
+	resp, err := n.client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	trace.Latency = time.Since(start)
+	if err != nil {
+		return trace, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1000))
+	trace.StatusCode = resp.StatusCode
+	trace.ResponseBody = strings.TrimSpace(string(respBody))
+
+	if resp.StatusCode != http.StatusOK {
+		trace.ErrCode = trace.ResponseBody
+		return trace, &notifier.SendError{
+			StatusCode: resp.StatusCode,
+			Code:       trace.ResponseBody,
+			Message:    "slack error: " + resp.Status,
+			Retryable:  notifier.RetryableStatus(resp.StatusCode),
+		}
+	}
+	return trace, nil
+}
+
+// redactWebhookURL 隐藏 Webhook URL 路径中的密钥（/services/T000/B000/XXXX 的最后一段）
+func redactWebhookURL(raw string) string {
+	redacted := notifier.RedactURL(raw)
+	if i := strings.LastIndex(redacted, "/"); i > len("https://") {
+		return redacted[:i+1] + "***"
+	}
+	return redacted
+}
@@ -0,0 +1,65 @@
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/pkg/notifier"
+)
+
+func TestSend(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	n := &Notifier{client: srv.Client()}
+	trace, err := n.Send(notifier.Config{"webhook_url": srv.URL + "/services/T1/B1/secret"}, notifier.Message{Title: "t", Content: "*hello*"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got["text"] != "*hello*" || got["mrkdwn"] != true {
+		t.Errorf("payload = %v", got)
+	}
+	if trace.StatusCode != 200 || trace.ResponseBody != "ok" {
+		t.Errorf("trace = %+v", trace)
+	}
+	if want := srv.URL + "/services/T1/B1/***"; trace.URL != want {
+		t.Errorf("trace.URL = %q, want %q", trace.URL, want)
+	}
+}
+
+func TestSendError(t *testing.T) {
+	tests := []struct {
+		status    int
+		body      string
+		retryable bool
+	}{
+		{http.StatusBadRequest, "invalid_payload", false},
+		{http.StatusTooManyRequests, "rate_limited", true},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			io.WriteString(w, tt.body)
+		}))
+		n := &Notifier{client: srv.Client()}
+		_, err := n.Send(notifier.Config{"webhook_url": srv.URL}, notifier.Message{Content: "x"})
+		srv.Close()
+
+		var sendErr *notifier.SendError
+		if !errors.As(err, &sendErr) {
+			t.Fatalf("Send() error = %v, want SendError", err)
+		}
+		if sendErr.Code != tt.body || sendErr.Retryable != tt.retryable {
+			t.Errorf("status %d: got code %q retryable %v", tt.status, sendErr.Code, sendErr.Retryable)
+		}
+	}
+}
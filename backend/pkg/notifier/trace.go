@@ -0,0 +1,68 @@
+package notifier
+
+import (
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Trace 一次发送请求的请求与响应，用于排查渠道发送失败
+type Trace struct {
+	Method       string
+	URL          string // 已脱敏
+	RequestBody  string
+	StatusCode   int // 未收到响应时为 0
+	ResponseBody string
+	ErrCode      string // 渠道错误码，如钉钉 errcode
+	Latency      time.Duration
+}
+
+// sensitiveParams 视为敏感信息的查询参数名片段
+var sensitiveParams = []string{"token", "secret", "sign", "key", "pass", "auth", "sig"}
+
+// RedactURL 隐藏 URL 中的敏感信息：用户密码和 token、secret、sign 等查询参数
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "***"
+	}
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+
+	query := u.Query()
+	for key, values := range query {
+		if !isSensitiveParam(key) {
+			continue
+		}
+		for i := range values {
+			values[i] = "***"
+		}
+	}
+	// 保持 *** 可读，不做转义
+	u.RawQuery = strings.ReplaceAll(query.Encode(), "%2A%2A%2A", "***")
+	return u.Redacted()
+}
+
+func isSensitiveParam(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveParams {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Truncate 截断过长的请求或响应内容，maxBytes 按字节计算且不截断多字节字符
+func Truncate(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "...(truncated)"
+}
@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/pkg/markdown"
+	"backend/pkg/notifier"
+)
+
+// Type 渠道标识
+const Type = "webhook"
+
+func init() {
+	notifier.Register(&Notifier{client: &http.Client{Timeout: 30 * time.Second}})
+}
+
+// Notifier 通用 Webhook 推送渠道，以 JSON 形式回调指定地址
+type Notifier struct {
+	client *http.Client
+}
+
+func (n *Notifier) Type() string { return Type }
+
+func (n *Notifier) Name() string { return "Webhook" }
+
+func (n *Notifier) Schema() []notifier.Field {
+	return []notifier.Field{
+		{Key: "webhook_url", Label: "Webhook URL", Type: notifier.FieldTypeText, Required: true, Placeholder: "https://example.com/webhook"},
+		{Key: "method", Label: "请求方法", Type: notifier.FieldTypeSelect, Default: "POST", Options: []string{"POST", "PUT"}},
+		{Key: "headers", Label: "请求头", Type: notifier.FieldTypeMap},
+	}
+}
+
+func (n *Notifier) Dialect() markdown.Dialect { return markdown.Standard }
+
+func (n *Notifier) Validate(cfg notifier.Config) error {
+	if cfg.String("webhook_url") == "" {
+		return errors.New("Webhook URL不能为空")
+	}
+	return notifier.ValidateRequired(n, cfg)
+}
+
+func (n *Notifier) Send(cfg notifier.Config, msg notifier.Message) (*notifier.Trace, error) {
+	payload := map[string]interface{}{
+		"event":     "notify",
+		"title":     msg.Title,
+		"content":   msg.Content,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	return n.do(cfg, payload)
+}
+
+func (n *Notifier) Test(cfg notifier.Config, targetName string) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"event":       "test",
+		"message":     "这是一条测试消息",
+		"target_name": targetName,
+		"timestamp":   time.Now().Format(time.RFC3339),
+	}
+
+	trace, err := n.do(cfg, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"status":      "success",
+		"message":     "测试消息已发送",
+		"type":        Type,
+		"status_code": trace.StatusCode,
+		"response":    trace.ResponseBody,
+	}, nil
+}
+
+// do 发送请求，返回请求记录，响应内容限制1000字符
+func (n *Notifier) do(cfg notifier.Config, payload map[string]interface{}) (*notifier.Trace, error) {
+	webhookURL := cfg.String("webhook_url")
+	if webhookURL == "" {
+		return nil, errors.New("webhook_url不能为空")
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	method := cfg.StringWithDefault("method", "POST")
+	req, err := http.NewRequest(method, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.New("创建请求失败: " + err.Error())
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range cfg.StringMap("headers") {
+		req.Header.Set(key, value)
+	}
+
+	trace := &notifier.Trace{
+		Method:      method,
+		URL:         notifier.RedactURL(webhookURL),
+		RequestBody: string(body),
+	}
+	start := time.Now()
+	resp, err := n.client.Do(req)
+	trace.Latency = time.Since(start)
+	if err != nil {
+		return trace, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1000))
+	trace.StatusCode = resp.StatusCode
+	trace.ResponseBody = strings.TrimSpace(string(respBody))
+
+	if resp.StatusCode >= 400 {
+		return trace, &notifier.SendError{
+			StatusCode: resp.StatusCode,
+			Message:    "返回状态码: " + resp.Status,
+			Retryable:  notifier.RetryableStatus(resp.StatusCode),
+		}
+	}
+
+	return trace, nil
+}
@@ -0,0 +1,134 @@
+package wecom
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/pkg/markdown"
+	"backend/pkg/notifier"
+)
+
+// Type 渠道标识
+const Type = "wecom"
+
+func init() {
+	notifier.Register(&Notifier{client: &http.Client{Timeout: 30 * time.Second}})
+}
+
+// Notifier 企业微信群机器人推送渠道
+type Notifier struct {
+	client *http.Client
+}
+
+func (n *Notifier) Type() string { return Type }
+
+func (n *Notifier) Name() string { return "企业微信" }
+
+func (n *Notifier) Schema() []notifier.Field {
+	return []notifier.Field{
+		{Key: "webhook_url", Label: "Webhook URL", Type: notifier.FieldTypeText, Required: true, Placeholder: "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=xxx"},
+	}
+}
+
+func (n *Notifier) Dialect() markdown.Dialect { return markdown.WeCom }
+
+func (n *Notifier) Validate(cfg notifier.Config) error {
+	if !strings.HasPrefix(cfg.String("webhook_url"), "https://") {
+		return errors.New("无效的企业微信 Webhook URL")
+	}
+	return notifier.ValidateRequired(n, cfg)
+}
+
+// Send 发送 markdown 消息，企业微信的 markdown 消息没有标题，正文中通常已包含标题
+func (n *Notifier) Send(cfg notifier.Config, msg notifier.Message) (*notifier.Trace, error) {
+	return n.do(cfg, msg.Content)
+}
+
+func (n *Notifier) Test(cfg notifier.Config, targetName string) (map[string]interface{}, error) {
+	content := "## 推送通知测试\n\n" +
+		"**这是一条测试消息**\n" +
+		"> 推送目标: " + targetName + "\n" +
+		"> 测试时间: " + time.Now().Format("2006-01-02 15:04:05") + "\n\n" +
+		"如果收到此消息，说明企业微信配置正确。"
+	if _, err := n.do(cfg, content); err != nil {
+		return nil, errors.New("发送失败: " + err.Error())
+	}
+
+	return map[string]interface{}{
+		"status":  "success",
+		"message": "测试消息已发送",
+		"type":    Type,
+	}, nil
+}
+
+// retryableErrCodes 可重试的企业微信错误码：-1 系统繁忙，45009 接口调用超过限制
+var retryableErrCodes = map[int]bool{
+	-1:    true,
+	45009: true,
+}
+
+// do 发送 markdown 消息，企业微信以 HTTP 200 返回 errcode，非 0 表示失败
+func (n *Notifier) do(cfg notifier.Config, content string) (*notifier.Trace, error) {
+	webhookURL := cfg.String("webhook_url")
+	if webhookURL == "" {
+		return nil, errors.New("webhook_url不能为空")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"content": content},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	trace := &notifier.Trace{
+		Method:      "POST",
+		URL:         notifier.RedactURL(webhookURL),
+		RequestBody: string(body),
+	}
+	start := time.Now()
+	resp, err := n.client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	trace.Latency = time.Since(start)
+	if err != nil {
+		return trace, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1000))
+	trace.StatusCode = resp.StatusCode
+	trace.ResponseBody = strings.TrimSpace(string(respBody))
+
+	if resp.StatusCode != http.StatusOK {
+		return trace, &notifier.SendError{
+			StatusCode: resp.StatusCode,
+			Message:    "返回状态码: " + resp.Status,
+			Retryable:  notifier.RetryableStatus(resp.StatusCode),
+		}
+	}
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return trace, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if result.ErrCode != 0 {
+		trace.ErrCode = strconv.Itoa(result.ErrCode)
+		return trace, &notifier.SendError{
+			StatusCode: resp.StatusCode,
+			Code:       trace.ErrCode,
+			Message:    "wecom error: " + result.ErrMsg,
+			Retryable:  retryableErrCodes[result.ErrCode],
+		}
+	}
+	return trace, nil
+}
@@ -0,0 +1,67 @@
+package wecom
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/pkg/notifier"
+)
+
+func TestSend(t *testing.T) {
+	var got struct {
+		MsgType  string `json:"msgtype"`
+		Markdown struct {
+			Content string `json:"content"`
+		} `json:"markdown"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+		io.WriteString(w, `{"errcode":0,"errmsg":"ok"}`)
+	}))
+	defer srv.Close()
+
+	n := &Notifier{client: srv.Client()}
+	trace, err := n.Send(notifier.Config{"webhook_url": srv.URL + "?key=secret"}, notifier.Message{Title: "t", Content: "## hi"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got.MsgType != "markdown" || got.Markdown.Content != "## hi" {
+		t.Errorf("payload = %+v", got)
+	}
+	if strings.Contains(trace.URL, "secret") {
+		t.Errorf("trace URL not redacted: %s", trace.URL)
+	}
+}
+
+func TestSendErrCode(t *testing.T) {
+	tests := []struct {
+		body      string
+		code      string
+		retryable bool
+	}{
+		{`{"errcode":93000,"errmsg":"invalid webhook url"}`, "93000", false},
+		{`{"errcode":45009,"errmsg":"api freq out of limit"}`, "45009", true},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, tt.body)
+		}))
+		n := &Notifier{client: srv.Client()}
+		trace, err := n.Send(notifier.Config{"webhook_url": srv.URL}, notifier.Message{Content: "x"})
+		srv.Close()
+
+		var sendErr *notifier.SendError
+		if !errors.As(err, &sendErr) {
+			t.Fatalf("Send() error = %v, want SendError", err)
+		}
+		if sendErr.Code != tt.code || sendErr.Retryable != tt.retryable || trace.ErrCode != tt.code {
+			t.Errorf("%s: got code %q retryable %v", tt.body, sendErr.Code, sendErr.Retryable)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket 令牌桶
+// 令牌以固定速率补充，容量为 burst；令牌不足时 Reserve 会预支令牌并返回需要等待的时长，
+// 因此并发调用者按预约先后依次放行。
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒补充的令牌数
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket 创建令牌桶，perMinute 为每分钟允许的次数
+func NewBucket(perMinute, burst int) *Bucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &Bucket{
+		rate:   float64(perMinute) / 60,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Reserve 取一个令牌，返回需要等待的时长（0 表示立即可用）
+func (b *Bucket) Reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 || b.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Wait 取一个令牌，必要时阻塞等待，返回实际等待的时长
+func (b *Bucket) Wait() time.Duration {
+	d := b.Reserve()
+	if d > 0 {
+		time.Sleep(d)
+	}
+	return d
+}
+
+// Limit 限流配置
+type Limit struct {
+	PerMinute int // 每分钟次数，<=0 表示不限流
+	Burst     int // 突发容量
+}
+
+type entry struct {
+	limit  Limit
+	bucket *Bucket
+}
+
+// Limiter 按键（如推送目标ID）维护独立的令牌桶
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*entry
+}
+
+// NewLimiter 创建限流器
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: make(map[string]*entry)}
+}
+
+// Wait 按 key 对应的令牌桶等待，限流配置变化时重建令牌桶
+func (l *Limiter) Wait(key string, limit Limit) time.Duration {
+	b := l.bucket(key, limit)
+	if b == nil {
+		return 0
+	}
+	return b.Wait()
+}
+
+// Reserve 按 key 对应的令牌桶预约令牌，返回需要等待的时长
+func (l *Limiter) Reserve(key string, limit Limit) time.Duration {
+	b := l.bucket(key, limit)
+	if b == nil {
+		return 0
+	}
+	return b.Reserve()
+}
+
+func (l *Limiter) bucket(key string, limit Limit) *Bucket {
+	if limit.PerMinute <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.buckets[key]
+	if !ok || e.limit != limit {
+		e = &entry{limit: limit, bucket: NewBucket(limit.PerMinute, limit.Burst)}
+		l.buckets[key] = e
+	}
+	return e.bucket
+}
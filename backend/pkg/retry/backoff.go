@@ -0,0 +1,52 @@
+package retry
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Policy 指数退避重试策略
+type Policy struct {
+	MaxAttempts int           // 最多执行次数（含首次）
+	BaseDelay   time.Duration // 首次重试的基础延迟
+	MaxDelay    time.Duration // 延迟上限
+}
+
+// DefaultPolicy 默认策略：最多 3 次，10 秒起步，最长 10 分钟
+var DefaultPolicy = Policy{
+	MaxAttempts: 3,
+	BaseDelay:   10 * time.Second,
+	MaxDelay:    10 * time.Minute,
+}
+
+// CanRetry 已执行 attempts 次后是否还能重试
+func (p Policy) CanRetry(attempts int) bool {
+	return attempts < p.MaxAttempts
+}
+
+// Delay 第 attempts 次执行失败后的等待时长
+// 延迟按 BaseDelay * 2^(attempts-1) 增长并受 MaxDelay 限制，
+// 实际取值在 [delay/2, delay] 之间随机，避免大量任务同时重试。
+func (p Policy) Delay(attempts int) time.Duration {
+	base, max := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = DefaultPolicy.BaseDelay
+	}
+	if max <= 0 {
+		max = DefaultPolicy.MaxDelay
+	}
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
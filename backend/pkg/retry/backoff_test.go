@@ -0,0 +1,58 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCanRetry(t *testing.T) {
+	p := Policy{MaxAttempts: 3}
+	for attempts, want := range map[int]bool{0: true, 1: true, 2: true, 3: false, 4: false} {
+		if got := p.CanRetry(attempts); got != want {
+			t.Errorf("CanRetry(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestDelayGrowsExponentially(t *testing.T) {
+	p := Policy{BaseDelay: 10 * time.Second, MaxDelay: time.Hour}
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 10 * time.Second}, // 不足 1 次按 1 次计算
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{5, 160 * time.Second},
+	}
+	for _, c := range cases {
+		for i := 0; i < 50; i++ {
+			got := p.Delay(c.attempts)
+			if got < c.want/2 || got > c.want {
+				t.Fatalf("Delay(%d) = %v, want within [%v, %v]", c.attempts, got, c.want/2, c.want)
+			}
+		}
+	}
+}
+
+func TestDelayCappedByMaxDelay(t *testing.T) {
+	p := Policy{BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+	for _, attempts := range []int{6, 10, 100} {
+		got := p.Delay(attempts)
+		if got < 15*time.Second || got > 30*time.Second {
+			t.Errorf("Delay(%d) = %v, want within [15s, 30s]", attempts, got)
+		}
+	}
+}
+
+func TestDelayFallsBackToDefaults(t *testing.T) {
+	got := Policy{}.Delay(1)
+	if got < DefaultPolicy.BaseDelay/2 || got > DefaultPolicy.BaseDelay {
+		t.Errorf("Delay(1) = %v, want within default base delay", got)
+	}
+	got = Policy{}.Delay(100)
+	if got < DefaultPolicy.MaxDelay/2 || got > DefaultPolicy.MaxDelay {
+		t.Errorf("Delay(100) = %v, want within default max delay", got)
+	}
+}
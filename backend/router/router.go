@@ -7,16 +7,24 @@ import (
 	"backend/internal/middleware"
 	"backend/internal/services"
 	"backend/internal/utils"
+	"backend/pkg/git"
+	"backend/pkg/ratelimit"
+	"backend/pkg/retry"
 	"backend/static"
+	"backend/utils/logger"
+	"context"
 	"fmt"
+	"sync"
 
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func Setup(cfg *config.Config) *gin.Engine {
+// Setup 创建路由并启动后台任务，返回的 shutdown 用于优雅停机时停止后台任务
+func Setup(cfg *config.Config) (*gin.Engine, func(ctx context.Context)) {
 	// 初始化数据库连接
 	db, err := database.Init(database.DatabaseConfig{
 		Driver:  cfg.Database.Driver,
@@ -48,13 +56,65 @@ func Setup(cfg *config.Config) *gin.Engine {
 	generateTemplateService := services.NewGenerateTemplateService(db)
 	promptService := services.NewPromptService(db)
 	modelService := services.NewAIModelService(db)
-	pushService := services.NewPushService(db)
 
 	baseURL := ""
 	if cfg.App.Host != "" && cfg.App.Host != "0.0.0.0" && cfg.App.Port != 0 {
 		baseURL = fmt.Sprintf("http://%s:%d", cfg.App.Host, cfg.App.Port)
 	}
-	webhookService := services.NewWebhookService(db, baseURL)
+	rateLimits := make(map[string]ratelimit.Limit, len(cfg.Delivery.RateLimits))
+	for targetType, l := range cfg.Delivery.RateLimits {
+		rateLimits[targetType] = ratelimit.Limit{PerMinute: l.PerMinute, Burst: l.Burst}
+	}
+	deliveryService := services.NewDeliveryService(db, rateLimits, services.CircuitBreaker{
+		Threshold:     cfg.Delivery.CircuitBreaker.Threshold,
+		AlertTargetID: cfg.Delivery.CircuitBreaker.AlertTargetID,
+	})
+	retryPolicy := retry.Policy{
+		MaxAttempts: cfg.Delivery.Retry.MaxAttempts,
+		BaseDelay:   time.Duration(cfg.Delivery.Retry.BaseDelay) * time.Second,
+		MaxDelay:    time.Duration(cfg.Delivery.Retry.MaxDelay) * time.Second,
+	}
+	queueOpts := services.QueueOptions{
+		Workers:     cfg.Queue.Workers,
+		HighWorkers: cfg.Queue.HighWorkers,
+		Retention:   time.Duration(cfg.Queue.RetentionDays) * 24 * time.Hour,
+	}
+	pushService := services.NewPushService(db, deliveryService, retryPolicy, queueOpts)
+	quietHoursService := services.NewQuietHoursService(db, deliveryService, pushService)
+	quietHoursService.Start(time.Minute)
+	reviewOpts := services.ReviewOptions{ContextLines: cfg.Git.ContextLines, KnownHostsFile: cfg.Git.KnownHosts}
+	if cfg.AI.ReviewCacheDays > 0 {
+		reviewOpts.CacheTTL = time.Duration(cfg.AI.ReviewCacheDays) * 24 * time.Hour
+	}
+	var mirrorService *services.MirrorService
+	mirrorCache, err := git.NewMirrorCache(cfg.Git.MirrorDir, int64(cfg.Git.MirrorMaxSize)<<20)
+	if err != nil {
+		// 镜像目录不可用时退回每次克隆到内存
+		logger.Warn("Git mirror cache disabled", map[string]interface{}{
+			"dir":   cfg.Git.MirrorDir,
+			"error": err.Error(),
+		})
+	} else {
+		reviewOpts.Mirror = mirrorCache
+		mirrorService = services.NewMirrorService(mirrorCache)
+		mirrorService.Start(time.Duration(cfg.Git.MirrorGCInterval) * time.Minute)
+	}
+	webhookService := services.NewWebhookService(db, baseURL, deliveryService, pushService, quietHoursService, reviewOpts, queueOpts)
+	queueService := services.NewQueueService(db, append(webhookService.Queues(), pushService.Queues()...)...)
+	for _, names := range []map[string]int{cfg.Queue.Workers, cfg.Queue.HighWorkers} {
+		for name := range names {
+			if !queueService.Has(name) {
+				logger.Warn("Unknown queue in config", map[string]interface{}{
+					"queue": name,
+				})
+			}
+		}
+	}
+	// 队列按配置创建、设置好回调后再启动执行者
+	pushService.Start()
+	webhookService.Start()
+	digestService := services.NewDigestService(db, deliveryService, quietHoursService, baseURL)
+	digestService.Start(time.Minute)
 
 	// 初始化处理器
 	authHandler := handlers.NewAuthHandler(authService)
@@ -65,7 +125,10 @@ func Setup(cfg *config.Config) *gin.Engine {
 	modelHandler := handlers.NewModelHandler(modelService, logService)
 	pushHandler := handlers.NewPushHandler(pushService)
 	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	digestHandler := handlers.NewDigestHandler(digestService)
+	quietHoursHandler := handlers.NewQuietHoursHandler(quietHoursService)
 	logHandler := handlers.NewLogHandler(logService)
+	queueHandler := handlers.NewQueueHandler(queueService, logService)
 
 	// 公共接口（无需认证）
 	publicAPI := r.Group("/api/v1/auth")
@@ -99,6 +162,10 @@ func Setup(cfg *config.Config) *gin.Engine {
 			repos.PUT("/:id", repoHandler.Update)
 			repos.DELETE("/:id", repoHandler.Delete)
 			repos.POST("/:id/test", repoHandler.TestWebhook)
+			repos.POST("/:id/ssh-key", repoHandler.GenerateSSHKey)
+			repos.DELETE("/:id/ssh-key", repoHandler.DeleteSSHKey)
+			repos.GET("/:id/ssh-host-keys", repoHandler.ScanHostKeys)
+			repos.DELETE("/:id/review-cache", repoHandler.ClearReviewCache)
 			repos.GET("/:id/targets", repoHandler.GetTargets)
 			repos.POST("/:id/targets", repoHandler.AddTarget)
 			repos.DELETE("/:id/targets/:targetId", repoHandler.RemoveTarget)
@@ -109,6 +176,7 @@ func Setup(cfg *config.Config) *gin.Engine {
 		{
 			targets.GET("", targetHandler.List)
 			targets.POST("", targetHandler.Create)
+			targets.GET("/types", targetHandler.Types)
 			targets.GET("/:id", targetHandler.Detail)
 			targets.PUT("/:id", targetHandler.Update)
 			targets.DELETE("/:id", targetHandler.Delete)
@@ -129,6 +197,24 @@ func Setup(cfg *config.Config) *gin.Engine {
 			pushes.GET("/stats", pushHandler.GetStats)
 		}
 
+		// 推送摘要
+		digests := api.Group("/digests")
+		{
+			digests.GET("", digestHandler.List)
+			digests.GET("/:id", digestHandler.Detail)
+			digests.POST("/flush", digestHandler.Flush)
+		}
+
+		// 免打扰
+		quietHours := api.Group("/quiet-hours")
+		{
+			quietHours.GET("/calendars", quietHoursHandler.Calendars)
+			quietHours.GET("/holidays", quietHoursHandler.Holidays)
+			quietHours.POST("/holidays/import", quietHoursHandler.ImportHolidays)
+			quietHours.DELETE("/holidays/:id", quietHoursHandler.DeleteHoliday)
+			quietHours.GET("/held", quietHoursHandler.HeldMessages)
+		}
+
 		// 消息模板
 		templates := api.Group("/templates")
 		{
@@ -192,8 +278,25 @@ func Setup(cfg *config.Config) *gin.Engine {
 			users.PUT("/:id/lock", lockUserHandler(db))
 		}
 
+		// 任务队列（仅管理员）
+		queues := api.Group("/queues")
+		queues.Use(middleware.AdminOnly())
+		{
+			queues.GET("", queueHandler.List)
+			queues.GET("/:name/jobs", queueHandler.Jobs)
+			queues.POST("/:name/pause", queueHandler.Pause)
+			queues.POST("/:name/resume", queueHandler.Resume)
+			queues.PUT("/:name/workers", queueHandler.SetWorkers)
+		}
+		jobs := api.Group("/jobs")
+		jobs.Use(middleware.AdminOnly())
+		{
+			jobs.POST("/:id/cancel", queueHandler.CancelJob)
+			jobs.PUT("/:id/priority", queueHandler.SetJobPriority)
+		}
+
 		// 个人设置
-		api.GET("/settings", settingsHandler)
+		api.GET("/settings", settingsHandler(quietHoursService))
 		api.PUT("/settings", updateSettingsHandler)
 	}
 
@@ -227,7 +330,48 @@ func Setup(cfg *config.Config) *gin.Engine {
 		c.Redirect(http.StatusFound, "/web/")
 	})
 
-	return r
+	shutdown := func(ctx context.Context) {
+		var wg sync.WaitGroup
+		stop := func(name string, fn func(ctx context.Context) error) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := fn(ctx); err != nil {
+					logger.Warn("Background service stopped with unfinished work", map[string]interface{}{
+						"service": name,
+						"error":   err.Error(),
+					})
+				}
+			}()
+		}
+		stop("webhook", webhookService.Shutdown)
+		stop("push_retry", pushService.Shutdown)
+		stop("digest", waitStop(digestService.Stop))
+		stop("quiet_hours", waitStop(quietHoursService.Stop))
+		if mirrorService != nil {
+			stop("git_mirror", waitStop(mirrorService.Stop))
+		}
+		wg.Wait()
+	}
+
+	return r, shutdown
+}
+
+// waitStop 将阻塞的 Stop 包装为受 ctx 限制的停止函数
+func waitStop(stop func()) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			stop()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 }
 
 // 用户管理处理器
@@ -283,17 +427,19 @@ func lockUserHandler(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-func settingsHandler(c *gin.Context) {
-	utils.Success(c, map[string]interface{}{
-		"notify": map[string]interface{}{
-			"channels":    []string{"dingtalk", "email"},
-			"quiet_hours": false,
-		},
-		"preferences": map[string]interface{}{
-			"language": "zh-CN",
-			"theme":    "light",
-		},
-	})
+func settingsHandler(quietHoursService *services.QuietHoursService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		utils.Success(c, map[string]interface{}{
+			"notify": map[string]interface{}{
+				"channels":    []string{"dingtalk", "email"},
+				"quiet_hours": quietHoursService.Enabled(),
+			},
+			"preferences": map[string]interface{}{
+				"language": "zh-CN",
+				"theme":    "light",
+			},
+		})
+	}
 }
 
 func updateSettingsHandler(c *gin.Context) {
@@ -67,6 +67,12 @@ const routes = [
         component: () => import("@/views/users/index.vue"),
         meta: { title: "用户管理", roles: ["admin"] },
       },
+      {
+        path: "queues",
+        name: "Queues",
+        component: () => import("@/views/queues/index.vue"),
+        meta: { title: "任务队列", roles: ["admin"] },
+      },
       {
         path: "logs/system",
         name: "SystemLog",
@@ -0,0 +1,30 @@
+import { $get, $post, $put } from '@/utils/request'
+
+export function getQueueList() {
+  return $get('/queues')
+}
+
+export function getQueueJobs(name, params) {
+  return $get(`/queues/${name}/jobs`, params)
+}
+
+export function pauseQueue(name) {
+  return $post(`/queues/${name}/pause`)
+}
+
+export function resumeQueue(name) {
+  return $post(`/queues/${name}/resume`)
+}
+
+// data: { workers, high_workers }，只传需要调整的字段
+export function setQueueWorkers(name, data) {
+  return $put(`/queues/${name}/workers`, data)
+}
+
+export function cancelJob(id) {
+  return $post(`/jobs/${id}/cancel`)
+}
+
+export function setJobPriority(id, priority) {
+  return $put(`/jobs/${id}/priority`, { priority })
+}
@@ -24,6 +24,22 @@ export function testWebhook(id) {
   return $post(`/repos/${id}/test`)
 }
 
+export function generateSSHKey(id) {
+  return $post(`/repos/${id}/ssh-key`)
+}
+
+export function deleteSSHKey(id) {
+  return $delete(`/repos/${id}/ssh-key`)
+}
+
+export function scanHostKeys(id) {
+  return $get(`/repos/${id}/ssh-host-keys`)
+}
+
+export function clearReviewCache(id) {
+  return $delete(`/repos/${id}/review-cache`)
+}
+
 export function getRepoTargets(id) {
   return $get(`/repos/${id}/targets`)
 }
@@ -31,3 +31,30 @@ export function addTargetRepo(targetId, repoIds) {
 export function removeTargetRepo(targetId, repoId) {
   return $delete(`/targets/${targetId}/repos/${repoId}`)
 }
+
+export function getTargetTypes() {
+  return $get('/targets/types')
+}
+
+export function getHolidayCalendars() {
+  return $get('/quiet-hours/calendars')
+}
+
+export function getHolidays(params) {
+  return $get('/quiet-hours/holidays', params)
+}
+
+export function importHolidays(calendar, file) {
+  const data = new FormData()
+  data.append('calendar', calendar)
+  data.append('file', file)
+  return $post('/quiet-hours/holidays/import', data)
+}
+
+export function deleteHoliday(id) {
+  return $delete(`/quiet-hours/holidays/${id}`)
+}
+
+export function getHeldMessages(params) {
+  return $get('/quiet-hours/held', params)
+}